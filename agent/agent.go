@@ -3,14 +3,19 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/api"
 	"github.com/aftermath2/hydrus/channel"
 	"github.com/aftermath2/hydrus/config"
 	"github.com/aftermath2/hydrus/graph"
 	"github.com/aftermath2/hydrus/lightning"
 	"github.com/aftermath2/hydrus/logger"
+	"github.com/aftermath2/hydrus/metrics"
+	"github.com/aftermath2/hydrus/notifier"
 
 	"github.com/go-co-op/gocron/v2"
 	"github.com/lightningnetwork/lnd/lnrpc"
@@ -33,22 +38,57 @@ type Agent interface {
 	CloseChannels(ctx context.Context, localNode local.Node) error
 	OpenChannels(ctx context.Context, localNode local.Node) error
 	UpdatePolicies(ctx context.Context, localNode local.Node) error
+	// PlanOpen returns the batch opening the channels chosen by hand, if they pass the agent's checks.
+	PlanOpen(ctx context.Context, localNode local.Node, manual ManualOpen) (channel.OpenRequest, error)
+	// PlanClose returns the request closing the channels chosen by hand, if they pass the agent's checks.
+	PlanClose(ctx context.Context, localNode local.Node, channelPoints []string) (channel.CloseRequest, error)
+	// ExplainPolicies returns the routing policy rule matching each local channel.
+	ExplainPolicies(ctx context.Context, localNode local.Node) ([]PolicyMatch, error)
+	// Reload replaces the configuration of a running agent.
+	Reload(config config.Agent) error
 }
 
 type agent struct {
 	lnd            lightning.Client
 	channelManager channel.Manager
 	logger         logger.Logger
-	config         config.Agent
+	notifier       *notifier.Dispatcher
+	// Minimum channel sizes learned from peers rejecting our channels
+	peerMinChannelSizes *minChannelSizes
+	state               *state
+	feeStrategies       *feeStrategies
+	graphCache          *graphCache
+	policyHistory       *policyHistory
+	balanceRecorder     *balanceRecorder
+	statusHistory       *statusHistory
+	reloader            *reloader
+	config              config.Agent
 }
 
 // New returns a new agent interface.
 func New(config config.Agent, lnd lightning.Client) Agent {
+	dispatcher := notifier.NewDispatcher(config.Notifiers)
+	channelManager := channel.NewManager(config.ChannelManager, config.DataDir, lnd, dispatcher)
+	feeStrategies := newFeeStrategies(config.Fees, config.Tags)
 	return &agent{
-		lnd:            lnd,
-		channelManager: channel.NewManager(config.ChannelManager, lnd),
-		logger:         logger.New("AGT"),
-		config:         config,
+		lnd:                 lnd,
+		channelManager:      channelManager,
+		logger:              logger.New("AGT"),
+		notifier:            dispatcher,
+		peerMinChannelSizes: newMinChannelSizes(),
+		state:               newState(config.DryRun),
+		feeStrategies:       feeStrategies,
+		graphCache:          newGraphCache(),
+		policyHistory:       newPolicyHistory(config.DataDir),
+		balanceRecorder:     newBalanceRecorder(config.DataDir),
+		statusHistory:       newStatusHistory(config.DataDir),
+		reloader: &reloader{
+			channelManager: channelManager,
+			notifier:       dispatcher,
+			feeStrategies:  feeStrategies,
+			config:         config,
+		},
+		config: config,
 	}
 }
 
@@ -58,36 +98,125 @@ func (a *agent) Run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	// Let the notifications in flight be delivered once the scheduler is shut down
+	defer func() { a.snapshot().notifier.Wait() }()
 
-	_, err = scheduler.NewJob(
+	channelsJob, err := scheduler.NewJob(
 		gocron.DurationJob(a.config.Intervals.Channels),
-		gocron.NewTask(a.channelsTask, ctx),
+		gocron.NewTask(a.runTask, ctx, channelsTaskName),
 		gocron.WithStartAt(gocron.WithStartImmediately()),
 	)
 	if err != nil {
 		return err
 	}
 
-	_, err = scheduler.NewJob(
+	routingPoliciesJob, err := scheduler.NewJob(
 		gocron.DurationJob(a.config.Intervals.RoutingPolicies),
-		gocron.NewTask(a.routingPoliciesTask, ctx),
+		gocron.NewTask(a.runTask, ctx, routingPoliciesTaskName),
 		gocron.WithStartAt(gocron.WithStartImmediately()),
 	)
 	if err != nil {
 		return err
 	}
 
+	a.reloader.setJobs(ctx, scheduler, map[string]gocron.Job{
+		channelsTaskName:        channelsJob,
+		routingPoliciesTaskName: routingPoliciesJob,
+	})
 	scheduler.Start()
 
+	serverErr := make(chan error, 2)
+	if a.config.Metrics.Enabled() {
+		go func() {
+			serverErr <- metrics.Serve(ctx, a.config.Metrics.Address)
+		}()
+	}
+
+	if a.config.API.Enabled() {
+		controller := &controller{
+			ctx:   ctx,
+			agent: a,
+			jobs: map[string]gocron.Job{
+				channelsTaskName:        channelsJob,
+				routingPoliciesTaskName: routingPoliciesJob,
+			},
+		}
+		server := api.NewServer(a.config.API, controller)
+
+		go func() {
+			serverErr <- server.Serve(ctx)
+		}()
+	}
+
 	select {
-	// Block until the context is cancelled
+	// Block until the context is cancelled or one of the servers fails
 	case <-ctx.Done():
 		a.logger.Info("Context canceled, shutting down")
+	case err := <-serverErr:
+		if err != nil {
+			if shutdownErr := scheduler.Shutdown(); shutdownErr != nil {
+				a.logger.Errorf("Shutting down scheduler: %v", shutdownErr)
+			}
+			return errors.Wrap(err, "running server")
+		}
 	}
 
 	return scheduler.Shutdown()
 }
 
+func (a *agent) tasks() map[string]func(ctx context.Context) error {
+	return map[string]func(ctx context.Context) error{
+		channelsTaskName:        a.channelsTask,
+		routingPoliciesTaskName: a.routingPoliciesTask,
+	}
+}
+
+// runTask executes a scheduled task, unless the agent is paused or the task is already running.
+func (a *agent) runTask(ctx context.Context, name string) error {
+	if err := a.state.begin(name, false); err != nil {
+		a.logger.Infof("Skipping %s task: %v", name, err)
+		// The runs skipped while paused are notified once per pause
+		if !errors.Is(err, errAgentPaused) || a.state.firstPausedSkip(name) {
+			a.snapshot().notifier.Notify(ctx, notifier.NewRunSkipped(name, err.Error()))
+		}
+		return nil
+	}
+
+	return a.execTask(ctx, name, false)
+}
+
+// execTask executes the task and records its results. The task must have been marked as running first.
+func (a *agent) execTask(ctx context.Context, name string, manual bool) error {
+	snapshot := a.snapshot()
+	startedAt := time.Now()
+	err := snapshot.tasks()[name](ctx)
+
+	run := api.Run{
+		StartedAt: startedAt,
+		Duration:  time.Since(startedAt).Round(time.Millisecond).String(),
+		Manual:    manual,
+	}
+	if err != nil {
+		a.logger.Errorf("Executing %s task: %v", name, err)
+		run.Error = err.Error()
+		metrics.TaskErrors.WithLabelValues(name).Inc()
+		snapshot.notifier.Notify(ctx, notifier.NewTaskError(name, err))
+	} else {
+		metrics.TaskLastSuccess.WithLabelValues(name).SetToCurrentTime()
+	}
+	a.state.end(name, run)
+
+	return err
+}
+
+// dryRun returns whether the agent should skip executing the actions it decides.
+func (a *agent) dryRun() bool {
+	if a.state == nil {
+		return a.config.DryRun
+	}
+	return a.state.isDryRun()
+}
+
 func (a *agent) channelsTask(ctx context.Context) error {
 	logger := logger.New("CHT")
 
@@ -96,13 +225,16 @@ func (a *agent) channelsTask(ctx context.Context) error {
 		return err
 	}
 	logger.Debugf("Local node: %s", localNode)
+	recordNodeMetrics(localNode)
 
 	if localNode.SatvB > a.config.ChannelManager.MaxSatvB {
-		logger.Infof(
-			"Skipping... The estimated transaction fee per virtual byte (%d) is higher than the maximum (%d)",
+		reason := fmt.Sprintf(
+			"The estimated transaction fee per virtual byte (%d) is higher than the maximum (%d)",
 			localNode.SatvB,
 			a.config.ChannelManager.MaxSatvB,
 		)
+		logger.Infof("Skipping... %s", reason)
+		a.notifier.Notify(ctx, notifier.NewRunSkipped(channelsTaskName, reason))
 		return nil
 	}
 
@@ -123,6 +255,11 @@ func (a *agent) routingPoliciesTask(ctx context.Context) error {
 		return err
 	}
 	logger.Debugf("Local node: %s", localNode)
+	recordNodeMetrics(localNode)
+
+	if err := a.balanceRecorder.record(time.Now(), localNode); err != nil {
+		logger.Errorf("Recording channels balances: %v", err)
+	}
 
 	logger.Info("Updating channels routing policies")
 	return a.UpdatePolicies(ctx, localNode)
@@ -142,7 +279,13 @@ func (a *agent) CloseChannels(ctx context.Context, localNode local.Node) error {
 	}
 	a.logger.Debugf("Channels heuristics: %s", heuristics)
 
-	candidates := getCandidateChannels(a.logger, localNode, a.config.Keeplist)
+	tagger, err := a.tagger(ctx)
+	if err != nil {
+		return err
+	}
+
+	candidates := getCandidateChannels(a.logger, localNode, tagger, a.config.Closing)
+	a.state.setChannels(candidates)
 
 	channels := a.selectChannels(localNode, candidates)
 	if len(channels) == 0 {
@@ -152,7 +295,7 @@ func (a *agent) CloseChannels(ctx context.Context, localNode local.Node) error {
 
 	a.logger.Infof("Closing channels: %v", channels)
 
-	if a.config.DryRun {
+	if a.dryRun() {
 		return nil
 	}
 
@@ -173,7 +316,12 @@ func (a *agent) OpenChannels(ctx context.Context, localNode local.Node) error {
 
 	a.logger.Info("Generating network graph")
 
-	networkGraph, err := graph.New(ctx, a.config.HeuristicWeights.Open, a.lnd)
+	channelGraph, err := a.graphCache.get(ctx, a.lnd)
+	if err != nil {
+		return errors.Wrap(err, "getting channel graph")
+	}
+
+	networkGraph, err := graph.FromChannelGraph(ctx, a.config.HeuristicWeights.Open, channelGraph)
 	if err != nil {
 		return errors.Wrap(err, "creating graph")
 	}
@@ -191,8 +339,11 @@ func (a *agent) OpenChannels(ctx context.Context, localNode local.Node) error {
 	}
 	a.logger.Debugf("Graph heuristics: %s", heuristics)
 
-	candidates := getCandidateNodes(a.logger, localNode, networkGraph, a.config.Blocklist)
-	nodes := a.selectNodes(ctx, localNode, candidates)
+	tagger := NewTagger(a.config, channelGraph)
+	candidates := getCandidateNodes(a.logger, localNode, networkGraph, tagger)
+	a.state.setNodes(candidates)
+	selector := newSelector(a.config.Selection, a.config.HeuristicWeights.Open.Centrality, localNode, networkGraph)
+	nodes := a.selectNodes(ctx, localNode, candidates, selector, true)
 	if len(nodes) == 0 {
 		a.logger.Info("No channels will be opened")
 		return nil
@@ -200,7 +351,7 @@ func (a *agent) OpenChannels(ctx context.Context, localNode local.Node) error {
 
 	a.logger.Infof("Opening channels: %#v", nodes)
 
-	if a.config.DryRun {
+	if a.dryRun() {
 		return nil
 	}
 
@@ -208,23 +359,43 @@ func (a *agent) OpenChannels(ctx context.Context, localNode local.Node) error {
 		Nodes: nodes,
 		SatvB: localNode.SatvB,
 	}
-	return a.channelManager.Open(ctx, req)
+	if err := a.channelManager.Open(ctx, req); err != nil {
+		if publicKey, minChannelSize, ok := parseMinChannelSize(err); ok {
+			a.logger.Infof("Peer %q requires channels of at least %d sats", publicKey, minChannelSize)
+			a.peerMinChannelSizes.set(publicKey, minChannelSize)
+		}
+		return err
+	}
+
+	return nil
 }
 
-func (a *agent) selectNodes(ctx context.Context, localNode local.Node, candidates []nodeCandidate) map[string]uint64 {
+// selectNodes returns the nodes to open channels with and their funding amounts. If connect is true, the
+// candidates we aren't connected to are connected to and discarded if it fails, otherwise the connection is
+// left to the channel manager.
+func (a *agent) selectNodes(
+	ctx context.Context,
+	localNode local.Node,
+	candidates []nodeCandidate,
+	selector selector,
+	connect bool,
+) map[string]uint64 {
 	if localNode.MaxOpenChannels < 1 {
 		return nil
 	}
 
-	nodes := make(map[string]uint64, localNode.MaxOpenChannels)
-	fundingAmount := min(localNode.AllocatedBalance/localNode.MaxOpenChannels, a.config.MaxChannelSize)
+	selected := make([]nodeCandidate, 0, localNode.MaxOpenChannels)
+	remaining := slices.Clone(candidates)
 
-	for _, candidate := range candidates {
-		if len(nodes) == int(localNode.MaxOpenChannels) {
-			break
-		}
+	for len(selected) < int(localNode.MaxOpenChannels) && len(remaining) > 0 {
+		i, gain := selector.next(selected, remaining)
+		candidate := remaining[i]
+		candidate.Gain = gain
+		remaining = slices.Delete(remaining, i, i+1)
 
-		if _, ok := localNode.SyncPeers[candidate.PublicKey]; !ok {
+		if _, ok := localNode.SyncPeers[candidate.PublicKey]; ok {
+			a.logger.Debugf("Already connected with peer %q", candidate.PublicKey)
+		} else if connect {
 			a.logger.Debugf("Connecting with peer %q", candidate.PublicKey)
 
 			// Try to connect to the peer and skip if we can't do it before the timeout
@@ -232,14 +403,31 @@ func (a *agent) selectNodes(ctx context.Context, localNode local.Node, candidate
 				a.logger.Debugf("Couldn't connect with peer %q: %v. Discarding", candidate.PublicKey, err)
 				continue
 			}
-		} else {
-			a.logger.Debugf("Already connected with peer %q", candidate.PublicKey)
 		}
 
-		nodes[candidate.PublicKey] = fundingAmount
+		selected = append(selected, candidate)
+	}
+
+	selectedB, _ := json.Marshal(selected)
+	a.logger.Infof("Selected nodes: %s", selectedB)
+
+	limits := fundingLimits{
+		peerMinChannelSizes: a.peerMinChannelSizes,
+		minChannelSize:      a.config.MinChannelSize,
+		maxChannelSize:      a.config.MaxChannelSize,
+		nodeCapacity:        nodeCapacity(localNode),
+	}
+	return newAllocator(a.config.FundingStrategy, limits).allocate(localNode, selected)
+}
+
+// keepChannel returns whether the candidate channel should be kept open according to the closing mode.
+func (a *agent) keepChannel(candidate channelCandidate, weightsSum float64) bool {
+	if a.config.Closing.Mode == config.ClosingModeProfitability {
+		return candidate.Profitability.ExpectedReturn >= 0
 	}
 
-	return nodes
+	normalizedScore := candidate.Score * (1 / weightsSum)
+	return normalizedScore > 0.5
 }
 
 func (a *agent) selectChannels(localNode local.Node, candidates []channelCandidate) map[string]bool {
@@ -247,16 +435,15 @@ func (a *agent) selectChannels(localNode local.Node, candidates []channelCandida
 
 	channels := make(map[string]bool, localNode.MaxCloseChannels)
 	for _, candidate := range candidates {
-		normalizedScore := candidate.Score * (1 / weightsSum)
-		// If we have reached the maximum number of channel to close or the score is above 0.3,
-		// skip the rest of the candidates
-		if len(channels) >= int(localNode.MaxCloseChannels) || normalizedScore > 0.5 {
+		// If we have reached the maximum number of channel to close or the candidate is worth keeping,
+		// skip the rest of the candidates as they are sorted from worst to best
+		if len(channels) >= int(localNode.MaxCloseChannels) || a.keepChannel(candidate, weightsSum) {
 			break
 		}
 
 		forceClose := false
 		if !candidate.Active {
-			if a.config.AllowForceCloses {
+			if candidate.Tags.allowForceCloses(a.config.AllowForceCloses) {
 				forceClose = true
 			} else {
 				// Do not force-close inactive channel, continue iterating the list
@@ -282,7 +469,38 @@ func (a *agent) UpdatePolicies(ctx context.Context, localNode local.Node) error
 		return nil
 	}
 
-	startTime := uint64(time.Now().Add(-a.config.Intervals.RoutingPolicies).Unix())
+	now := time.Now()
+	interval := a.config.Intervals.RoutingPolicies
+	startTime := uint64(now.Add(-interval).Unix())
+
+	if err := a.updateStatuses(ctx, localNode, now); err != nil {
+		return errors.Wrap(err, "updating channels status")
+	}
+
+	damping, err := newDamper(a.config.RoutingPolicies.Damping, a.policyHistory, now)
+	if err != nil {
+		return err
+	}
+
+	var (
+		deltas       *timeLockDeltas
+		markets      markets
+		channelGraph *lnrpc.ChannelGraph
+	)
+	if a.config.RoutingPolicies.TimeLockDelta.Enabled ||
+		usesFeeStrategy(a.config.Fees, a.config.Tags, config.FeeStrategyMarket) ||
+		usesPeerConditions(a.config.RoutingPolicies.Rules) ||
+		tagsUseGraph(a.config.Tags) {
+		channelGraph, err = a.graphCache.get(ctx, a.lnd)
+		if err != nil {
+			return errors.Wrap(err, "getting channel graph")
+		}
+
+		deltas = newTimeLockDeltas(channelGraph)
+		markets = newMarkets(channelGraph, localNode.PublicKey)
+	}
+	rules := newRuleMatcher(a.config.RoutingPolicies, channelGraph, localNode.CurrentBlockHeight, interval)
+	tagger := NewTagger(a.config, channelGraph)
 
 	for _, ch := range localNode.Channels.List {
 		policy, err := getChannelPolicy(ctx, a.lnd, localNode.PublicKey, ch)
@@ -296,58 +514,151 @@ func (a *agent) UpdatePolicies(ctx context.Context, localNode local.Node) error
 			return err
 		}
 
-		forwardsAmountIn := uint64(0)
-		forwardsAmountOut := uint64(0)
-		for _, forward := range forwards {
-			if ch.ID == forward.ChanIdIn {
-				forwardsAmountIn += forward.AmtIn
-			}
-			if ch.ID == forward.ChanIdOut {
-				forwardsAmountOut += forward.AmtOut
-			}
+		current := Policy{
+			BaseFeeMsat:        uint64(policy.FeeBaseMsat),
+			FeeRatePPM:         uint64(policy.FeeRateMilliMsat),
+			MinHTLCMsat:        uint64(policy.MinHtlc),
+			MaxHTLCMsat:        policy.MaxHtlcMsat,
+			TimeLockDelta:      uint64(policy.TimeLockDelta),
+			InboundBaseFeeMsat: policy.InboundFeeBaseMsat,
+			InboundFeeRatePPM:  policy.InboundFeeRateMilliMsat,
+		}
+		market := markets.get(ch.RemotePublicKey)
+		if cheapest, ok := market.Cheapest(0); ok {
+			a.logger.Debugf("Channel %q market: %d competitors. Fee rate percentiles 25th: %d ppm, "+
+				"median: %d ppm, 75th: %d ppm. Cheapest: %d ppm (%s)",
+				ch.Point,
+				len(market.Channels),
+				market.Percentile(0.25),
+				market.Median(),
+				market.Percentile(0.75),
+				cheapest.FeeRatePPM,
+				cheapest.PublicKey,
+			)
 		}
 
-		feeRatePPM := uint64(policy.FeeRateMilliMsat)
-		newFeeRatePPM := calculateNewFeeRate(
-			ch,
-			localNode.CurrentBlockHeight,
-			feeRatePPM,
-			forwardsAmountIn,
-			forwardsAmountOut,
-		)
-		newMaxHTLC := calculateNewMaxHTLC(ch)
+		state := ChannelState{
+			Channel:            ch,
+			CurrentBlockHeight: localNode.CurrentBlockHeight,
+			Market:             market,
+		}
+
+		rule, matched := rules.match(ch, forwards)
+		if matched && rule.Actions.Keep {
+			a.logger.Infof("Channel %q matches rule %q keeping its policy, skipping", ch.Point, rule.Name)
+			continue
+		}
+
+		newPolicy := current
+		// The fee strategy state is left untouched when a rule sets the fee rate
+		if !matched || rule.Actions.FeeRatePPM == nil {
+			strategy := a.feeStrategies.get(ch.Point, tagger.Channel(ch))
+			newPolicy = strategy.Policy(state, getForwards(ch.ID, forwards), current)
+		}
+		newPolicy = applyRoutingPolicies(a.config.RoutingPolicies, ch, deltas, forwards, interval, newPolicy)
+		if matched {
+			a.logger.Infof("Channel %q matches rule %q", ch.Point, rule.Name)
+			newPolicy = applyRule(rule.Actions, ch, newPolicy)
+		}
+
+		newPolicy, reason, err := damping.damp(ch.Point, current, newPolicy, now)
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			a.logger.Infof("Channel %q routing policy update damped: %s", ch.Point, reason)
+		}
 
 		// No changes required, skip
-		if newFeeRatePPM == feeRatePPM && newMaxHTLC == policy.MaxHtlcMsat {
+		if newPolicy == current {
 			a.logger.Infof("Channel %q requires no changes, skipping", ch.Point)
 			continue
 		}
 
-		a.logger.Infof("Updating %q channel policies. Fee rate: %d ppm. Max HTLC: %d",
+		a.logger.Infof("Updating %q channel policies. Base fee: %d msat. Fee rate: %d ppm. "+
+			"Min HTLC: %d. Max HTLC: %d. Time lock delta: %d. Inbound base fee: %d msat. Inbound fee rate: %d ppm",
 			ch.Point,
-			newFeeRatePPM,
-			newMaxHTLC,
+			newPolicy.BaseFeeMsat,
+			newPolicy.FeeRatePPM,
+			newPolicy.MinHTLCMsat,
+			newPolicy.MaxHTLCMsat,
+			newPolicy.TimeLockDelta,
+			newPolicy.InboundBaseFeeMsat,
+			newPolicy.InboundFeeRatePPM,
 		)
 
-		if a.config.DryRun {
+		if a.dryRun() {
 			continue
 		}
 
 		req := channel.UpdatePolicyRequest{
-			ChannelPoint:  ch.Point,
-			BaseFeeMsat:   uint64(policy.FeeBaseMsat),
-			FeeRatePPM:    newFeeRatePPM,
-			MaxHTLCMsat:   newMaxHTLC,
-			TimeLockDelta: uint64(policy.TimeLockDelta),
+			ChannelPoint:       ch.Point,
+			BaseFeeMsat:        newPolicy.BaseFeeMsat,
+			FeeRatePPM:         newPolicy.FeeRatePPM,
+			MinHTLCMsat:        newPolicy.MinHTLCMsat,
+			MaxHTLCMsat:        newPolicy.MaxHTLCMsat,
+			TimeLockDelta:      newPolicy.TimeLockDelta,
+			InboundBaseFeeMsat: newPolicy.InboundBaseFeeMsat,
+			InboundFeeRatePPM:  newPolicy.InboundFeeRatePPM,
 		}
 		if err := a.channelManager.UpdatePolicy(ctx, req); err != nil {
 			return err
 		}
+
+		damping.sent()
+		update := policyUpdate{
+			Time:               now,
+			PreviousFeeRatePPM: current.FeeRatePPM,
+			FeeRatePPM:         newPolicy.FeeRatePPM,
+		}
+		if err := a.policyHistory.record(ch.Point, update); err != nil {
+			a.logger.Errorf("Recording %q channel policy update: %v", ch.Point, err)
+		}
 	}
 
 	return nil
 }
 
+// ExplainPolicies returns the routing policy rule matching each local channel.
+func (a *agent) ExplainPolicies(ctx context.Context, localNode local.Node) ([]PolicyMatch, error) {
+	interval := a.config.Intervals.RoutingPolicies
+	startTime := uint64(time.Now().Add(-interval).Unix())
+
+	var channelGraph *lnrpc.ChannelGraph
+	if usesPeerConditions(a.config.RoutingPolicies.Rules) || tagsUseGraph(a.config.Tags) {
+		var err error
+		channelGraph, err = a.graphCache.get(ctx, a.lnd)
+		if err != nil {
+			return nil, errors.Wrap(err, "getting channel graph")
+		}
+	}
+	rules := newRuleMatcher(a.config.RoutingPolicies, channelGraph, localNode.CurrentBlockHeight, interval)
+	tagger := NewTagger(a.config, channelGraph)
+
+	matches := make([]PolicyMatch, 0, len(localNode.Channels.List))
+	for _, ch := range localNode.Channels.List {
+		forwards, err := local.ListForwards(ctx, a.lnd, ch.ID, startTime, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		tags := tagger.Channel(ch)
+		match := PolicyMatch{
+			ChannelPoint:    ch.Point,
+			RemotePublicKey: ch.RemotePublicKey,
+			Tags:            tags.Names(),
+		}
+		if rule, ok := rules.match(ch, forwards); ok {
+			match.Rule = rule.Name
+		} else {
+			match.FeeStrategy = feeStrategyName(a.config.Fees, ch.Point, tags)
+		}
+		matches = append(matches, match)
+	}
+
+	return matches, nil
+}
+
 func getChannelPolicy(
 	ctx context.Context,
 	lnd lightning.Client,
@@ -420,16 +731,24 @@ func calculateNewFeeRate(
 	return feeRatePPM
 }
 
-// calculateNewMaxHTLC computes 80% of the local channel capacity in millisats.
+// calculateNewMaxHTLC computes 80% of the local spendable balance in millisats, limited by the value that
+// can still be put in flight.
 func calculateNewMaxHTLC(channel local.Channel) uint64 {
-	if channel.LocalBalance < 2 {
+	spendable := channel.LocalBalance - min(channel.LocalReserve, channel.LocalBalance)
+	if spendable < 2 {
 		return 1_000
 	}
 
-	// Leave a buffer of 20% of the local balance to avoid running out of liquidity and starting to fail
+	// Leave a buffer of 20% of the spendable balance to avoid running out of liquidity and starting to fail
 	// payments before the next update
-	newMaxHTLC := getPercentage(channel.LocalBalance, 80)
-	return newMaxHTLC * 1000
+	newMaxHTLC := getPercentage(spendable, 80) * 1000
+
+	if channel.MaxPendingMsat > 0 {
+		pendingMsat := min(channel.PendingOutgoing*1000, channel.MaxPendingMsat)
+		newMaxHTLC = min(newMaxHTLC, max(channel.MaxPendingMsat-pendingMsat, 1_000))
+	}
+
+	return newMaxHTLC
 }
 
 // getPercentage returns the specified percent of value.
@@ -130,12 +130,13 @@ func TestSelectNodes(t *testing.T) {
 
 	lndMock.On("ConnectPeer", ctx, candidates[1].PublicKey, candidates[1].Addresses).Return(nil)
 
-	nodes := agent.selectNodes(ctx, localNode, candidates)
+	nodes := agent.selectNodes(ctx, localNode, candidates, scoreSelector{}, true)
 
 	assert.Equal(t, expectedNodes, nodes)
 }
 
 func TestSelectChannels(t *testing.T) {
+	allowForceCloses := true
 	tests := []struct {
 		desc             string
 		agent            agent
@@ -215,6 +216,43 @@ func TestSelectChannels(t *testing.T) {
 				"3": false,
 			},
 		},
+		{
+			desc: "Tag allowing force closes",
+			agent: agent{
+				logger: logger.New(""),
+				config: config.Agent{
+					AllowForceCloses: false,
+					HeuristicWeights: config.HeuristicsWeights{
+						Close: config.DefaultCloseWeights,
+					},
+				},
+			},
+			localNode: local.Node{
+				MaxCloseChannels: 2,
+			},
+			candidates: []channelCandidate{
+				{
+					ChannelPoint: "1",
+					Active:       false,
+					Score:        1,
+					Tags:         TagSet{{Name: "unreliable", AllowForceCloses: &allowForceCloses}},
+				},
+				{
+					ChannelPoint: "2",
+					Active:       false,
+					Score:        1.4,
+				},
+				{
+					ChannelPoint: "3",
+					Active:       true,
+					Score:        1.5,
+				},
+			},
+			expectedChannels: map[string]bool{
+				"1": true,
+				"3": false,
+			},
+		},
 		{
 			desc: "High scored channels",
 			agent: agent{
@@ -236,6 +274,44 @@ func TestSelectChannels(t *testing.T) {
 			},
 			expectedChannels: map[string]bool{},
 		},
+		{
+			desc: "Negative expected return",
+			agent: agent{
+				logger: logger.New(""),
+				config: config.Agent{
+					Closing: config.Closing{
+						Mode: config.ClosingModeProfitability,
+					},
+				},
+			},
+			localNode: local.Node{
+				MaxCloseChannels: 3,
+			},
+			candidates: []channelCandidate{
+				{
+					ChannelPoint:  "1",
+					Active:        true,
+					Score:         4,
+					Profitability: local.Profitability{ExpectedReturn: -1_500},
+				},
+				{
+					ChannelPoint:  "2",
+					Active:        true,
+					Score:         0.2,
+					Profitability: local.Profitability{ExpectedReturn: -20},
+				},
+				{
+					ChannelPoint:  "3",
+					Active:        true,
+					Score:         0.1,
+					Profitability: local.Profitability{ExpectedReturn: 300},
+				},
+			},
+			expectedChannels: map[string]bool{
+				"1": false,
+				"2": false,
+			},
+		},
 		{
 			desc: "Do not close channels",
 			agent: agent{
@@ -277,11 +353,12 @@ func TestUpdatePolicies(t *testing.T) {
 		config: config.Agent{
 			MaxChannelSize: 10_000_000,
 		},
-		channelManager: channel.NewManager(config.ChannelManager{}, lndMock),
+		channelManager: channel.NewManager(config.ChannelManager{}, "", lndMock, nil),
 	}
 	publicKey := "test"
 	channelID := uint64(191315023298560)
-	channelPoint := "1"
+	txID := "e5b8ccc43b4eea6e2664a843e27d82c6d71d2885e7aef73777dd35c737c1d7bc"
+	channelPoint := txID + ":1"
 	localNode := local.Node{
 		PublicKey: publicKey,
 		Channels: local.Channels{
@@ -336,15 +413,19 @@ func TestUpdatePolicies(t *testing.T) {
 	lndMock.On("GetChanInfo", ctx, channelID).Return(chanInfoResp, nil)
 	lndMock.On("UpdateChannelPolicy",
 		ctx,
-		channelPoint,
-		uint64(chanInfoResp.Node1Policy.FeeBaseMsat),
-		expectedFeeRatePPM,
-		expectedMaxHTLCMsat,
-		uint64(chanInfoResp.Node1Policy.TimeLockDelta),
+		mock.MatchedBy(func(req *lnrpc.PolicyUpdateRequest) bool {
+			return req.GetChanPoint().GetFundingTxidStr() == txID &&
+				req.BaseFeeMsat == chanInfoResp.Node1Policy.FeeBaseMsat &&
+				uint64(req.FeeRatePpm) == expectedFeeRatePPM &&
+				req.MaxHtlcMsat == expectedMaxHTLCMsat &&
+				req.TimeLockDelta == chanInfoResp.Node1Policy.TimeLockDelta &&
+				req.InboundFee.BaseFeeMsat == 0 && req.InboundFee.FeeRatePpm == 0
+		}),
 	).Return(nil)
 
 	err := agent.UpdatePolicies(ctx, localNode)
 	assert.NoError(t, err)
+	lndMock.AssertExpectations(t)
 }
 
 func TestGetChannelPolicy(t *testing.T) {
@@ -534,6 +615,40 @@ func TestCalculateNewMaxHTLC(t *testing.T) {
 			},
 			expectedResult: 18_400_000_000,
 		},
+		{
+			desc: "Reserve",
+			channel: local.Channel{
+				LocalBalance: 1_000_000,
+				LocalReserve: 10_000,
+			},
+			expectedResult: 792_000_000,
+		},
+		{
+			desc: "Balance within the reserve",
+			channel: local.Channel{
+				LocalBalance: 5_000,
+				LocalReserve: 10_000,
+			},
+			expectedResult: 1_000,
+		},
+		{
+			desc: "Pending HTLCs",
+			channel: local.Channel{
+				LocalBalance:    1_000_000,
+				PendingOutgoing: 300_000,
+				MaxPendingMsat:  900_000_000,
+			},
+			expectedResult: 600_000_000,
+		},
+		{
+			desc: "Value in flight exhausted",
+			channel: local.Channel{
+				LocalBalance:    1_000_000,
+				PendingOutgoing: 950_000,
+				MaxPendingMsat:  900_000_000,
+			},
+			expectedResult: 1_000,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,195 @@
+package agent
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/config"
+)
+
+// minChanSizeRegexp matches the error LND returns when a peer rejects a channel for being too small.
+var minChanSizeRegexp = regexp.MustCompile(
+	`received funding error from ([0-9a-f]{66}): chan size of [0-9.]+ BTC is below min chan size of ([0-9.]+) BTC`,
+)
+
+// allocator decides how much to fund the channels opened to the selected nodes.
+type allocator interface {
+	// allocate returns the funding amount of each node. Nodes are expected to be sorted by priority.
+	allocate(localNode local.Node, nodes []nodeCandidate) map[string]uint64
+}
+
+// fundingLimits holds the boundaries every funding amount must respect.
+type fundingLimits struct {
+	peerMinChannelSizes *minChannelSizes
+	minChannelSize      uint64
+	maxChannelSize      uint64
+	// Capacity the tags capacity shares are relative to
+	nodeCapacity uint64
+}
+
+// newAllocator returns the allocator corresponding to the funding strategy.
+func newAllocator(strategy string, limits fundingLimits) allocator {
+	switch strategy {
+	case config.FundingStrategyScore:
+		return scoreAllocator{limits: limits}
+	case config.FundingStrategyPeerMedian:
+		return peerMedianAllocator{limits: limits}
+	default:
+		return equalAllocator{limits: limits}
+	}
+}
+
+// equalAllocator splits the allocated balance equally between all the channels to open.
+type equalAllocator struct {
+	limits fundingLimits
+}
+
+func (e equalAllocator) allocate(localNode local.Node, nodes []nodeCandidate) map[string]uint64 {
+	share := equalShare(localNode)
+	return e.limits.fit(localNode.AllocatedBalance, nodes, func(nodeCandidate) uint64 {
+		return share
+	})
+}
+
+// scoreAllocator splits the allocated balance proportionally to the score of each node.
+type scoreAllocator struct {
+	limits fundingLimits
+}
+
+func (s scoreAllocator) allocate(localNode local.Node, nodes []nodeCandidate) map[string]uint64 {
+	scoresSum := 0.0
+	for _, node := range nodes {
+		scoresSum += node.Score
+	}
+
+	share := equalShare(localNode)
+	return s.limits.fit(localNode.AllocatedBalance, nodes, func(node nodeCandidate) uint64 {
+		if scoresSum == 0 {
+			return share
+		}
+		return uint64(float64(localNode.AllocatedBalance) * (node.Score / scoresSum))
+	})
+}
+
+// peerMedianAllocator funds each channel with the median capacity of the peer's channels, so that
+// large hubs and small nodes get channels of the size they are used to.
+type peerMedianAllocator struct {
+	limits fundingLimits
+}
+
+func (p peerMedianAllocator) allocate(localNode local.Node, nodes []nodeCandidate) map[string]uint64 {
+	share := equalShare(localNode)
+	return p.limits.fit(localNode.AllocatedBalance, nodes, func(node nodeCandidate) uint64 {
+		if node.MedianChannelCapacity == 0 {
+			return share
+		}
+		return node.MedianChannelCapacity
+	})
+}
+
+// fit adjusts the desired amounts to the channel size limits and the balance available, giving priority to
+// the nodes that come first. Nodes whose minimum channel size can't be satisfied are left out.
+func (l fundingLimits) fit(
+	balance uint64,
+	nodes []nodeCandidate,
+	desired func(node nodeCandidate) uint64,
+) map[string]uint64 {
+	amounts := make(map[string]uint64, len(nodes))
+
+	for _, node := range nodes {
+		minSize := max(l.minChannelSize, l.peerMinChannelSizes.get(node.PublicKey))
+		maxSize := l.maxSize(node.Tags)
+		if minSize > maxSize || minSize > balance {
+			continue
+		}
+
+		amount := min(max(desired(node), minSize), maxSize, balance)
+		if amount == 0 {
+			continue
+		}
+
+		amounts[node.PublicKey] = amount
+		balance -= amount
+	}
+
+	return amounts
+}
+
+// maxSize returns the maximum size of a channel with a peer with the tags.
+func (l fundingLimits) maxSize(tags TagSet) uint64 {
+	if share := tags.maxCapacityShare(); share > 0 {
+		return min(l.maxChannelSize, uint64(share*float64(l.nodeCapacity)))
+	}
+	return l.maxChannelSize
+}
+
+// minChannelSizes records the minimum channel sizes required by peers.
+type minChannelSizes struct {
+	// map[public_key]min_channel_size
+	sizes map[string]uint64
+	mu    sync.RWMutex
+}
+
+func newMinChannelSizes() *minChannelSizes {
+	return &minChannelSizes{sizes: make(map[string]uint64)}
+}
+
+func (m *minChannelSizes) get(publicKey string) uint64 {
+	if m == nil {
+		return 0
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sizes[publicKey]
+}
+
+func (m *minChannelSizes) set(publicKey string, minChannelSize uint64) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sizes[publicKey] = minChannelSize
+}
+
+// equalShare returns the amount each channel gets if the allocated balance is split equally.
+func equalShare(localNode local.Node) uint64 {
+	if localNode.MaxOpenChannels == 0 {
+		return 0
+	}
+	return localNode.AllocatedBalance / localNode.MaxOpenChannels
+}
+
+// nodeCapacity returns the capacity of the local channels plus the balance allocated to new ones.
+func nodeCapacity(localNode local.Node) uint64 {
+	capacity := localNode.AllocatedBalance
+	for _, channel := range localNode.Channels.List {
+		capacity += channel.Capacity
+	}
+	return capacity
+}
+
+// parseMinChannelSize looks for a peer's minimum channel size in a funding error, returning the peer's
+// public key and the minimum size in satoshis.
+func parseMinChannelSize(err error) (string, uint64, bool) {
+	if err == nil {
+		return "", 0, false
+	}
+
+	matches := minChanSizeRegexp.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return "", 0, false
+	}
+
+	btc, parseErr := strconv.ParseFloat(matches[2], 64)
+	if parseErr != nil {
+		return "", 0, false
+	}
+
+	return matches[1], uint64(math.Round(btc * 1e8)), true
+}
@@ -0,0 +1,168 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllocate(t *testing.T) {
+	localNode := local.Node{
+		AllocatedBalance: 10_000_000,
+		MaxOpenChannels:  4,
+	}
+	nodes := []nodeCandidate{
+		{PublicKey: "alice", Score: 6, MedianChannelCapacity: 8_000_000},
+		{PublicKey: "bob", Score: 3, MedianChannelCapacity: 500_000},
+		{PublicKey: "carol", Score: 1, MedianChannelCapacity: 0},
+	}
+
+	tests := []struct {
+		desc            string
+		strategy        string
+		limits          fundingLimits
+		expectedAmounts map[string]uint64
+	}{
+		{
+			desc:     "Equal",
+			strategy: config.FundingStrategyEqual,
+			limits: fundingLimits{
+				minChannelSize: 1_000_000,
+				maxChannelSize: 5_000_000,
+			},
+			expectedAmounts: map[string]uint64{
+				"alice": 2_500_000,
+				"bob":   2_500_000,
+				"carol": 2_500_000,
+			},
+		},
+		{
+			desc:     "Score",
+			strategy: config.FundingStrategyScore,
+			limits: fundingLimits{
+				minChannelSize: 1_000_000,
+				maxChannelSize: 5_000_000,
+			},
+			expectedAmounts: map[string]uint64{
+				"alice": 5_000_000,
+				"bob":   3_000_000,
+				"carol": 1_000_000,
+			},
+		},
+		{
+			desc:     "Peer median",
+			strategy: config.FundingStrategyPeerMedian,
+			limits: fundingLimits{
+				minChannelSize: 1_000_000,
+				maxChannelSize: 6_000_000,
+			},
+			expectedAmounts: map[string]uint64{
+				"alice": 6_000_000,
+				"bob":   1_000_000,
+				"carol": 2_500_000,
+			},
+		},
+		{
+			desc:     "Peer minimum channel size",
+			strategy: config.FundingStrategyEqual,
+			limits: fundingLimits{
+				peerMinChannelSizes: &minChannelSizes{
+					sizes: map[string]uint64{
+						"bob":   4_000_000,
+						"carol": 6_000_000,
+					},
+				},
+				minChannelSize: 1_000_000,
+				maxChannelSize: 5_000_000,
+			},
+			expectedAmounts: map[string]uint64{
+				"alice": 2_500_000,
+				"bob":   4_000_000,
+			},
+		},
+		{
+			desc:     "Not enough balance",
+			strategy: config.FundingStrategyPeerMedian,
+			limits: fundingLimits{
+				minChannelSize: 2_000_000,
+				maxChannelSize: 9_000_000,
+			},
+			expectedAmounts: map[string]uint64{
+				"alice": 8_000_000,
+				"bob":   2_000_000,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			amounts := newAllocator(tt.strategy, tt.limits).allocate(localNode, nodes)
+			assert.Equal(t, tt.expectedAmounts, amounts)
+		})
+	}
+}
+
+func TestFundingLimitsMaxSize(t *testing.T) {
+	localNode := local.Node{
+		AllocatedBalance: 4_000_000,
+		Channels: local.Channels{
+			List: []local.Channel{{Capacity: 10_000_000}, {Capacity: 6_000_000}},
+		},
+	}
+	limits := fundingLimits{maxChannelSize: 5_000_000, nodeCapacity: nodeCapacity(localNode)}
+
+	assert.Equal(t, uint64(20_000_000), limits.nodeCapacity)
+	assert.Equal(t, uint64(5_000_000), limits.maxSize(nil))
+	assert.Equal(t, uint64(2_000_000), limits.maxSize(TagSet{{MaxCapacityShare: 0.1}}))
+	assert.Equal(t, uint64(5_000_000), limits.maxSize(TagSet{{MaxCapacityShare: 0.5}}))
+
+	nodes := []nodeCandidate{{PublicKey: "alice", Tags: TagSet{{MaxCapacityShare: 0.1}}}, {PublicKey: "bob"}}
+	amounts := limits.fit(10_000_000, nodes, func(nodeCandidate) uint64 { return 4_000_000 })
+	assert.Equal(t, map[string]uint64{"alice": 2_000_000, "bob": 4_000_000}, amounts)
+}
+
+func TestParseMinChannelSize(t *testing.T) {
+	publicKey := "02b5a8213a52feee44ecb735bc22ba5a1ba2d6a8e4e2fa6cf1d98da32a9e2e0f7c"
+
+	tests := []struct {
+		desc              string
+		err               error
+		expectedPublicKey string
+		expectedSize      uint64
+		ok                bool
+	}{
+		{
+			desc: "Min chan size error",
+			err: errors.Errorf(
+				"batch opening channels: received funding error from %s: chan size of 0.01 BTC is below "+
+					"min chan size of 0.025 BTC", publicKey,
+			),
+			expectedPublicKey: publicKey,
+			expectedSize:      2_500_000,
+			ok:                true,
+		},
+		{
+			desc: "Other error",
+			err:  errors.New("batch opening channels: insufficient funds"),
+			ok:   false,
+		},
+		{
+			desc: "Nil error",
+			err:  nil,
+			ok:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			publicKey, size, ok := parseMinChannelSize(tt.err)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expectedPublicKey, publicKey)
+			assert.Equal(t, tt.expectedSize, size)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aftermath2/hydrus/agent/local"
+
+	"github.com/pkg/errors"
+)
+
+// BalanceSnapshotsFile is the name of the file inside the data directory where the channels balances are
+// recorded.
+const BalanceSnapshotsFile = "balances.jsonl"
+
+// BalanceSnapshot contains the balances of the local channels at a point in time.
+type BalanceSnapshot struct {
+	Time        time.Time        `json:"time"`
+	Channels    []ChannelBalance `json:"channels"`
+	BlockHeight uint32           `json:"block_height"`
+}
+
+// ChannelBalance is the balance of a channel.
+type ChannelBalance struct {
+	Point string `json:"point"`
+	ID    uint64 `json:"id"`
+	// Not recorded by older versions
+	RemotePublicKey string `json:"remote_public_key,omitempty"`
+	Capacity        uint64 `json:"capacity"`
+	LocalBalance    uint64 `json:"local_balance"`
+}
+
+// balanceRecorder appends a snapshot of the channels balances to a file on every routing policies run, so
+// that the fee strategies can be evaluated against them later.
+//
+// All methods are safe to call on a nil balanceRecorder, in which case nothing is recorded.
+type balanceRecorder struct {
+	path string
+	mu   sync.Mutex
+}
+
+func newBalanceRecorder(dataDir string) *balanceRecorder {
+	if dataDir == "" {
+		return nil
+	}
+
+	return &balanceRecorder{path: filepath.Join(dataDir, BalanceSnapshotsFile)}
+}
+
+// record appends the balances of the node's channels to the file.
+func (b *balanceRecorder) record(now time.Time, localNode local.Node) error {
+	if b == nil {
+		return nil
+	}
+
+	snapshot := BalanceSnapshot{
+		Time:        now,
+		BlockHeight: localNode.CurrentBlockHeight,
+		Channels:    make([]ChannelBalance, 0, len(localNode.Channels.List)),
+	}
+	for _, channel := range localNode.Channels.List {
+		snapshot.Channels = append(snapshot.Channels, ChannelBalance{
+			Point:           channel.Point,
+			ID:              channel.ID,
+			RemotePublicKey: channel.RemotePublicKey,
+			Capacity:        channel.Capacity,
+			LocalBalance:    channel.LocalBalance,
+		})
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return errors.Wrap(err, "encoding balance snapshot")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o700); err != nil {
+		return errors.Wrap(err, "creating data directory")
+	}
+
+	f, err := os.OpenFile(b.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "opening balance snapshots file")
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return errors.Wrap(err, "writing balance snapshot")
+	}
+
+	return nil
+}
+
+// ReadBalanceSnapshots returns the balance snapshots recorded in the file, sorted from oldest to newest.
+func ReadBalanceSnapshots(path string) ([]BalanceSnapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening balance snapshots file")
+	}
+	defer f.Close()
+
+	var snapshots []BalanceSnapshot
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var snapshot BalanceSnapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snapshot); err != nil {
+			return nil, errors.Wrapf(err, "decoding balance snapshot on line %d", line)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading balance snapshots file")
+	}
+
+	slices.SortStableFunc(snapshots, func(a, b BalanceSnapshot) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	return snapshots, nil
+}
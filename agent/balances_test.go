@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aftermath2/hydrus/agent/local"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBalanceRecorder(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+	recorder := newBalanceRecorder(dir)
+
+	localNode := func(localBalance uint64) local.Node {
+		return local.Node{
+			CurrentBlockHeight: 900_000,
+			Channels: local.Channels{
+				List: []local.Channel{
+					{ID: 1, Point: "txid:0", Capacity: 1_000_000, LocalBalance: localBalance},
+				},
+			},
+		}
+	}
+	assert.NoError(t, recorder.record(now, localNode(400_000)))
+	assert.NoError(t, recorder.record(now.Add(-time.Hour), localNode(500_000)))
+
+	snapshots, err := ReadBalanceSnapshots(filepath.Join(dir, BalanceSnapshotsFile))
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 2)
+
+	// Snapshots are sorted by time
+	assert.True(t, now.Add(-time.Hour).Equal(snapshots[0].Time))
+	assert.Equal(t, uint32(900_000), snapshots[0].BlockHeight)
+	assert.Equal(t, []ChannelBalance{
+		{Point: "txid:0", ID: 1, Capacity: 1_000_000, LocalBalance: 500_000},
+	}, snapshots[0].Channels)
+	assert.Equal(t, uint64(400_000), snapshots[1].Channels[0].LocalBalance)
+}
+
+func TestReadBalanceSnapshotsInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), BalanceSnapshotsFile)
+	err := os.WriteFile(path, []byte("{\"time\":\"2026-01-01T00:00:00Z\"}\n{"), 0o600)
+	assert.NoError(t, err)
+
+	_, err = ReadBalanceSnapshots(path)
+	assert.ErrorContains(t, err, "line 2")
+
+	_, err = ReadBalanceSnapshots(filepath.Join(t.TempDir(), "missing.jsonl"))
+	assert.Error(t, err)
+}
+
+func TestNilBalanceRecorder(t *testing.T) {
+	recorder := newBalanceRecorder("")
+	assert.Nil(t, recorder)
+
+	assert.NoError(t, recorder.record(time.Now(), local.Node{}))
+}
@@ -4,10 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"slices"
 	"sort"
 
 	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/config"
 	"github.com/aftermath2/hydrus/graph"
 	"github.com/aftermath2/hydrus/logger"
 
@@ -22,16 +22,21 @@ const (
 
 // nodeCandidate represents a node we might open a channel with.
 type nodeCandidate struct {
-	PublicKey string   `json:"public_key"`
-	Addresses []string `json:"-"`
-	Score     float64  `json:"score"`
+	PublicKey             string          `json:"public_key"`
+	Addresses             []string        `json:"-"`
+	Score                 float64         `json:"score"`
+	MedianChannelCapacity uint64          `json:"-"`
+	Gain                  *centralityGain `json:"gain,omitempty"`
+	Tags                  TagSet          `json:"tags,omitempty"`
 }
 
 // channelCandidate represents a channel we might close.
 type channelCandidate struct {
-	ChannelPoint string  `json:"channel_point,omitempty"`
-	Active       bool    `json:"active,omitempty"`
-	Score        float64 `json:"score,omitempty"`
+	ChannelPoint  string              `json:"channel_point,omitempty"`
+	Active        bool                `json:"active,omitempty"`
+	Score         float64             `json:"score,omitempty"`
+	Profitability local.Profitability `json:"profitability,omitzero"`
+	Tags          TagSet              `json:"tags,omitempty"`
 }
 
 // getCandidateNodes returns a ranking with candidates to open a channel to.
@@ -39,21 +44,24 @@ func getCandidateNodes(
 	logger logger.Logger,
 	localNode local.Node,
 	graph graph.Graph,
-	blocklist []string,
+	tagger *Tagger,
 ) []nodeCandidate {
 	logger.Info("Getting candidate nodes to open a channel with")
 	candidates := make([]nodeCandidate, 0, len(graph.Nodes))
 
 	for _, node := range graph.Nodes {
-		if err := discardNode(localNode, node, blocklist); err != nil {
+		tags := tagger.Node(node)
+		if err := discardNode(localNode, node, tags); err != nil {
 			logger.Debugf("Discarding candidate node %q: %v", node.PublicKey, err)
 			continue
 		}
 
 		candidates = append(candidates, nodeCandidate{
-			PublicKey: node.PublicKey,
-			Addresses: node.Addresses,
-			Score:     graph.Heuristics.GetScore(node),
+			PublicKey:             node.PublicKey,
+			Addresses:             node.Addresses,
+			Score:                 graph.Heuristics.GetScore(node),
+			MedianChannelCapacity: node.MedianChannelCapacity(),
+			Tags:                  tags,
 		})
 	}
 
@@ -67,16 +75,64 @@ func getCandidateNodes(
 	return candidates
 }
 
+// nodeRule returns an error describing why the node must be skipped or nil if not.
+type nodeRule func(localNode local.Node, peerNode graph.Node, tags TagSet) error
+
+// nodeRules are checked in order against every candidate node.
+var nodeRules = []nodeRule{
+	checkTags,
+	checkChannelPeer,
+	checkSharedPeers,
+	checkClosedChannels,
+	checkOwnNode,
+}
+
 // discardNode returns an error if the node should be skipped or nil if not.
-func discardNode(localNode local.Node, peerNode graph.Node, blocklist []string) error {
-	if slices.Contains(blocklist, peerNode.PublicKey) {
+func discardNode(localNode local.Node, peerNode graph.Node, tags TagSet) error {
+	for _, rule := range nodeRules {
+		if err := rule(localNode, peerNode, tags); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DiscardReasons returns the reasons why the node would not be considered as a candidate to open a channel
+// with, it's empty if there are none.
+func DiscardReasons(localNode local.Node, peerNode graph.Node, tagger *Tagger) []string {
+	tags := tagger.Node(peerNode)
+	reasons := make([]string, 0)
+	for _, rule := range nodeRules {
+		if err := rule(localNode, peerNode, tags); err != nil {
+			reasons = append(reasons, err.Error())
+		}
+	}
+
+	return reasons
+}
+
+func checkTags(_ local.Node, _ graph.Node, tags TagSet) error {
+	name, ok := tags.neverOpen()
+	if !ok {
+		return nil
+	}
+
+	if name == config.TagBlocklist {
 		return errors.New("blocklisted")
 	}
+	return fmt.Errorf("tagged %q, channels with this peer are never opened", name)
+}
 
+func checkChannelPeer(localNode local.Node, peerNode graph.Node, _ TagSet) error {
 	if _, ok := localNode.ChannelPeers[peerNode.PublicKey]; ok {
 		return errors.New("already sharing a channel")
 	}
 
+	return nil
+}
+
+func checkSharedPeers(localNode local.Node, peerNode graph.Node, _ TagSet) error {
 	// Count the number of shared channel peers between local and candidate nodes
 	numSharedPeers := uint64(0)
 	for _, channel := range peerNode.Channels {
@@ -91,6 +147,10 @@ func discardNode(localNode local.Node, peerNode graph.Node, blocklist []string)
 		return fmt.Errorf("sharing too many channel peers (%d)", numSharedPeers)
 	}
 
+	return nil
+}
+
+func checkClosedChannels(localNode local.Node, peerNode graph.Node, _ TagSet) error {
 	// Use int32 to avoid overflows setting the number too high
 	threeMonthsAgo := int32(localNode.CurrentBlockHeight - threeMonthsInBlocks)
 
@@ -111,6 +171,10 @@ func discardNode(localNode local.Node, peerNode graph.Node, blocklist []string)
 		}
 	}
 
+	return nil
+}
+
+func checkOwnNode(localNode local.Node, peerNode graph.Node, _ TagSet) error {
 	// Our own node may be in the graph
 	if localNode.PublicKey == peerNode.PublicKey {
 		return errors.New("own node")
@@ -120,25 +184,36 @@ func discardNode(localNode local.Node, peerNode graph.Node, blocklist []string)
 }
 
 // getCandidateChannels returns a ranking with the candidates channels to close.
-func getCandidateChannels(logger logger.Logger, localNode local.Node, keeplist []string) []channelCandidate {
+func getCandidateChannels(
+	logger logger.Logger,
+	localNode local.Node,
+	tagger *Tagger,
+	closing config.Closing,
+) []channelCandidate {
 	logger.Info("Getting candidate channels to close")
 
 	candidates := make([]channelCandidate, 0, len(localNode.Channels.List))
 
 	for _, channel := range localNode.Channels.List {
-		if slices.Contains(keeplist, channel.Point) {
-			logger.Debugf("Discarding candidate channel %q: channel point is in the keeplist", channel.Point)
+		tags := tagger.Channel(channel)
+		if err := discardChannel(localNode, channel, tags, closing); err != nil {
+			logger.Debugf("Discarding candidate channel %q: %v", channel.Point, err)
 			continue
 		}
 
 		candidates = append(candidates, channelCandidate{
-			ChannelPoint: channel.Point,
-			Active:       channel.Active,
-			Score:        localNode.Channels.Heuristics.GetScore(channel),
+			ChannelPoint:  channel.Point,
+			Active:        channel.Active,
+			Score:         localNode.Channels.Heuristics.GetScore(channel),
+			Profitability: channel.GetProfitability(closing.Profitability, localNode.SatvB),
+			Tags:          tags,
 		})
 	}
 
 	sort.Slice(candidates, func(i, j int) bool {
+		if closing.Mode == config.ClosingModeProfitability {
+			return candidates[i].Profitability.ExpectedReturn < candidates[j].Profitability.ExpectedReturn
+		}
 		return candidates[i].Score < candidates[j].Score
 	})
 
@@ -147,3 +222,21 @@ func getCandidateChannels(logger logger.Logger, localNode local.Node, keeplist [
 
 	return candidates
 }
+
+// discardChannel returns an error if the channel must not be closed or nil if not.
+func discardChannel(localNode local.Node, channel local.Channel, tags TagSet, closing config.Closing) error {
+	if name, ok := tags.neverClose(); ok {
+		if name == config.TagKeeplist {
+			return errors.New("channel is in the keeplist")
+		}
+		return fmt.Errorf("tagged %q, the channel is never closed", name)
+	}
+
+	// A nil grace period means it's disabled
+	gracePeriod := closing.GracePeriod
+	if gracePeriod != nil && channel.Age(localNode.CurrentBlockHeight) < *gracePeriod {
+		return errors.New("channel is within the grace period")
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"testing"
+	"time"
 
 	"github.com/aftermath2/hydrus/agent/local"
 	"github.com/aftermath2/hydrus/config"
@@ -241,7 +242,8 @@ func TestGetCandidateNodes(t *testing.T) {
 				tt.graph.Heuristics.Update(node)
 			}
 
-			candidates := getCandidateNodes(logger.New(""), tt.localNode, tt.graph, tt.blocklist)
+			tagger := NewTagger(config.Agent{Blocklist: tt.blocklist}, nil)
+			candidates := getCandidateNodes(logger.New(""), tt.localNode, tt.graph, tagger)
 
 			assert.Equal(t, tt.expectedCandidates, candidates)
 		})
@@ -363,7 +365,8 @@ func TestDiscardNode(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
-			err := discardNode(tt.localNode, tt.peerNode, tt.blocklist)
+			tags := NewTagger(config.Agent{Blocklist: tt.blocklist}, nil).Node(tt.peerNode)
+			err := discardNode(tt.localNode, tt.peerNode, tags)
 			if tt.discard {
 				assert.Error(t, err)
 			} else {
@@ -373,74 +376,129 @@ func TestDiscardNode(t *testing.T) {
 	}
 }
 
+func TestDiscardReasons(t *testing.T) {
+	localNode := local.Node{
+		PublicKey:    "alice",
+		ChannelPeers: map[string]struct{}{"alice": {}},
+	}
+
+	tagger := NewTagger(config.Agent{Blocklist: []string{"alice"}}, nil)
+	reasons := DiscardReasons(localNode, graph.Node{PublicKey: "alice"}, tagger)
+	assert.Equal(t, []string{"blocklisted", "already sharing a channel", "own node"}, reasons)
+
+	assert.Empty(t, DiscardReasons(localNode, graph.Node{PublicKey: "bob"}, tagger))
+}
+
 func TestGetCandidateChannels(t *testing.T) {
 	node := local.Node{
-		MaxCloseChannels: 5,
+		MaxCloseChannels:   5,
+		CurrentBlockHeight: 4_500,
 		Channels: local.Channels{
 			List: []local.Channel{
 				{
-					Point:          "1",
-					Active:         true,
-					BlockHeight:    10,
-					Capacity:       5_000_000,
-					NumForwards:    900,
-					ForwardsAmount: 3_000_000_000,
-					Fees:           10_000_000,
-					PingTime:       300,
-					FlapCount:      4,
+					Point:       "1",
+					Active:      true,
+					BlockHeight: 10,
+					Capacity:    5_000_000,
+					Rates:       []local.Rates{{Days: 30, NumForwards: 900, ForwardsAmount: 3_000_000_000, Fees: 10_000_000}},
+					PingTime:    300,
+					FlapCount:   4,
 				},
 				{
-					Point:          "2",
-					Active:         true,
-					BlockHeight:    21,
-					Capacity:       12_000_000,
-					NumForwards:    2_000,
-					ForwardsAmount: 150_000_000_000,
-					Fees:           50_000_000,
-					PingTime:       100,
-					FlapCount:      2,
+					Point:       "2",
+					Active:      true,
+					BlockHeight: 21,
+					Capacity:    12_000_000,
+					Rates:       []local.Rates{{Days: 30, NumForwards: 2_000, ForwardsAmount: 150_000_000_000, Fees: 50_000_000}},
+					PingTime:    100,
+					FlapCount:   2,
 				},
 				{
-					Point:          "3",
-					Active:         true,
-					BlockHeight:    100,
-					Capacity:       100_000,
-					NumForwards:    10,
-					ForwardsAmount: 50_000_000,
-					Fees:           250_000,
-					PingTime:       150,
-					FlapCount:      20,
+					Point:       "3",
+					Active:      true,
+					BlockHeight: 100,
+					Capacity:    100_000,
+					Rates:       []local.Rates{{Days: 30, NumForwards: 10, ForwardsAmount: 50_000_000, Fees: 250_000}},
+					PingTime:    150,
+					FlapCount:   20,
 				},
 				{
-					Point:          "4",
-					Active:         false,
-					BlockHeight:    350,
-					Capacity:       20_000,
-					NumForwards:    5,
-					ForwardsAmount: 150,
-					Fees:           1,
-					PingTime:       20,
-					FlapCount:      1,
+					Point:       "4",
+					Active:      false,
+					BlockHeight: 350,
+					Capacity:    20_000,
+					Rates:       []local.Rates{{Days: 30, NumForwards: 5, ForwardsAmount: 150, Fees: 1}},
+					PingTime:    20,
+					FlapCount:   1,
 				},
 			},
-			Heuristics: *local.NewHeuristics(config.DefaultCloseWeights),
+			Heuristics: *local.NewHeuristics(config.DefaultCloseWeights, []time.Duration{time.Hour * 24 * 30}),
 		},
 	}
-	expectedCandidates := []channelCandidate{
-		{
-			ChannelPoint: node.Channels.List[3].Point,
-			Active:       false,
-			Score:        0.6,
-		},
+	opportunityRate := 0.01
+	gracePeriod := time.Hour * 24 * 30
+
+	tests := []struct {
+		desc               string
+		closing            config.Closing
+		expectedCandidates []channelCandidate
+	}{
 		{
-			ChannelPoint: node.Channels.List[2].Point,
-			Active:       true,
-			Score:        1.666,
+			desc:    "Score",
+			closing: config.Closing{},
+			expectedCandidates: []channelCandidate{
+				{
+					ChannelPoint:  node.Channels.List[3].Point,
+					Active:        false,
+					Score:         0.6,
+					Profitability: local.Profitability{FeesPerDay: 0.001},
+				},
+				{
+					ChannelPoint:  node.Channels.List[2].Point,
+					Active:        true,
+					Score:         1.666,
+					Profitability: local.Profitability{FeesPerDay: 250},
+				},
+				{
+					ChannelPoint:  node.Channels.List[0].Point,
+					Active:        true,
+					Score:         2.555,
+					Profitability: local.Profitability{FeesPerDay: 10_000},
+				},
+			},
 		},
 		{
-			ChannelPoint: node.Channels.List[0].Point,
-			Active:       true,
-			Score:        2.555,
+			desc: "Profitability",
+			closing: config.Closing{
+				Mode: config.ClosingModeProfitability,
+				Profitability: config.Profitability{
+					OpportunityRate: &opportunityRate,
+					Horizon:         time.Hour * 24 * 90,
+				},
+				GracePeriod: &gracePeriod,
+			},
+			expectedCandidates: []channelCandidate{
+				{
+					ChannelPoint: node.Channels.List[2].Point,
+					Active:       true,
+					Score:        1.666,
+					Profitability: local.Profitability{
+						FeesPerDay:        250,
+						CapitalCostPerDay: 2.74,
+						ExpectedReturn:    22253.425,
+					},
+				},
+				{
+					ChannelPoint: node.Channels.List[0].Point,
+					Active:       true,
+					Score:        2.555,
+					Profitability: local.Profitability{
+						FeesPerDay:        10_000,
+						CapitalCostPerDay: 136.986,
+						ExpectedReturn:    887671.233,
+					},
+				},
+			},
 		},
 	}
 
@@ -449,7 +507,27 @@ func TestGetCandidateChannels(t *testing.T) {
 		node.Channels.Heuristics.Update(channel)
 	}
 
-	candidates := getCandidateChannels(logger.New(""), node, []string{node.Channels.List[1].Point})
+	tagger := NewTagger(config.Agent{Keeplist: []string{node.Channels.List[1].Point}}, nil)
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			candidates := getCandidateChannels(logger.New(""), node, tagger, tt.closing)
+			assert.Equal(t, tt.expectedCandidates, candidates)
+		})
+	}
+}
+
+func TestDiscardChannelGracePeriod(t *testing.T) {
+	localNode := local.Node{CurrentBlockHeight: 1_000}
+	channel := local.Channel{Point: "txid:0", BlockHeight: 990}
+
+	gracePeriod := time.Hour * 24
+	err := discardChannel(localNode, channel, nil, config.Closing{GracePeriod: &gracePeriod})
+	assert.Error(t, err)
+
+	disabled := time.Duration(0)
+	err = discardChannel(localNode, channel, nil, config.Closing{GracePeriod: &disabled})
+	assert.NoError(t, err)
 
-	assert.Equal(t, expectedCandidates, candidates)
+	err = discardChannel(localNode, channel, nil, config.Closing{})
+	assert.NoError(t, err)
 }
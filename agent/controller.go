@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/aftermath2/hydrus/api"
+
+	"github.com/go-co-op/gocron/v2"
+)
+
+// controller implements api.Controller on top of a running agent.
+type controller struct {
+	ctx   context.Context
+	agent *agent
+	jobs  map[string]gocron.Job
+}
+
+func (c *controller) Status() api.Status {
+	status := api.Status{
+		Paused: c.agent.state.isPaused(),
+		DryRun: c.agent.dryRun(),
+		Tasks:  make([]api.Task, 0, len(c.jobs)),
+	}
+
+	for _, name := range []string{channelsTaskName, routingPoliciesTaskName} {
+		task := c.agent.state.task(name)
+		if job, ok := c.jobs[name]; ok {
+			if nextRun, err := job.NextRun(); err == nil {
+				task.NextRun = nextRun
+			}
+		}
+		status.Tasks = append(status.Tasks, task)
+	}
+
+	return status
+}
+
+func (c *controller) Rankings() api.Rankings {
+	return c.agent.state.rankings()
+}
+
+func (c *controller) RunTask(name string) error {
+	if _, ok := c.agent.tasks()[name]; !ok {
+		return api.ErrTaskNotFound
+	}
+
+	if err := c.agent.state.begin(name, true); err != nil {
+		return err
+	}
+
+	go func() {
+		_ = c.agent.execTask(c.ctx, name, true)
+	}()
+
+	return nil
+}
+
+func (c *controller) Pause() {
+	c.agent.state.setPaused(true)
+}
+
+func (c *controller) Resume() {
+	c.agent.state.setPaused(false)
+}
+
+func (c *controller) SetDryRun(enabled bool) {
+	c.agent.state.setDryRun(enabled)
+}
@@ -0,0 +1,162 @@
+package agent
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/aftermath2/hydrus/config"
+)
+
+// damper limits how often and how much the routing policies change based on the updates history, peers
+// rate-limit the nodes that send channel updates too frequently.
+type damper struct {
+	history           *policyHistory
+	enabled           bool
+	minInterval       time.Duration
+	maxDailyChange    float64
+	flipFlopUpdates   int
+	maxUpdatesPerHour int
+	// Updates sent across all channels within the last hour, including the ones of the current run
+	recentUpdates int
+}
+
+func newDamper(config config.Damping, history *policyHistory, now time.Time) (*damper, error) {
+	d := &damper{
+		history: history,
+		enabled: config.Enabled,
+	}
+	if config.MinInterval != nil {
+		d.minInterval = *config.MinInterval
+	}
+	if config.MaxDailyChange != nil {
+		d.maxDailyChange = *config.MaxDailyChange
+	}
+	if config.FlipFlopUpdates != nil {
+		d.flipFlopUpdates = *config.FlipFlopUpdates
+	}
+	if config.MaxUpdatesPerHour != nil {
+		d.maxUpdatesPerHour = *config.MaxUpdatesPerHour
+	}
+
+	if d.enabled && d.maxUpdatesPerHour > 0 {
+		count, err := history.countSince(now.Add(-time.Hour))
+		if err != nil {
+			return nil, err
+		}
+		d.recentUpdates = count
+	}
+
+	return d, nil
+}
+
+// damp adjusts the new policy according to the channel updates history. Every update sent counts, but only
+// the fee rate is bounded by its past changes. It returns the policy to send, the current one if the update
+// must be held back, and the reason if it was damped.
+func (d *damper) damp(channelPoint string, current, policy Policy, now time.Time) (Policy, string, error) {
+	if !d.enabled || policy == current {
+		return policy, "", nil
+	}
+
+	// A lower max HTLC avoids forwards the channel can no longer route failing, it's sent even when the
+	// rest of the update is held back
+	held := current
+	held.MaxHTLCMsat = min(current.MaxHTLCMsat, policy.MaxHTLCMsat)
+
+	maxUpdates := d.maxUpdatesPerHour
+	if maxUpdates > 0 && d.recentUpdates >= maxUpdates {
+		return held, fmt.Sprintf("the limit of %d updates per hour was reached", maxUpdates), nil
+	}
+
+	updates, err := d.history.channel(channelPoint)
+	if err != nil {
+		return current, "", err
+	}
+
+	if d.minInterval > 0 && len(updates) > 0 {
+		elapsed := now.Sub(updates[len(updates)-1].Time)
+		if elapsed < d.minInterval {
+			return held, fmt.Sprintf("its routing policy was updated %s ago", elapsed.Round(time.Minute)), nil
+		}
+	}
+
+	if policy.FeeRatePPM == current.FeeRatePPM {
+		return policy, "", nil
+	}
+
+	direction := policyUpdate{PreviousFeeRatePPM: current.FeeRatePPM, FeeRatePPM: policy.FeeRatePPM}.direction()
+	if isFlipFlop(updates, d.flipFlopUpdates, direction) {
+		policy.FeeRatePPM = current.FeeRatePPM
+		return policy, fmt.Sprintf("the last %d fee rate changes alternated direction", d.flipFlopUpdates), nil
+	}
+
+	feeRate := limitDailyChange(
+		updates,
+		d.maxDailyChange,
+		current.FeeRatePPM,
+		policy.FeeRatePPM,
+		now,
+	)
+	if feeRate == policy.FeeRatePPM {
+		return policy, "", nil
+	}
+
+	reason := fmt.Sprintf("the fee rate can't change more than %g%% a day, setting %d ppm instead of %d ppm",
+		d.maxDailyChange*100, feeRate, policy.FeeRatePPM)
+	policy.FeeRatePPM = feeRate
+	return policy, reason, nil
+}
+
+// sent counts an update towards the hourly limit.
+func (d *damper) sent() {
+	d.recentUpdates++
+}
+
+// isFlipFlop returns whether the last n fee rate changes alternate direction and a change in the direction
+// specified would continue alternating. The updates that left the fee rate as it was are ignored.
+func isFlipFlop(updates []policyUpdate, n, direction int) bool {
+	updates = slices.DeleteFunc(slices.Clone(updates), func(u policyUpdate) bool {
+		return u.direction() == 0
+	})
+	if n == 0 || direction == 0 || len(updates) < n {
+		return false
+	}
+
+	expected := -direction
+	for i := len(updates) - 1; i >= len(updates)-n; i-- {
+		if updates[i].direction() != expected {
+			return false
+		}
+		expected = -expected
+	}
+
+	return true
+}
+
+// limitDailyChange bounds the new fee rate to the maximum relative change over the fee rate the channel had
+// a day ago.
+func limitDailyChange(
+	updates []policyUpdate,
+	maxChange float64,
+	currentFeeRate,
+	feeRate uint64,
+	now time.Time,
+) uint64 {
+	// The fee rate a day ago is the one the channel had before the first update within the last day
+	reference := currentFeeRate
+	for i := len(updates) - 1; i >= 0 && now.Sub(updates[i].Time) < 24*time.Hour; i-- {
+		reference = updates[i].PreviousFeeRatePPM
+	}
+
+	// The relative change of a zero fee rate is undefined, do not limit it
+	if maxChange == 0 || reference == 0 {
+		return feeRate
+	}
+
+	maxDelta := float64(reference) * maxChange
+	lowest := math.Max(float64(reference)-maxDelta, 0)
+	highest := float64(reference) + maxDelta
+
+	return uint64(math.Round(min(max(float64(feeRate), lowest), highest)))
+}
@@ -0,0 +1,186 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDamperDamp(t *testing.T) {
+	now := time.Now()
+	minInterval, maxDailyChange := 12*time.Hour, 0.5
+	flipFlopUpdates, maxUpdatesPerHour := 2, 1
+	dampingConfig := config.Damping{
+		Enabled:           true,
+		MinInterval:       &minInterval,
+		MaxDailyChange:    &maxDailyChange,
+		FlipFlopUpdates:   &flipFlopUpdates,
+		MaxUpdatesPerHour: &maxUpdatesPerHour,
+	}
+	current := Policy{FeeRatePPM: 100, MaxHTLCMsat: 1_000}
+
+	tests := []struct {
+		desc           string
+		updates        []policyUpdate
+		config         config.Damping
+		policy         Policy
+		expectedPolicy Policy
+		damped         bool
+	}{
+		{
+			desc:           "Disabled",
+			config:         config.Damping{},
+			updates:        []policyUpdate{{Time: now.Add(-time.Minute), PreviousFeeRatePPM: 50, FeeRatePPM: 100}},
+			policy:         Policy{FeeRatePPM: 500, MaxHTLCMsat: 1_000},
+			expectedPolicy: Policy{FeeRatePPM: 500, MaxHTLCMsat: 1_000},
+		},
+		{
+			desc:           "No history",
+			config:         dampingConfig,
+			policy:         Policy{FeeRatePPM: 120, MaxHTLCMsat: 2_000},
+			expectedPolicy: Policy{FeeRatePPM: 120, MaxHTLCMsat: 2_000},
+		},
+		{
+			desc:           "Updated recently",
+			config:         dampingConfig,
+			updates:        []policyUpdate{{Time: now.Add(-5 * time.Hour), PreviousFeeRatePPM: 50, FeeRatePPM: 100}},
+			policy:         Policy{FeeRatePPM: 120, MaxHTLCMsat: 1_000},
+			expectedPolicy: current,
+			damped:         true,
+		},
+		{
+			desc:           "Max HTLC increase updated recently",
+			config:         dampingConfig,
+			updates:        []policyUpdate{{Time: now.Add(-5 * time.Hour), PreviousFeeRatePPM: 100, FeeRatePPM: 100}},
+			policy:         Policy{FeeRatePPM: 100, MaxHTLCMsat: 2_000},
+			expectedPolicy: current,
+			damped:         true,
+		},
+		{
+			desc:           "Max HTLC increase",
+			config:         dampingConfig,
+			updates:        []policyUpdate{{Time: now.Add(-13 * time.Hour), PreviousFeeRatePPM: 100, FeeRatePPM: 100}},
+			policy:         Policy{FeeRatePPM: 100, MaxHTLCMsat: 2_000},
+			expectedPolicy: Policy{FeeRatePPM: 100, MaxHTLCMsat: 2_000},
+		},
+		{
+			desc:           "Updated recently with a max HTLC decrease",
+			config:         dampingConfig,
+			updates:        []policyUpdate{{Time: now.Add(-5 * time.Hour), PreviousFeeRatePPM: 50, FeeRatePPM: 100}},
+			policy:         Policy{FeeRatePPM: 120, MaxHTLCMsat: 500},
+			expectedPolicy: Policy{FeeRatePPM: 100, MaxHTLCMsat: 500},
+			damped:         true,
+		},
+		{
+			desc:   "Daily change limit",
+			config: dampingConfig,
+			updates: []policyUpdate{
+				{Time: now.Add(-20 * time.Hour), PreviousFeeRatePPM: 80, FeeRatePPM: 100},
+			},
+			policy:         Policy{FeeRatePPM: 500, MaxHTLCMsat: 1_000},
+			expectedPolicy: Policy{FeeRatePPM: 120, MaxHTLCMsat: 1_000},
+			damped:         true,
+		},
+		{
+			desc:   "Flip-flop",
+			config: dampingConfig,
+			updates: []policyUpdate{
+				{Time: now.Add(-72 * time.Hour), PreviousFeeRatePPM: 100, FeeRatePPM: 90},
+				{Time: now.Add(-48 * time.Hour), PreviousFeeRatePPM: 90, FeeRatePPM: 100},
+				{Time: now.Add(-24 * time.Hour), PreviousFeeRatePPM: 100, FeeRatePPM: 100},
+			},
+			policy:         Policy{FeeRatePPM: 90, MaxHTLCMsat: 2_000},
+			expectedPolicy: Policy{FeeRatePPM: 100, MaxHTLCMsat: 2_000},
+			damped:         true,
+		},
+		{
+			desc:   "Same direction",
+			config: dampingConfig,
+			updates: []policyUpdate{
+				{Time: now.Add(-72 * time.Hour), PreviousFeeRatePPM: 100, FeeRatePPM: 90},
+				{Time: now.Add(-48 * time.Hour), PreviousFeeRatePPM: 90, FeeRatePPM: 100},
+			},
+			policy:         Policy{FeeRatePPM: 110, MaxHTLCMsat: 1_000},
+			expectedPolicy: Policy{FeeRatePPM: 110, MaxHTLCMsat: 1_000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			history := newPolicyHistory(t.TempDir())
+			for _, update := range tt.updates {
+				assert.NoError(t, history.record("txid:0", update))
+			}
+
+			damper, err := newDamper(tt.config, history, now.Add(-2*time.Hour))
+			assert.NoError(t, err)
+
+			policy, reason, err := damper.damp("txid:0", current, tt.policy, now)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.damped, reason != "")
+			assert.Equal(t, tt.expectedPolicy, policy)
+		})
+	}
+}
+
+func TestDamperHourlyLimit(t *testing.T) {
+	now := time.Now()
+	history := newPolicyHistory(t.TempDir())
+	err := history.record("txid:1", policyUpdate{Time: now.Add(-30 * time.Minute), FeeRatePPM: 10})
+	assert.NoError(t, err)
+
+	maxUpdatesPerHour := 2
+	damper, err := newDamper(config.Damping{Enabled: true, MaxUpdatesPerHour: &maxUpdatesPerHour}, history, now)
+	assert.NoError(t, err)
+
+	current := Policy{FeeRatePPM: 100}
+	policy, reason, err := damper.damp("txid:0", current, Policy{FeeRatePPM: 200}, now)
+	assert.NoError(t, err)
+	assert.Empty(t, reason)
+	assert.Equal(t, Policy{FeeRatePPM: 200}, policy)
+	damper.sent()
+
+	policy, reason, err = damper.damp("txid:2", current, Policy{FeeRatePPM: 100, MaxHTLCMsat: 1_000}, now)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, reason)
+	assert.Equal(t, current, policy)
+
+	// Max HTLC decreases are sent regardless of the limit
+	current.MaxHTLCMsat = 2_000
+	policy, reason, err = damper.damp("txid:2", current, Policy{FeeRatePPM: 200, MaxHTLCMsat: 1_000}, now)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, reason)
+	assert.Equal(t, Policy{FeeRatePPM: 100, MaxHTLCMsat: 1_000}, policy)
+}
+
+func TestIsFlipFlop(t *testing.T) {
+	up := policyUpdate{PreviousFeeRatePPM: 10, FeeRatePPM: 20}
+	down := policyUpdate{PreviousFeeRatePPM: 20, FeeRatePPM: 10}
+	same := policyUpdate{PreviousFeeRatePPM: 10, FeeRatePPM: 10}
+
+	assert.True(t, isFlipFlop([]policyUpdate{up, down, up}, 3, -1))
+	assert.True(t, isFlipFlop([]policyUpdate{same, down, up}, 2, -1))
+	assert.False(t, isFlipFlop([]policyUpdate{same, down, up}, 3, -1))
+	assert.True(t, isFlipFlop([]policyUpdate{up, same, down, up}, 3, -1))
+	assert.False(t, isFlipFlop([]policyUpdate{up, down, up}, 3, 1))
+	assert.False(t, isFlipFlop([]policyUpdate{up, down}, 3, 1))
+	assert.False(t, isFlipFlop([]policyUpdate{up, down, up}, 0, -1))
+}
+
+func TestLimitDailyChange(t *testing.T) {
+	now := time.Now()
+	updates := []policyUpdate{
+		{Time: now.Add(-30 * time.Hour), PreviousFeeRatePPM: 10, FeeRatePPM: 100},
+		{Time: now.Add(-12 * time.Hour), PreviousFeeRatePPM: 100, FeeRatePPM: 130},
+		{Time: now.Add(-6 * time.Hour), PreviousFeeRatePPM: 130, FeeRatePPM: 140},
+	}
+
+	assert.Equal(t, uint64(150), limitDailyChange(updates, 0.5, 140, 300, now))
+	assert.Equal(t, uint64(50), limitDailyChange(updates, 0.5, 140, 10, now))
+	assert.Equal(t, uint64(120), limitDailyChange(updates, 0.5, 140, 120, now))
+	assert.Equal(t, uint64(300), limitDailyChange(updates, 0, 140, 300, now))
+	assert.Equal(t, uint64(300), limitDailyChange(nil, 0.5, 0, 300, now))
+}
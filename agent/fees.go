@@ -0,0 +1,352 @@
+package agent
+
+import (
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+)
+
+// FeeStrategy computes the new routing policy of a channel.
+type FeeStrategy interface {
+	Policy(state ChannelState, forwards Forwards, current Policy) Policy
+}
+
+// ChannelState contains the channel information available to the fee strategies.
+type ChannelState struct {
+	// Fees charged by the other nodes to reach the channel peer, only available for the strategies using it
+	Market             Market
+	Channel            local.Channel
+	CurrentBlockHeight uint32
+}
+
+// Forwards contains the amounts forwarded through a channel since the last routing policies update.
+type Forwards struct {
+	AmountIn  uint64
+	AmountOut uint64
+}
+
+// Policy is the routing policy of one side of a channel.
+type Policy struct {
+	BaseFeeMsat        uint64
+	FeeRatePPM         uint64
+	MinHTLCMsat        uint64
+	MaxHTLCMsat        uint64
+	TimeLockDelta      uint64
+	InboundBaseFeeMsat int32
+	InboundFeeRatePPM  int32
+}
+
+// feeStrategies holds the global fee strategy and the ones used for specific channels and tags.
+//
+// All methods are safe to call on a nil feeStrategies, in which case the default strategy is used.
+type feeStrategies struct {
+	global   FeeStrategy
+	channels map[string]FeeStrategy
+	// Strategies of the tags setting one, indexed by tag name
+	tags map[string]FeeStrategy
+}
+
+func newFeeStrategies(config config.Fees, tags []config.Tag) *feeStrategies {
+	channels := make(map[string]FeeStrategy, len(config.Channels))
+	for channelPoint, strategy := range config.Channels {
+		channels[channelPoint] = newFeeStrategy(strategy)
+	}
+
+	tagStrategies := make(map[string]FeeStrategy)
+	for _, tag := range tags {
+		if tag.Fees != nil {
+			tagStrategies[tag.Name] = newFeeStrategy(*tag.Fees)
+		}
+	}
+
+	return &feeStrategies{
+		global:   newFeeStrategy(config.FeeStrategy),
+		channels: channels,
+		tags:     tagStrategies,
+	}
+}
+
+// get returns the fee strategy used for the channel. The strategy configured for the channel point takes
+// precedence over the one of its first tag setting a strategy, and both over the global one.
+func (f *feeStrategies) get(channelPoint string, tags TagSet) FeeStrategy {
+	if f == nil {
+		return defaultFeeStrategy{}
+	}
+
+	if strategy, ok := f.channels[channelPoint]; ok {
+		return strategy
+	}
+
+	if name, ok := tags.feeStrategy(); ok {
+		if strategy, ok := f.tags[name]; ok {
+			return strategy
+		}
+	}
+
+	return f.global
+}
+
+// feeStrategyName returns the name of the fee strategy used for the channel, following the same precedence
+// as feeStrategies.get.
+func feeStrategyName(fees config.Fees, channelPoint string, tags TagSet) string {
+	if strategy, ok := fees.Channels[channelPoint]; ok {
+		return strategy.Name
+	}
+
+	for _, tag := range tags {
+		if tag.Fees != nil {
+			return tag.Fees.Name
+		}
+	}
+
+	return fees.Name
+}
+
+// NewFeeStrategy returns the fee strategy configured for the channel, following the same precedence as the
+// agent: the one set for the channel point, the one of its first tag setting a strategy or the global one.
+func NewFeeStrategy(fees config.Fees, tags []config.Tag, channelPoint string, channelTags TagSet) FeeStrategy {
+	return newFeeStrategies(fees, tags).get(channelPoint, channelTags)
+}
+
+func newFeeStrategy(strategy config.FeeStrategy) FeeStrategy {
+	var feeStrategy FeeStrategy
+	switch strategy.Name {
+	case config.FeeStrategyLiquidity:
+		feeStrategy = liquidityFeeStrategy{config: strategy.Liquidity}
+	case config.FeeStrategyPID:
+		feeStrategy = newPIDFeeStrategy(strategy.PID)
+	case config.FeeStrategyFlat:
+		feeStrategy = flatFeeStrategy{config: strategy.Flat}
+	case config.FeeStrategyMarket:
+		feeStrategy = marketFeeStrategy{config: strategy.Market}
+	default:
+		feeStrategy = defaultFeeStrategy{}
+	}
+
+	if enabled := strategy.Inbound.Enabled; enabled != nil && *enabled {
+		return inboundFeeStrategy{next: feeStrategy, config: strategy.Inbound}
+	}
+
+	return feeStrategy
+}
+
+// defaultFeeStrategy raises or lowers the fee rate depending on the local balance and the direction of the
+// forwards.
+type defaultFeeStrategy struct{}
+
+func (defaultFeeStrategy) Policy(state ChannelState, forwards Forwards, current Policy) Policy {
+	current.FeeRatePPM = calculateNewFeeRate(
+		state.Channel,
+		state.CurrentBlockHeight,
+		current.FeeRatePPM,
+		forwards.AmountIn,
+		forwards.AmountOut,
+	)
+	return current
+}
+
+// liquidityFeeStrategy sets a fee rate that grows as the local balance shrinks, following the curve
+// min + (max - min) * (1 - local_ratio)^exponent.
+type liquidityFeeStrategy struct {
+	config config.LiquidityFees
+}
+
+func (l liquidityFeeStrategy) Policy(state ChannelState, _ Forwards, current Policy) Policy {
+	if state.Channel.Capacity == 0 {
+		return current
+	}
+
+	minFeeRate := uint64(0)
+	if l.config.MinFeeRatePPM != nil {
+		minFeeRate = *l.config.MinFeeRatePPM
+	}
+
+	remoteRatio := 1 - localRatio(state.Channel)
+	feeRange := float64(l.config.MaxFeeRatePPM - minFeeRate)
+	feeRate := float64(minFeeRate) + feeRange*math.Pow(remoteRatio, l.config.Exponent)
+
+	current.FeeRatePPM = uint64(math.Round(feeRate))
+	return current
+}
+
+// pidFeeStrategy drives the local balance of the channels towards a target ratio. The further below the
+// target the local balance is, the higher the fee rate, to slow down outgoing payments.
+//
+// The controllers state is kept in memory, one per channel.
+type pidFeeStrategy struct {
+	controllers map[uint64]*pidController
+	config      config.PIDFees
+	mu          sync.Mutex
+}
+
+type pidController struct {
+	integral      float64
+	previousError float64
+	initialized   bool
+}
+
+func newPIDFeeStrategy(config config.PIDFees) *pidFeeStrategy {
+	return &pidFeeStrategy{
+		controllers: make(map[uint64]*pidController),
+		config:      config,
+	}
+}
+
+func (p *pidFeeStrategy) Policy(state ChannelState, _ Forwards, current Policy) Policy {
+	if state.Channel.Capacity == 0 {
+		return current
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	controller, ok := p.controllers[state.Channel.ID]
+	if !ok {
+		controller = &pidController{}
+		p.controllers[state.Channel.ID] = controller
+	}
+
+	err := p.config.TargetRatio - localRatio(state.Channel)
+	integral := controller.integral + err
+	derivative := 0.0
+	if controller.initialized {
+		derivative = err - controller.previousError
+	}
+
+	output := float64(p.config.FeeRatePPM) + p.config.Kp*err + p.config.Ki*integral + p.config.Kd*derivative
+	feeRate := min(max(output, float64(p.config.MinFeeRatePPM)), float64(p.config.MaxFeeRatePPM))
+
+	// Stop accumulating the error while the output is saturated to avoid overshooting when the local
+	// balance goes back within the range
+	if feeRate == output {
+		controller.integral = integral
+	}
+	controller.previousError = err
+	controller.initialized = true
+
+	current.FeeRatePPM = uint64(math.Round(feeRate))
+	return current
+}
+
+// flatFeeStrategy sets a fixed fee rate.
+type flatFeeStrategy struct {
+	config config.FlatFees
+}
+
+func (f flatFeeStrategy) Policy(_ ChannelState, _ Forwards, current Policy) Policy {
+	current.FeeRatePPM = f.config.FeeRatePPM
+	return current
+}
+
+// marketFeeStrategy places the fee rate relative to the ones charged by the other nodes to forward payments
+// to the same peer. It undercuts them when the channel has plenty of local balance and charges a premium
+// when it's running out of it.
+type marketFeeStrategy struct {
+	config config.MarketFees
+}
+
+func (m marketFeeStrategy) Policy(state ChannelState, _ Forwards, current Policy) Policy {
+	if state.Channel.Capacity == 0 || len(state.Market.Channels) == 0 {
+		return current
+	}
+
+	reference := float64(state.Market.Percentile(m.config.Percentile))
+	feeRate := reference
+
+	ratio := localRatio(state.Channel)
+	switch {
+	case ratio > m.config.HighRatio:
+		if m.config.Undercut != nil {
+			feeRate = reference * (1 - *m.config.Undercut)
+		}
+		// Charging less than the cheapest competitor able to route the same payments doesn't attract more
+		// of them
+		if cheapest, ok := state.Market.Cheapest(m.config.MinCapacity); ok {
+			feeRate = max(feeRate, min(float64(cheapest.FeeRatePPM), reference))
+		}
+	case ratio < m.config.LowRatio:
+		if m.config.Premium != nil {
+			feeRate = reference * (1 + *m.config.Premium)
+		}
+	}
+
+	feeRate = min(max(feeRate, float64(m.config.MinFeeRatePPM)), float64(m.config.MaxFeeRatePPM))
+	current.FeeRatePPM = uint64(math.Round(feeRate))
+	return current
+}
+
+// inboundFeeStrategy applies inbound discounts on the channels with a high local balance, to attract the
+// payments that move it back to the remote side, and inbound surcharges on the depleted ones. The outbound
+// fees are decided by the next strategy.
+type inboundFeeStrategy struct {
+	next   FeeStrategy
+	config config.InboundFees
+}
+
+func (i inboundFeeStrategy) Policy(state ChannelState, forwards Forwards, current Policy) Policy {
+	policy := i.next.Policy(state, forwards, current)
+	if state.Channel.Capacity == 0 {
+		return policy
+	}
+
+	policy.InboundBaseFeeMsat = 0
+	policy.InboundFeeRatePPM = 0
+
+	// The fees grow linearly from zero at the ratio thresholds up to the bounds
+	ratio := localRatio(state.Channel)
+	switch {
+	case ratio > i.config.HighRatio:
+		factor := (ratio - i.config.HighRatio) / (1 - i.config.HighRatio)
+		policy.InboundBaseFeeMsat = int32(math.Round(factor * float64(i.config.MinBaseFeeMsat)))
+		if i.config.MinFeeRatePPM != nil {
+			policy.InboundFeeRatePPM = int32(math.Round(factor * float64(*i.config.MinFeeRatePPM)))
+		}
+	case ratio < i.config.LowRatio:
+		factor := (i.config.LowRatio - ratio) / i.config.LowRatio
+		policy.InboundFeeRatePPM = int32(math.Round(factor * float64(i.config.MaxFeeRatePPM)))
+	}
+
+	return policy
+}
+
+// usesFeeStrategy returns whether the strategy is used globally or for any specific channel or tag.
+func usesFeeStrategy(fees config.Fees, tags []config.Tag, name string) bool {
+	if fees.Name == name {
+		return true
+	}
+
+	for _, strategy := range fees.Channels {
+		if strategy.Name == name {
+			return true
+		}
+	}
+
+	return slices.ContainsFunc(tags, func(tag config.Tag) bool {
+		return tag.Fees != nil && tag.Fees.Name == name
+	})
+}
+
+// getForwards returns the amounts forwarded in and out of the channel.
+func getForwards(channelID uint64, events []*lnrpc.ForwardingEvent) Forwards {
+	var forwards Forwards
+	for _, event := range events {
+		if channelID == event.ChanIdIn {
+			forwards.AmountIn += event.AmtIn
+		}
+		if channelID == event.ChanIdOut {
+			forwards.AmountOut += event.AmtOut
+		}
+	}
+
+	return forwards
+}
+
+// localRatio returns the fraction of the channel capacity on our side.
+func localRatio(channel local.Channel) float64 {
+	return float64(channel.LocalBalance) / float64(channel.Capacity)
+}
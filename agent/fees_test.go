@@ -0,0 +1,343 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeeStrategies(t *testing.T) {
+	fees := config.Fees{
+		FeeStrategy: config.FeeStrategy{Name: config.FeeStrategyDefault},
+		Channels: map[string]config.FeeStrategy{
+			"txid:0": {Name: config.FeeStrategyFlat},
+		},
+	}
+	tags := TagSet{
+		{Name: "sinks"},
+		{Name: "exchanges", Fees: &config.FeeStrategy{Name: config.FeeStrategyMarket}},
+		{Name: "large", Fees: &config.FeeStrategy{Name: config.FeeStrategyPID}},
+	}
+	strategies := newFeeStrategies(fees, tags)
+
+	assert.IsType(t, defaultFeeStrategy{}, strategies.get("txid:1", nil))
+	assert.IsType(t, flatFeeStrategy{}, strategies.get("txid:0", nil))
+	assert.IsType(t, flatFeeStrategy{}, strategies.get("txid:0", tags))
+	assert.IsType(t, marketFeeStrategy{}, strategies.get("txid:1", tags))
+	assert.IsType(t, &pidFeeStrategy{}, strategies.get("txid:1", tags[2:]))
+	assert.Equal(t, config.FeeStrategyMarket, feeStrategyName(fees, "txid:1", tags))
+	assert.Equal(t, config.FeeStrategyFlat, feeStrategyName(fees, "txid:0", tags))
+
+	var nilStrategies *feeStrategies
+	assert.IsType(t, defaultFeeStrategy{}, nilStrategies.get("txid:0", tags))
+}
+
+func TestLiquidityFeeStrategy(t *testing.T) {
+	tests := []struct {
+		desc               string
+		channel            local.Channel
+		exponent           float64
+		expectedFeeRatePPM uint64
+	}{
+		{
+			desc:               "Linear",
+			channel:            local.Channel{LocalBalance: 250, Capacity: 1_000},
+			exponent:           1,
+			expectedFeeRatePPM: 850,
+		},
+		{
+			desc:               "Quadratic",
+			channel:            local.Channel{LocalBalance: 250, Capacity: 1_000},
+			exponent:           2,
+			expectedFeeRatePPM: 663,
+		},
+		{
+			desc:               "Full local balance",
+			channel:            local.Channel{LocalBalance: 1_000, Capacity: 1_000},
+			exponent:           1,
+			expectedFeeRatePPM: 100,
+		},
+		{
+			desc:               "Depleted",
+			channel:            local.Channel{LocalBalance: 0, Capacity: 1_000},
+			exponent:           3,
+			expectedFeeRatePPM: 1_100,
+		},
+		{
+			desc:               "No capacity",
+			exponent:           1,
+			expectedFeeRatePPM: 50,
+		},
+	}
+
+	minFeeRate := uint64(100)
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			strategy := liquidityFeeStrategy{
+				config: config.LiquidityFees{
+					MinFeeRatePPM: &minFeeRate,
+					MaxFeeRatePPM: 1_100,
+					Exponent:      tt.exponent,
+				},
+			}
+			current := Policy{FeeRatePPM: 50, TimeLockDelta: 80}
+
+			policy := strategy.Policy(ChannelState{Channel: tt.channel}, Forwards{}, current)
+			assert.Equal(t, tt.expectedFeeRatePPM, policy.FeeRatePPM)
+			assert.Equal(t, current.TimeLockDelta, policy.TimeLockDelta)
+		})
+	}
+}
+
+func TestPIDFeeStrategy(t *testing.T) {
+	tests := []struct {
+		desc                string
+		maxFeeRatePPM       uint64
+		localBalances       []uint64
+		expectedFeeRatesPPM []uint64
+		expectedIntegral    float64
+	}{
+		{
+			desc:                "Depleting and refilling",
+			maxFeeRatePPM:       5_000,
+			localBalances:       []uint64{200, 200, 800},
+			expectedFeeRatesPPM: []uint64{860, 920, 200},
+			expectedIntegral:    0.3,
+		},
+		{
+			desc:                "At target",
+			maxFeeRatePPM:       5_000,
+			localBalances:       []uint64{500, 500},
+			expectedFeeRatesPPM: []uint64{500, 500},
+			expectedIntegral:    0,
+		},
+		{
+			desc:                "Saturated",
+			maxFeeRatePPM:       600,
+			localBalances:       []uint64{200, 200},
+			expectedFeeRatesPPM: []uint64{600, 600},
+			expectedIntegral:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			strategy := newPIDFeeStrategy(config.PIDFees{
+				TargetRatio:   0.5,
+				FeeRatePPM:    500,
+				MaxFeeRatePPM: tt.maxFeeRatePPM,
+				Kp:            1_000,
+				Ki:            200,
+				Kd:            100,
+			})
+
+			for i, localBalance := range tt.localBalances {
+				state := ChannelState{
+					Channel: local.Channel{ID: 1, LocalBalance: localBalance, Capacity: 1_000},
+				}
+				policy := strategy.Policy(state, Forwards{}, Policy{FeeRatePPM: 100})
+				assert.Equal(t, tt.expectedFeeRatesPPM[i], policy.FeeRatePPM)
+			}
+
+			assert.InDelta(t, tt.expectedIntegral, strategy.controllers[1].integral, 1e-9)
+		})
+	}
+}
+
+func TestFlatFeeStrategy(t *testing.T) {
+	strategy := flatFeeStrategy{config: config.FlatFees{FeeRatePPM: 250}}
+	current := Policy{BaseFeeMsat: 1_000, FeeRatePPM: 100}
+
+	policy := strategy.Policy(ChannelState{}, Forwards{}, current)
+	assert.Equal(t, Policy{BaseFeeMsat: 1_000, FeeRatePPM: 250}, policy)
+}
+
+func TestMarketFeeStrategy(t *testing.T) {
+	undercut, premium := 0.1, 0.25
+	strategy := marketFeeStrategy{
+		config: config.MarketFees{
+			Percentile:    0.5,
+			HighRatio:     0.7,
+			LowRatio:      0.3,
+			Undercut:      &undercut,
+			Premium:       &premium,
+			MinCapacity:   1_000_000,
+			MaxFeeRatePPM: 1_000,
+		},
+	}
+	market := Market{
+		Channels: []MarketChannel{
+			{Capacity: 500_000, FeeRatePPM: 50},
+			{Capacity: 2_000_000, FeeRatePPM: 195},
+			{Capacity: 2_000_000, FeeRatePPM: 200},
+			{Capacity: 2_000_000, FeeRatePPM: 900},
+		},
+	}
+
+	tests := []struct {
+		desc               string
+		market             Market
+		localBalance       uint64
+		expectedFeeRatePPM uint64
+	}{
+		{
+			desc:               "Balanced",
+			market:             market,
+			localBalance:       500,
+			expectedFeeRatePPM: 195,
+		},
+		{
+			desc:               "Undercut limited by the cheapest competitor",
+			market:             market,
+			localBalance:       900,
+			expectedFeeRatePPM: 195,
+		},
+		{
+			desc: "Undercut",
+			market: Market{
+				Channels: []MarketChannel{
+					{Capacity: 2_000_000, FeeRatePPM: 200},
+					{Capacity: 2_000_000, FeeRatePPM: 300},
+					{Capacity: 2_000_000, FeeRatePPM: 500},
+				},
+			},
+			localBalance:       900,
+			expectedFeeRatePPM: 270,
+		},
+		{
+			desc:               "Premium",
+			market:             market,
+			localBalance:       100,
+			expectedFeeRatePPM: 244,
+		},
+		{
+			desc: "Maximum",
+			market: Market{
+				Channels: []MarketChannel{{Capacity: 2_000_000, FeeRatePPM: 2_000}},
+			},
+			localBalance:       100,
+			expectedFeeRatePPM: 1_000,
+		},
+		{
+			desc:               "No competitors",
+			market:             Market{},
+			localBalance:       100,
+			expectedFeeRatePPM: 80,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			state := ChannelState{
+				Channel: local.Channel{LocalBalance: tt.localBalance, Capacity: 1_000},
+				Market:  tt.market,
+			}
+
+			policy := strategy.Policy(state, Forwards{}, Policy{FeeRatePPM: 80})
+			assert.Equal(t, tt.expectedFeeRatePPM, policy.FeeRatePPM)
+		})
+	}
+}
+
+func TestUsesFeeStrategy(t *testing.T) {
+	fees := config.Fees{
+		FeeStrategy: config.FeeStrategy{Name: config.FeeStrategyDefault},
+		Channels: map[string]config.FeeStrategy{
+			"txid:0": {Name: config.FeeStrategyMarket},
+		},
+	}
+
+	tags := []config.Tag{{Name: "large", Fees: &config.FeeStrategy{Name: config.FeeStrategyPID}}}
+
+	assert.True(t, usesFeeStrategy(fees, nil, config.FeeStrategyDefault))
+	assert.True(t, usesFeeStrategy(fees, nil, config.FeeStrategyMarket))
+	assert.False(t, usesFeeStrategy(fees, nil, config.FeeStrategyPID))
+	assert.True(t, usesFeeStrategy(fees, tags, config.FeeStrategyPID))
+}
+
+func TestDefaultFeeStrategy(t *testing.T) {
+	state := ChannelState{Channel: local.Channel{LocalBalance: 500, Capacity: 1_000}}
+	forwards := Forwards{AmountIn: 1_000, AmountOut: 1_700}
+
+	policy := defaultFeeStrategy{}.Policy(state, forwards, Policy{FeeRatePPM: 50})
+	assert.Equal(t, uint64(60), policy.FeeRatePPM)
+}
+
+func TestGetForwards(t *testing.T) {
+	channelID := uint64(1)
+	events := []*lnrpc.ForwardingEvent{
+		{ChanIdIn: channelID, AmtIn: 1_000, ChanIdOut: 2, AmtOut: 990},
+		{ChanIdIn: 2, AmtIn: 510, ChanIdOut: channelID, AmtOut: 500},
+		{ChanIdIn: 3, AmtIn: 100, ChanIdOut: 2, AmtOut: 90},
+	}
+
+	forwards := getForwards(channelID, events)
+	assert.Equal(t, Forwards{AmountIn: 1_000, AmountOut: 500}, forwards)
+}
+
+func TestInboundFeeStrategy(t *testing.T) {
+	tests := []struct {
+		desc                       string
+		localBalance               uint64
+		expectedInboundBaseFeeMsat int32
+		expectedInboundFeeRatePPM  int32
+	}{
+		{
+			desc:                       "High local balance",
+			localBalance:               900,
+			expectedInboundBaseFeeMsat: -500,
+			expectedInboundFeeRatePPM:  -100,
+		},
+		{
+			desc:                       "Full local balance",
+			localBalance:               1_000,
+			expectedInboundBaseFeeMsat: -1_000,
+			expectedInboundFeeRatePPM:  -200,
+		},
+		{
+			desc:         "Balanced",
+			localBalance: 500,
+		},
+		{
+			desc:                      "Low local balance",
+			localBalance:              50,
+			expectedInboundFeeRatePPM: 75,
+		},
+		{
+			desc:                      "Depleted",
+			localBalance:              0,
+			expectedInboundFeeRatePPM: 100,
+		},
+	}
+
+	enabled := true
+	minFeeRate := int32(-200)
+	strategy := newFeeStrategy(config.FeeStrategy{
+		Name: config.FeeStrategyFlat,
+		Flat: config.FlatFees{FeeRatePPM: 250},
+		Inbound: config.InboundFees{
+			Enabled:        &enabled,
+			HighRatio:      0.8,
+			LowRatio:       0.2,
+			MinFeeRatePPM:  &minFeeRate,
+			MinBaseFeeMsat: -1_000,
+			MaxFeeRatePPM:  100,
+		},
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			state := ChannelState{Channel: local.Channel{LocalBalance: tt.localBalance, Capacity: 1_000}}
+			current := Policy{FeeRatePPM: 100, InboundBaseFeeMsat: -10, InboundFeeRatePPM: -50}
+
+			policy := strategy.Policy(state, Forwards{}, current)
+			assert.Equal(t, uint64(250), policy.FeeRatePPM)
+			assert.Equal(t, tt.expectedInboundBaseFeeMsat, policy.InboundBaseFeeMsat)
+			assert.Equal(t, tt.expectedInboundFeeRatePPM, policy.InboundFeeRatePPM)
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package agent
+
+import (
+	"cmp"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	policyHistoryFile = "policy_history.json"
+	// Period of time the policy updates are kept for
+	policyHistoryRetention = 30 * 24 * time.Hour
+)
+
+// policyUpdate is a routing policy update sent for a channel.
+type policyUpdate struct {
+	Time               time.Time `json:"time"`
+	PreviousFeeRatePPM uint64    `json:"previous_fee_rate_ppm"`
+	FeeRatePPM         uint64    `json:"fee_rate_ppm"`
+}
+
+// direction returns 1 if the update raised the fee rate, -1 if it lowered it and 0 otherwise.
+func (u policyUpdate) direction() int {
+	return cmp.Compare(u.FeeRatePPM, u.PreviousFeeRatePPM)
+}
+
+// ChannelPolicyUpdate is a routing policy update sent by the agent for one of the channels.
+type ChannelPolicyUpdate struct {
+	ChannelPoint       string    `json:"channel_point"`
+	Time               time.Time `json:"time"`
+	PreviousFeeRatePPM uint64    `json:"previous_fee_rate_ppm"`
+	FeeRatePPM         uint64    `json:"fee_rate_ppm"`
+}
+
+// ReadPolicyUpdates returns the routing policy updates recorded by the agent in the data directory, sorted
+// from newest to oldest.
+func ReadPolicyUpdates(dataDir string) ([]ChannelPolicyUpdate, error) {
+	h := newPolicyHistory(dataDir)
+	if h == nil {
+		return nil, nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.load(); err != nil {
+		return nil, err
+	}
+
+	var result []ChannelPolicyUpdate
+	for point, updates := range h.updates {
+		for _, update := range updates {
+			result = append(result, ChannelPolicyUpdate{
+				ChannelPoint:       point,
+				Time:               update.Time,
+				PreviousFeeRatePPM: update.PreviousFeeRatePPM,
+				FeeRatePPM:         update.FeeRatePPM,
+			})
+		}
+	}
+
+	slices.SortStableFunc(result, func(a, b ChannelPolicyUpdate) int {
+		if c := b.Time.Compare(a.Time); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ChannelPoint, b.ChannelPoint)
+	})
+
+	return result, nil
+}
+
+// policyHistory records the routing policy updates of each channel in a file, so that they are taken into
+// account after restarts. The file is read the first time the history is used.
+//
+// All methods are safe to call on a nil policyHistory, in which case nothing is recorded.
+type policyHistory struct {
+	// map[channel_point]updates sorted from oldest to newest
+	updates map[string][]policyUpdate
+	path    string
+	mu      sync.Mutex
+	loaded  bool
+}
+
+func newPolicyHistory(dataDir string) *policyHistory {
+	if dataDir == "" {
+		return nil
+	}
+
+	return &policyHistory{path: filepath.Join(dataDir, policyHistoryFile)}
+}
+
+// channel returns the updates of the channel sorted from oldest to newest.
+func (h *policyHistory) channel(channelPoint string) ([]policyUpdate, error) {
+	if h == nil {
+		return nil, nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.load(); err != nil {
+		return nil, err
+	}
+
+	return slices.Clone(h.updates[channelPoint]), nil
+}
+
+// countSince returns the number of updates sent across all channels since the time specified.
+func (h *policyHistory) countSince(since time.Time) (int, error) {
+	if h == nil {
+		return 0, nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.load(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, updates := range h.updates {
+		for _, update := range updates {
+			if update.Time.After(since) {
+				count++
+			}
+		}
+	}
+
+	return count, nil
+}
+
+// record adds the update to the channel history and persists it, discarding the updates older than the
+// retention period.
+func (h *policyHistory) record(channelPoint string, update policyUpdate) error {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.load(); err != nil {
+		return err
+	}
+
+	h.updates[channelPoint] = append(h.updates[channelPoint], update)
+
+	oldest := update.Time.Add(-policyHistoryRetention)
+	for point, updates := range h.updates {
+		updates = slices.DeleteFunc(updates, func(u policyUpdate) bool {
+			return u.Time.Before(oldest)
+		})
+		if len(updates) == 0 {
+			delete(h.updates, point)
+			continue
+		}
+		h.updates[point] = updates
+	}
+
+	return h.save()
+}
+
+// load reads the history file if it wasn't read already. It must be called with the lock held.
+func (h *policyHistory) load() error {
+	if h.loaded {
+		return nil
+	}
+
+	updates := make(map[string][]policyUpdate)
+	data, err := os.ReadFile(h.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrap(err, "reading policy history")
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &updates); err != nil {
+			return errors.Wrap(err, "decoding policy history")
+		}
+	}
+
+	h.updates = updates
+	h.loaded = true
+	return nil
+}
+
+// save writes the history to a temporary file and replaces the previous one with it, so that the history
+// isn't left corrupted if the agent stops while writing it. It must be called with the lock held.
+func (h *policyHistory) save() error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return errors.Wrap(err, "creating data directory")
+	}
+
+	data, err := json.Marshal(h.updates)
+	if err != nil {
+		return errors.Wrap(err, "encoding policy history")
+	}
+
+	tmpPath := h.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return errors.Wrap(err, "writing policy history")
+	}
+
+	if err := os.Rename(tmpPath, h.path); err != nil {
+		return errors.Wrap(err, "replacing policy history")
+	}
+
+	return nil
+}
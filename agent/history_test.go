@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyHistory(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+	history := newPolicyHistory(dir)
+
+	updates, err := history.channel("txid:0")
+	assert.NoError(t, err)
+	assert.Empty(t, updates)
+
+	old := policyUpdate{Time: now.Add(-policyHistoryRetention - time.Hour), FeeRatePPM: 10}
+	first := policyUpdate{Time: now.Add(-2 * time.Hour), PreviousFeeRatePPM: 10, FeeRatePPM: 20}
+	second := policyUpdate{Time: now, PreviousFeeRatePPM: 20, FeeRatePPM: 15}
+	assert.NoError(t, history.record("txid:1", old))
+	assert.NoError(t, history.record("txid:0", first))
+	assert.NoError(t, history.record("txid:0", second))
+
+	// Read the history persisted from a new instance
+	history = newPolicyHistory(dir)
+	updates, err = history.channel("txid:0")
+	assert.NoError(t, err)
+	assert.Len(t, updates, 2)
+	assert.True(t, second.Time.Equal(updates[1].Time))
+	assert.Equal(t, second.FeeRatePPM, updates[1].FeeRatePPM)
+
+	// Updates older than the retention period are discarded
+	updates, err = history.channel("txid:1")
+	assert.NoError(t, err)
+	assert.Empty(t, updates)
+
+	count, err := history.countSince(now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestReadPolicyUpdates(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+	history := newPolicyHistory(dir)
+
+	updates, err := ReadPolicyUpdates(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, updates)
+
+	assert.NoError(t, history.record("txid:0", policyUpdate{Time: now.Add(-time.Hour), FeeRatePPM: 20}))
+	assert.NoError(t, history.record("txid:1", policyUpdate{Time: now, PreviousFeeRatePPM: 5, FeeRatePPM: 10}))
+	assert.NoError(t, history.record("txid:0", policyUpdate{Time: now, PreviousFeeRatePPM: 20, FeeRatePPM: 15}))
+
+	updates, err = ReadPolicyUpdates(dir)
+	assert.NoError(t, err)
+
+	expected := []ChannelPolicyUpdate{
+		{ChannelPoint: "txid:0", Time: now, PreviousFeeRatePPM: 20, FeeRatePPM: 15},
+		{ChannelPoint: "txid:1", Time: now, PreviousFeeRatePPM: 5, FeeRatePPM: 10},
+		{ChannelPoint: "txid:0", Time: now.Add(-time.Hour), FeeRatePPM: 20},
+	}
+	assert.Len(t, updates, len(expected))
+	for i, update := range updates {
+		assert.Equal(t, expected[i].ChannelPoint, update.ChannelPoint)
+		assert.True(t, expected[i].Time.Equal(update.Time))
+		assert.Equal(t, expected[i].FeeRatePPM, update.FeeRatePPM)
+	}
+
+	updates, err = ReadPolicyUpdates("")
+	assert.NoError(t, err)
+	assert.Nil(t, updates)
+}
+
+func TestPolicyHistoryInvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, policyHistoryFile), []byte("{"), 0o600)
+	assert.NoError(t, err)
+
+	_, err = newPolicyHistory(dir).channel("txid:0")
+	assert.Error(t, err)
+}
+
+func TestNilPolicyHistory(t *testing.T) {
+	history := newPolicyHistory("")
+	assert.Nil(t, history)
+
+	assert.NoError(t, history.record("txid:0", policyUpdate{Time: time.Now()}))
+
+	updates, err := history.channel("txid:0")
+	assert.NoError(t, err)
+	assert.Empty(t, updates)
+}
@@ -2,6 +2,7 @@ package local
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"github.com/aftermath2/hydrus/config"
@@ -15,6 +16,8 @@ const (
 	oneDay   = time.Hour * 24
 	oneWeek  = oneDay * 7
 	oneMonth = oneDay * 30
+	// Average time between blocks
+	blockInterval = time.Minute * 10
 )
 
 // Channels contains a list of public channels and their heuristics.
@@ -25,18 +28,51 @@ type Channels struct {
 
 // Channel represents a public channel the node currently has.
 type Channel struct {
-	ID              uint64 `json:"id,omitempty"`
-	Point           string `json:"point,omitempty"`
-	Active          bool   `json:"active,omitempty"`
-	BlockHeight     uint32 `json:"block_height,omitempty"`
-	RemotePublicKey string `json:"remote_public_key,omitempty"`
-	Capacity        uint64 `json:"capacity,omitempty"`
-	NumForwards     uint64 `json:"num_forwards,omitempty"`
-	ForwardsAmount  uint64 `json:"forwards_amount,omitempty"`
-	LocalBalance    uint64 `json:"local_balance,omitempty"`
-	Fees            uint64 `json:"fees,omitempty"`
-	PingTime        int64  `json:"ping_time,omitempty"`
-	FlapCount       int32  `json:"flap_count,omitempty"`
+	ID              uint64  `json:"id,omitempty"`
+	Point           string  `json:"point,omitempty"`
+	Active          bool    `json:"active,omitempty"`
+	BlockHeight     uint32  `json:"block_height,omitempty"`
+	RemotePublicKey string  `json:"remote_public_key,omitempty"`
+	Capacity        uint64  `json:"capacity,omitempty"`
+	LocalBalance    uint64  `json:"local_balance,omitempty"`
+	LocalReserve    uint64  `json:"local_reserve,omitempty"`
+	PendingOutgoing uint64  `json:"pending_outgoing,omitempty"`
+	PingTime        int64   `json:"ping_time,omitempty"`
+	FlapCount       int32   `json:"flap_count,omitempty"`
+	Rates           []Rates `json:"rates,omitempty"`
+	// Lowest HTLC and highest value in flight accepted by the channel, in millisats
+	MinHTLCMsat    uint64 `json:"min_htlc_msat,omitempty"`
+	MaxPendingMsat uint64 `json:"max_pending_msat,omitempty"`
+}
+
+// Rates contains the forwarding activity of a channel within a lookback window, expressed per day of the
+// channel's lifetime inside the window so young and old channels can be compared.
+type Rates struct {
+	// Length of the window in days
+	Days           int     `json:"days"`
+	NumForwards    float64 `json:"num_forwards"`
+	ForwardsAmount float64 `json:"forwards_amount"`
+	Fees           float64 `json:"fees"`
+}
+
+// Age returns the estimated time elapsed since the channel was opened.
+func (c Channel) Age(currentBlockHeight uint32) time.Duration {
+	if c.BlockHeight == 0 || currentBlockHeight <= c.BlockHeight {
+		return 0
+	}
+
+	return time.Duration(currentBlockHeight-c.BlockHeight) * blockInterval
+}
+
+// LongestRates returns the channel rates within the longest window.
+func (c Channel) LongestRates() Rates {
+	if len(c.Rates) == 0 {
+		return Rates{}
+	}
+
+	return slices.MaxFunc(c.Rates, func(a, b Rates) int {
+		return a.Days - b.Days
+	})
 }
 
 // getChannels returns the node's list of public channels along with their heuristics.
@@ -44,16 +80,31 @@ func getChannels(
 	ctx context.Context,
 	lnd lightning.Client,
 	closeWeights config.CloseWeights,
+	windows []time.Duration,
+	currentBlockHeight uint32,
 	channels []*lnrpc.Channel,
 	peers []*lnrpc.Peer,
 ) (Channels, error) {
-	oneMonthAgo := uint64(time.Now().Add(-oneMonth).Unix())
-	forwards, err := ListForwards(ctx, lnd, 0, oneMonthAgo, 0)
+	windows = slices.Clone(windows)
+	slices.Sort(windows)
+
+	now := time.Now()
+	startTime := now
+	if len(windows) > 0 {
+		startTime = now.Add(-windows[len(windows)-1])
+	}
+
+	forwards, err := ListForwards(ctx, lnd, 0, uint64(startTime.Unix()), 0)
 	if err != nil {
 		return Channels{}, err
 	}
 
-	heuristics := NewHeuristics(closeWeights)
+	windowForwards := make([][]*lnrpc.ForwardingEvent, len(windows))
+	for i, window := range windows {
+		windowForwards[i] = filterForwards(forwards, now.Add(-window))
+	}
+
+	heuristics := NewHeuristics(closeWeights, windows)
 	chans := make([]Channel, 0, len(channels))
 	for _, channel := range channels {
 		if channel.Private {
@@ -61,8 +112,8 @@ func getChannels(
 			continue
 		}
 
-		numForwards, forwardsAmount, fees := getForwardsInfo(channel, forwards)
 		pingTime, flapCount := getPeerInfo(channel, peers)
+		constraints := channel.GetLocalConstraints()
 
 		channel := Channel{
 			ID:              channel.ChanId,
@@ -70,13 +121,21 @@ func getChannels(
 			Point:           channel.ChannelPoint,
 			Active:          channel.Active,
 			Capacity:        uint64(channel.Capacity),
-			NumForwards:     numForwards,
-			ForwardsAmount:  forwardsAmount,
 			LocalBalance:    uint64(channel.LocalBalance),
-			Fees:            fees,
+			LocalReserve:    constraints.GetChanReserveSat(),
+			PendingOutgoing: getPendingOutgoing(channel.PendingHtlcs),
+			MinHTLCMsat:     constraints.GetMinHtlcMsat(),
+			MaxPendingMsat:  constraints.GetMaxPendingAmtMsat(),
 			RemotePublicKey: channel.RemotePubkey,
 			PingTime:        pingTime,
 			FlapCount:       flapCount,
+			Rates:           make([]Rates, len(windows)),
+		}
+
+		age := channel.Age(currentBlockHeight)
+		for i, window := range windows {
+			numForwards, forwardsAmount, fees := getForwardsInfo(channel.ID, windowForwards[i])
+			channel.Rates[i] = getRates(window, age, numForwards, forwardsAmount, fees)
 		}
 
 		heuristics.Update(channel)
@@ -86,6 +145,48 @@ func getChannels(
 	return Channels{List: chans, Heuristics: *heuristics}, nil
 }
 
+// getPendingOutgoing returns the value of the outgoing HTLCs in flight.
+func getPendingOutgoing(htlcs []*lnrpc.HTLC) uint64 {
+	pending := uint64(0)
+	for _, htlc := range htlcs {
+		if !htlc.Incoming {
+			pending += uint64(htlc.Amount)
+		}
+	}
+
+	return pending
+}
+
+// getRates divides the channel activity within the window by the days the channel was open inside it.
+func getRates(window, age time.Duration, numForwards, forwardsAmount, fees uint64) Rates {
+	lifetime := window
+	if age > 0 {
+		lifetime = min(window, age)
+	}
+	// Count at least one day so that channels opened a few hours ago don't get inflated rates
+	days := max(float64(lifetime)/float64(oneDay), 1)
+
+	return Rates{
+		Days:           int(window / oneDay),
+		NumForwards:    round(float64(numForwards) / days),
+		ForwardsAmount: round(float64(forwardsAmount) / days),
+		Fees:           round(float64(fees) / days),
+	}
+}
+
+// filterForwards returns the forwards that took place after the start time.
+func filterForwards(forwards []*lnrpc.ForwardingEvent, start time.Time) []*lnrpc.ForwardingEvent {
+	startNs := uint64(start.UnixNano())
+	filtered := make([]*lnrpc.ForwardingEvent, 0, len(forwards))
+	for _, forward := range forwards {
+		if forward.TimestampNs >= startNs {
+			filtered = append(filtered, forward)
+		}
+	}
+
+	return filtered
+}
+
 // ListForwards gets all channels forwards by paginating over LND's ListForwards RPC.
 func ListForwards(
 	ctx context.Context,
@@ -116,10 +217,10 @@ func ListForwards(
 	return events, nil
 }
 
-func getForwardsInfo(channel *lnrpc.Channel, forwards []*lnrpc.ForwardingEvent) (uint64, uint64, uint64) {
+func getForwardsInfo(channelID uint64, forwards []*lnrpc.ForwardingEvent) (uint64, uint64, uint64) {
 	var numForwards, forwardsAmount, fees uint64
 	for _, forward := range forwards {
-		if forward.ChanIdIn == channel.ChanId {
+		if forward.ChanIdIn == channelID {
 			numForwards++
 			forwardsAmount += forward.AmtInMsat
 			// Even though we collect fees in the other part of the circuit, we are counting fees for this
@@ -127,7 +228,7 @@ func getForwardsInfo(channel *lnrpc.Channel, forwards []*lnrpc.ForwardingEvent)
 			fees += forward.FeeMsat
 		}
 
-		if forward.ChanIdOut == channel.ChanId {
+		if forward.ChanIdOut == channelID {
 			numForwards++
 			forwardsAmount += forward.AmtOutMsat
 			fees += forward.FeeMsat
@@ -2,6 +2,7 @@ package local
 
 import (
 	"testing"
+	"time"
 
 	"github.com/aftermath2/hydrus/config"
 	"github.com/aftermath2/hydrus/heuristic"
@@ -22,6 +23,16 @@ func TestGetChannels(t *testing.T) {
 		ChannelPoint: "e5b8ccc43b4eea6e2664a843e27d82c6d71d2885e7aef73777dd35c737c1d7bc:1",
 		Active:       true,
 		Capacity:     1_000_000,
+		LocalConstraints: &lnrpc.ChannelConstraints{
+			ChanReserveSat:    10_000,
+			MinHtlcMsat:       1,
+			MaxPendingAmtMsat: 990_000_000,
+		},
+		PendingHtlcs: []*lnrpc.HTLC{
+			{Incoming: true, Amount: 2_000},
+			{Amount: 5_000},
+			{Amount: 1_000},
+		},
 	}
 	ch2 := &lnrpc.Channel{
 		ChanId:       152250023293560,
@@ -38,28 +49,32 @@ func TestGetChannels(t *testing.T) {
 		Capacity:     100_000,
 	}
 
+	now := time.Now()
 	forwardsResp := &lnrpc.ForwardingHistoryResponse{
 		ForwardingEvents: []*lnrpc.ForwardingEvent{
 			{
-				ChanIdIn:   ch1.ChanId,
-				ChanIdOut:  ch2.ChanId,
-				AmtInMsat:  1_000,
-				AmtOutMsat: 900,
-				FeeMsat:    100,
+				ChanIdIn:    ch1.ChanId,
+				ChanIdOut:   ch2.ChanId,
+				AmtInMsat:   1_000,
+				AmtOutMsat:  900,
+				FeeMsat:     100,
+				TimestampNs: uint64(now.Add(-time.Hour).UnixNano()),
 			},
 			{
-				ChanIdIn:   ch3.ChanId,
-				ChanIdOut:  ch2.ChanId,
-				AmtInMsat:  15_000,
-				AmtOutMsat: 14_000,
-				FeeMsat:    1_000,
+				ChanIdIn:    ch3.ChanId,
+				ChanIdOut:   ch2.ChanId,
+				AmtInMsat:   15_000,
+				AmtOutMsat:  14_000,
+				FeeMsat:     1_000,
+				TimestampNs: uint64(now.Add(-oneDay).UnixNano()),
 			},
 			{
-				ChanIdIn:   ch1.ChanId,
-				ChanIdOut:  ch3.ChanId,
-				AmtInMsat:  5_000,
-				AmtOutMsat: 4_500,
-				FeeMsat:    500,
+				ChanIdIn:    ch1.ChanId,
+				ChanIdOut:   ch3.ChanId,
+				AmtInMsat:   5_000,
+				AmtOutMsat:  4_500,
+				FeeMsat:     500,
+				TimestampNs: uint64(now.Add(-oneDay * 10).UnixNano()),
 			},
 		},
 		LastOffsetIndex: 3,
@@ -104,12 +119,17 @@ func TestGetChannels(t *testing.T) {
 				Point:           ch1.ChannelPoint,
 				BlockHeight:     174,
 				Capacity:        uint64(ch1.Capacity),
+				LocalReserve:    10_000,
+				PendingOutgoing: 6_000,
+				MinHTLCMsat:     1,
+				MaxPendingMsat:  990_000_000,
 				Active:          ch1.Active,
-				NumForwards:     2,
-				ForwardsAmount:  6_000,
-				Fees:            600,
 				PingTime:        peers[0].PingTime,
 				FlapCount:       peers[0].FlapCount,
+				Rates: []Rates{
+					{Days: 7, NumForwards: 0.143, ForwardsAmount: 142.857, Fees: 14.286},
+					{Days: 30, NumForwards: 0.067, ForwardsAmount: 200, Fees: 20},
+				},
 			},
 			{
 				ID:              ch2.ChanId,
@@ -118,11 +138,12 @@ func TestGetChannels(t *testing.T) {
 				BlockHeight:     138,
 				Capacity:        uint64(ch2.Capacity),
 				Active:          ch2.Active,
-				NumForwards:     2,
-				ForwardsAmount:  14_900,
-				Fees:            1_100,
 				PingTime:        peers[1].PingTime,
 				FlapCount:       peers[1].FlapCount,
+				Rates: []Rates{
+					{Days: 7, NumForwards: 0.286, ForwardsAmount: 2128.571, Fees: 157.143},
+					{Days: 30, NumForwards: 0.067, ForwardsAmount: 496.667, Fees: 36.667},
+				},
 			},
 			{
 				ID:              ch3.ChanId,
@@ -131,26 +152,39 @@ func TestGetChannels(t *testing.T) {
 				BlockHeight:     191,
 				Capacity:        uint64(ch3.Capacity),
 				Active:          ch3.Active,
-				NumForwards:     2,
-				ForwardsAmount:  19_500,
-				Fees:            1_500,
 				PingTime:        peers[2].PingTime,
 				FlapCount:       peers[2].FlapCount,
+				Rates: []Rates{
+					{Days: 7, NumForwards: 0.143, ForwardsAmount: 2142.857, Fees: 142.857},
+					{Days: 30, NumForwards: 0.067, ForwardsAmount: 650, Fees: 50},
+				},
 			},
 		},
 		Heuristics: Heuristics{
-			Active:         heuristic.NewFull(0, 1, weights.Active, false),
-			Capacity:       heuristic.NewFull(uint64(ch3.Capacity), uint64(ch2.Capacity), weights.Capacity, false),
-			NumForwards:    heuristic.NewFull[uint64](2, 2, weights.NumForwards, false),
-			ForwardsAmount: heuristic.NewFull[uint64](6_000, 19_500, weights.ForwardsAmount, false),
-			Fees:           heuristic.NewFull[uint64](600, 1_500, weights.Fees, false),
-			BlockHeight:    heuristic.NewFull[uint64](138, 191, weights.BlockHeight, true),
-			PingTime:       heuristic.NewFull(uint64(peers[1].PingTime), uint64(peers[2].PingTime), weights.PingTime, true),
-			FlapCount:      heuristic.NewFull(uint64(peers[1].FlapCount), uint64(peers[2].FlapCount), weights.FlapCount, true),
+			Active:      heuristic.NewFull(0, 1, weights.Active, false),
+			Capacity:    heuristic.NewFull(uint64(ch3.Capacity), uint64(ch2.Capacity), weights.Capacity, false),
+			BlockHeight: heuristic.NewFull[uint64](138, 191, weights.BlockHeight, true),
+			PingTime:    heuristic.NewFull(uint64(peers[1].PingTime), uint64(peers[2].PingTime), weights.PingTime, true),
+			FlapCount:   heuristic.NewFull(uint64(peers[1].FlapCount), uint64(peers[2].FlapCount), weights.FlapCount, true),
+			Rates: []RatesHeuristics{
+				{
+					Days:           7,
+					NumForwards:    heuristic.NewFull(0.143, 0.286, weights.NumForwards/2, false),
+					ForwardsAmount: heuristic.NewFull(142.857, 2142.857, weights.ForwardsAmount/2, false),
+					Fees:           heuristic.NewFull(14.286, 157.143, weights.Fees/2, false),
+				},
+				{
+					Days:           30,
+					NumForwards:    heuristic.NewFull(0.067, 0.067, weights.NumForwards/2, false),
+					ForwardsAmount: heuristic.NewFull[float64](200, 650, weights.ForwardsAmount/2, false),
+					Fees:           heuristic.NewFull[float64](20, 50, weights.Fees/2, false),
+				},
+			},
 		},
 	}
 
-	chans, err := getChannels(ctx, lndMock, weights, channels, peers)
+	windows := []time.Duration{oneMonth, oneWeek}
+	chans, err := getChannels(ctx, lndMock, weights, windows, 100_000, channels, peers)
 	assert.NoError(t, err)
 
 	assert.Equal(t, expectedChannels, chans)
@@ -306,7 +340,7 @@ func TestGetForwardsInfo(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			numForwards, forwardsAmount, fees := getForwardsInfo(tt.channel, tt.events)
+			numForwards, forwardsAmount, fees := getForwardsInfo(tt.channel.ChanId, tt.events)
 
 			assert.Equal(t, tt.expectedNumForwards, numForwards)
 			assert.Equal(t, tt.expectedForwardsAmount, forwardsAmount)
@@ -315,6 +349,67 @@ func TestGetForwardsInfo(t *testing.T) {
 	}
 }
 
+func TestGetRates(t *testing.T) {
+	tests := []struct {
+		name          string
+		window        time.Duration
+		age           time.Duration
+		expectedRates Rates
+	}{
+		{
+			name:   "Old channel",
+			window: oneWeek,
+			age:    oneMonth,
+			expectedRates: Rates{
+				Days:           7,
+				NumForwards:    2,
+				ForwardsAmount: 1_000,
+				Fees:           10,
+			},
+		},
+		{
+			name:   "Young channel",
+			window: oneMonth,
+			age:    oneDay * 2,
+			expectedRates: Rates{
+				Days:           30,
+				NumForwards:    7,
+				ForwardsAmount: 3_500,
+				Fees:           35,
+			},
+		},
+		{
+			name:   "Channel opened hours ago",
+			window: oneWeek,
+			age:    time.Hour * 3,
+			expectedRates: Rates{
+				Days:           7,
+				NumForwards:    14,
+				ForwardsAmount: 7_000,
+				Fees:           70,
+			},
+		},
+		{
+			name:   "Unknown age",
+			window: oneWeek,
+			age:    0,
+			expectedRates: Rates{
+				Days:           7,
+				NumForwards:    2,
+				ForwardsAmount: 1_000,
+				Fees:           10,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rates := getRates(tt.window, tt.age, 14, 7_000, 70)
+			assert.Equal(t, tt.expectedRates, rates)
+		})
+	}
+}
+
 func TestGetPeerInfo(t *testing.T) {
 	remotePublicKey := "test"
 
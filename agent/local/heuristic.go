@@ -1,7 +1,9 @@
 package local
 
 import (
+	"fmt"
 	"math"
+	"time"
 
 	"github.com/aftermath2/hydrus/config"
 	"github.com/aftermath2/hydrus/heuristic"
@@ -10,27 +12,44 @@ import (
 // Heuristics contains useful information from the local channels that can be used to decide which channels to
 // close.
 type Heuristics struct {
-	Active         *heuristic.Heuristic[int]    `json:"active,omitempty"`
-	Capacity       *heuristic.Heuristic[uint64] `json:"capacity,omitempty"`
-	NumForwards    *heuristic.Heuristic[uint64] `json:"num_forwards,omitempty"`
-	ForwardsAmount *heuristic.Heuristic[uint64] `json:"forwards_amount,omitempty"`
-	Fees           *heuristic.Heuristic[uint64] `json:"fees,omitempty"`
-	PingTime       *heuristic.Heuristic[uint64] `json:"ping_time,omitempty"`
-	BlockHeight    *heuristic.Heuristic[uint64] `json:"block_height,omitempty"`
-	FlapCount      *heuristic.Heuristic[uint64] `json:"flap_count,omitempty"`
+	Active      *heuristic.Heuristic[int]    `json:"active,omitempty"`
+	Capacity    *heuristic.Heuristic[uint64] `json:"capacity,omitempty"`
+	PingTime    *heuristic.Heuristic[uint64] `json:"ping_time,omitempty"`
+	BlockHeight *heuristic.Heuristic[uint64] `json:"block_height,omitempty"`
+	FlapCount   *heuristic.Heuristic[uint64] `json:"flap_count,omitempty"`
+	Rates       []RatesHeuristics            `json:"rates,omitempty"`
+}
+
+// RatesHeuristics contains the heuristics of the channels forwarding rates within a lookback window.
+type RatesHeuristics struct {
+	Days           int                           `json:"days"`
+	NumForwards    *heuristic.Heuristic[float64] `json:"num_forwards,omitempty"`
+	ForwardsAmount *heuristic.Heuristic[float64] `json:"forwards_amount,omitempty"`
+	Fees           *heuristic.Heuristic[float64] `json:"fees,omitempty"`
 }
 
 // NewHeuristics returns a new Heuristics object with its values initialized and ready to be updated.
-func NewHeuristics(weight config.CloseWeights) *Heuristics {
+//
+// The forwarding weights are split equally between the windows.
+func NewHeuristics(weight config.CloseWeights, windows []time.Duration) *Heuristics {
+	rates := make([]RatesHeuristics, 0, len(windows))
+	for _, window := range windows {
+		n := float64(len(windows))
+		rates = append(rates, RatesHeuristics{
+			Days:           int(window / oneDay),
+			NumForwards:    heuristic.New[float64](weight.NumForwards/n, false),
+			ForwardsAmount: heuristic.New[float64](weight.ForwardsAmount/n, false),
+			Fees:           heuristic.New[float64](weight.Fees/n, false),
+		})
+	}
+
 	return &Heuristics{
-		Active:         heuristic.NewFull(0, 1, weight.Active, false),
-		Capacity:       heuristic.New[uint64](weight.Capacity, false),
-		NumForwards:    heuristic.New[uint64](weight.NumForwards, false),
-		ForwardsAmount: heuristic.New[uint64](weight.ForwardsAmount, false),
-		Fees:           heuristic.New[uint64](weight.Fees, false),
-		BlockHeight:    heuristic.New[uint64](weight.BlockHeight, true),
-		PingTime:       heuristic.New[uint64](weight.PingTime, true),
-		FlapCount:      heuristic.New[uint64](weight.FlapCount, true),
+		Active:      heuristic.NewFull(0, 1, weight.Active, false),
+		Capacity:    heuristic.New[uint64](weight.Capacity, false),
+		BlockHeight: heuristic.New[uint64](weight.BlockHeight, true),
+		PingTime:    heuristic.New[uint64](weight.PingTime, true),
+		FlapCount:   heuristic.New[uint64](weight.FlapCount, true),
+		Rates:       rates,
 	}
 }
 
@@ -45,22 +64,64 @@ func (h *Heuristics) GetScore(channel Channel) float64 {
 	score += h.Active.GetScore(active)
 	score += h.Capacity.GetScore(channel.Capacity)
 	score += h.BlockHeight.GetScore(uint64(channel.BlockHeight))
-	score += h.NumForwards.GetScore(channel.NumForwards)
-	score += h.ForwardsAmount.GetScore(channel.ForwardsAmount)
-	score += h.Fees.GetScore(channel.Fees)
 	score += h.PingTime.GetScore(uint64(channel.PingTime))
 	score += h.FlapCount.GetScore(uint64(channel.FlapCount))
 
+	for i, rates := range channel.Rates {
+		if i >= len(h.Rates) {
+			break
+		}
+		score += h.Rates[i].NumForwards.GetScore(rates.NumForwards)
+		score += h.Rates[i].ForwardsAmount.GetScore(rates.ForwardsAmount)
+		score += h.Rates[i].Fees.GetScore(rates.Fees)
+	}
+
 	return math.Round(score*1000) / 1000
 }
 
 // Update heuristics based on the node values.
 func (h *Heuristics) Update(channel Channel) {
 	h.Capacity.Update(channel.Capacity)
-	h.NumForwards.Update(channel.NumForwards)
-	h.ForwardsAmount.Update(channel.ForwardsAmount)
-	h.Fees.Update(channel.Fees)
 	h.BlockHeight.Update(uint64(channel.BlockHeight))
 	h.PingTime.Update(uint64(channel.PingTime))
 	h.FlapCount.Update(uint64(channel.FlapCount))
+
+	for i, rates := range channel.Rates {
+		if i >= len(h.Rates) {
+			break
+		}
+		h.Rates[i].NumForwards.Update(rates.NumForwards)
+		h.Rates[i].ForwardsAmount.Update(rates.ForwardsAmount)
+		h.Rates[i].Fees.Update(rates.Fees)
+	}
+}
+
+// Explain returns the contribution of each heuristic to the channel's score.
+func (h *Heuristics) Explain(channel Channel) []heuristic.Component {
+	active := 0
+	if channel.Active {
+		active = 1
+	}
+
+	components := []heuristic.Component{
+		h.Active.Explain("active", active),
+		h.Capacity.Explain("capacity", channel.Capacity),
+		h.BlockHeight.Explain("block_height", uint64(channel.BlockHeight)),
+		h.PingTime.Explain("ping_time", uint64(channel.PingTime)),
+		h.FlapCount.Explain("flap_count", uint64(channel.FlapCount)),
+	}
+
+	for i, rates := range channel.Rates {
+		if i >= len(h.Rates) {
+			break
+		}
+		prefix := fmt.Sprintf("rates.%dd.", h.Rates[i].Days)
+		components = append(components,
+			h.Rates[i].NumForwards.Explain(prefix+"num_forwards", rates.NumForwards),
+			h.Rates[i].ForwardsAmount.Explain(prefix+"forwards_amount", rates.ForwardsAmount),
+			h.Rates[i].Fees.Explain(prefix+"fees", rates.Fees),
+		)
+	}
+
+	return components
 }
@@ -2,6 +2,7 @@ package local_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/aftermath2/hydrus/agent/local"
 	"github.com/aftermath2/hydrus/config"
@@ -9,16 +10,16 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+var windows = []time.Duration{time.Hour * 24 * 7}
+
 func TestHeuristicsGetScore(t *testing.T) {
 	channel := local.Channel{
-		Active:         true,
-		BlockHeight:    200,
-		Capacity:       500_000,
-		NumForwards:    14,
-		ForwardsAmount: 2_000,
-		Fees:           10,
-		PingTime:       200,
-		FlapCount:      3,
+		Active:      true,
+		BlockHeight: 200,
+		Capacity:    500_000,
+		Rates:       []local.Rates{{Days: 7, NumForwards: 14, ForwardsAmount: 2_000, Fees: 10}},
+		PingTime:    200,
+		FlapCount:   3,
 	}
 
 	tests := []struct {
@@ -29,31 +30,27 @@ func TestHeuristicsGetScore(t *testing.T) {
 	}{
 		{
 			desc:       "Default values",
-			heuristics: local.NewHeuristics(config.DefaultCloseWeights),
+			heuristics: local.NewHeuristics(config.DefaultCloseWeights, windows),
 			channel: local.Channel{
-				Active:         true,
-				Capacity:       1_000_000,
-				BlockHeight:    50,
-				NumForwards:    25,
-				ForwardsAmount: 25_000,
-				Fees:           1_500,
-				PingTime:       700,
-				FlapCount:      1,
+				Active:      true,
+				Capacity:    1_000_000,
+				BlockHeight: 50,
+				Rates:       []local.Rates{{Days: 7, NumForwards: 25, ForwardsAmount: 25_000, Fees: 1_500}},
+				PingTime:    700,
+				FlapCount:   1,
 			},
 			expectedScore: 5.1,
 		},
 		{
 			desc:       "Default values 2",
-			heuristics: local.NewHeuristics(config.DefaultCloseWeights),
+			heuristics: local.NewHeuristics(config.DefaultCloseWeights, windows),
 			channel: local.Channel{
-				Active:         false,
-				Capacity:       1_000_000,
-				BlockHeight:    250,
-				NumForwards:    5,
-				ForwardsAmount: 200,
-				Fees:           15,
-				PingTime:       50,
-				FlapCount:      1,
+				Active:      false,
+				Capacity:    1_000_000,
+				BlockHeight: 250,
+				Rates:       []local.Rates{{Days: 7, NumForwards: 5, ForwardsAmount: 200, Fees: 15}},
+				PingTime:    50,
+				FlapCount:   1,
 			},
 			expectedScore: 2.1,
 		},
@@ -68,16 +65,14 @@ func TestHeuristicsGetScore(t *testing.T) {
 				Fees:           1,
 				PingTime:       1,
 				FlapCount:      1,
-			}),
+			}, windows),
 			channel: local.Channel{
-				Active:         true,
-				Capacity:       2_000_000,
-				BlockHeight:    250,
-				NumForwards:    32,
-				ForwardsAmount: 5_000,
-				Fees:           500,
-				PingTime:       550,
-				FlapCount:      1,
+				Active:      true,
+				Capacity:    2_000_000,
+				BlockHeight: 250,
+				Rates:       []local.Rates{{Days: 7, NumForwards: 32, ForwardsAmount: 5_000, Fees: 500}},
+				PingTime:    550,
+				FlapCount:   1,
 			},
 			expectedScore: 6,
 		},
@@ -106,23 +101,21 @@ func TestHeuristicsUpdate(t *testing.T) {
 		BlockHeight:    0.9,
 	}
 	channel := local.Channel{
-		Capacity:       100,
-		NumForwards:    50,
-		ForwardsAmount: 200,
-		Fees:           30,
-		PingTime:       700,
-		BlockHeight:    800,
-		Active:         true,
+		Capacity:    100,
+		Rates:       []local.Rates{{Days: 7, NumForwards: 50, ForwardsAmount: 200, Fees: 30}},
+		PingTime:    700,
+		BlockHeight: 800,
+		Active:      true,
 	}
 
-	h := local.NewHeuristics(config)
+	h := local.NewHeuristics(config, windows)
 	h.Update(channel)
 
 	result := 1.0
 	assert.Equal(t, result*config.Capacity, h.Capacity.GetScore(channel.Capacity))
-	assert.Equal(t, result*config.NumForwards, h.NumForwards.GetScore(channel.NumForwards))
-	assert.Equal(t, result*config.ForwardsAmount, h.ForwardsAmount.GetScore(channel.ForwardsAmount))
-	assert.Equal(t, result*config.Fees, h.Fees.GetScore(channel.Fees))
+	assert.Equal(t, result*config.NumForwards, h.Rates[0].NumForwards.GetScore(channel.Rates[0].NumForwards))
+	assert.Equal(t, result*config.ForwardsAmount, h.Rates[0].ForwardsAmount.GetScore(channel.Rates[0].ForwardsAmount))
+	assert.Equal(t, result*config.Fees, h.Rates[0].Fees.GetScore(channel.Rates[0].Fees))
 	assert.Equal(t, result*config.PingTime, h.PingTime.GetScore(uint64(channel.PingTime)))
 	assert.Equal(t, result*config.BlockHeight, h.BlockHeight.GetScore(uint64(channel.BlockHeight)))
 	assert.Equal(t, result*config.Active, h.Active.GetScore(1))
@@ -136,7 +129,7 @@ func TestHeuristicsUpdateField(t *testing.T) {
 	value := uint64(200)
 	expectedScore := 0.3
 
-	h := local.NewHeuristics(config)
+	h := local.NewHeuristics(config, windows)
 	h.Update(channel1)
 	h.Update(channel2)
 	h.Update(channel3)
@@ -144,3 +137,40 @@ func TestHeuristicsUpdateField(t *testing.T) {
 	actualScore := h.Capacity.GetScore(value)
 	assert.Equal(t, expectedScore, actualScore)
 }
+
+func TestHeuristicsExplain(t *testing.T) {
+	heuristics := local.NewHeuristics(config.DefaultCloseWeights, windows)
+	channels := []local.Channel{
+		{
+			Active:      true,
+			Capacity:    1_000_000,
+			BlockHeight: 50,
+			Rates:       []local.Rates{{Days: 7, NumForwards: 25, ForwardsAmount: 25_000, Fees: 1_500}},
+			PingTime:    700,
+			FlapCount:   1,
+		},
+		{
+			Capacity:    3_000_000,
+			BlockHeight: 250,
+			Rates:       []local.Rates{{Days: 7, NumForwards: 5, ForwardsAmount: 1_000, Fees: 20}},
+			PingTime:    100,
+			FlapCount:   4,
+		},
+	}
+	for _, channel := range channels {
+		heuristics.Update(channel)
+	}
+
+	components := heuristics.Explain(channels[0])
+	assert.Len(t, components, 8)
+	assert.Equal(t, "rates.7d.num_forwards", components[5].Name)
+	assert.Equal(t, 25.0, components[5].Value)
+	assert.Equal(t, 5.0, components[5].Lowest)
+	assert.Equal(t, 25.0, components[5].Highest)
+
+	total := 0.0
+	for _, component := range components {
+		total += component.Score
+	}
+	assert.InDelta(t, heuristics.GetScore(channels[0]), total, 0.001)
+}
@@ -17,6 +17,7 @@ type Node struct {
 	SyncPeers          map[string]struct{}          `json:"sync_peers,omitempty"`
 	PublicKey          string                       `json:"public_key,omitempty"`
 	ClosedChannels     []*lnrpc.ChannelCloseSummary `json:"closed_channels,omitempty"`
+	WalletBalance      uint64                       `json:"wallet_balance,omitempty"`
 	AllocatedBalance   uint64                       `json:"allocated_balance,omitempty"`
 	NumChannels        uint64                       `json:"num_channels,omitempty"`
 	MaxOpenChannels    uint64                       `json:"max_open_channels,omitempty"`
@@ -79,7 +80,15 @@ func GetNode(ctx context.Context, config config.Agent, lnd lightning.Client) (No
 		return Node{}, errors.Wrap(err, "estimating transaction fee")
 	}
 
-	chans, err := getChannels(ctx, lnd, config.HeuristicWeights.Close, channels, peers)
+	chans, err := getChannels(
+		ctx,
+		lnd,
+		config.HeuristicWeights.Close,
+		config.Closing.Windows,
+		info.BlockHeight,
+		channels,
+		peers,
+	)
 	if err != nil {
 		return Node{}, err
 	}
@@ -105,6 +114,7 @@ func GetNode(ctx context.Context, config config.Agent, lnd lightning.Client) (No
 	return Node{
 		CurrentBlockHeight: info.BlockHeight,
 		PublicKey:          info.IdentityPubkey,
+		WalletBalance:      uint64(wallet.ConfirmedBalance),
 		AllocatedBalance:   allocatedBalance,
 		NumChannels:        numChannels,
 		MaxOpenChannels:    maxOpenChannels,
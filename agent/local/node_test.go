@@ -3,6 +3,7 @@ package local_test
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/aftermath2/hydrus/agent/local"
 	"github.com/aftermath2/hydrus/config"
@@ -48,6 +49,9 @@ func TestGetNode(t *testing.T) {
 					Close: config.DefaultCloseWeights,
 					Open:  config.DefaultOpenWeights,
 				},
+				Closing: config.Closing{
+					Windows: []time.Duration{time.Hour * 24 * 7},
+				},
 			}
 
 			infoResp := &lnrpc.GetInfoResponse{
@@ -84,9 +88,10 @@ func TestGetNode(t *testing.T) {
 			forwardsResp := &lnrpc.ForwardingHistoryResponse{
 				ForwardingEvents: []*lnrpc.ForwardingEvent{
 					{
-						ChanIdIn:  channelID,
-						AmtInMsat: 500,
-						FeeMsat:   10,
+						ChanIdIn:    channelID,
+						AmtInMsat:   500,
+						FeeMsat:     10,
+						TimestampNs: uint64(time.Now().UnixNano()),
 					},
 				},
 			}
@@ -110,6 +115,7 @@ func TestGetNode(t *testing.T) {
 					peersResp[0].PubKey: {},
 				},
 				ClosedChannels:     closedChannelsResp,
+				WalletBalance:      uint64(walletResp.ConfirmedBalance),
 				AllocatedBalance:   uint64(walletResp.ConfirmedBalance),
 				NumChannels:        numChannels,
 				MaxOpenChannels:    tt.maxOpenChannels,
@@ -125,22 +131,27 @@ func TestGetNode(t *testing.T) {
 							ID:              channelsResp[0].ChanId,
 							BlockHeight:     174,
 							Capacity:        uint64(channelsResp[0].Capacity),
-							NumForwards:     1,
-							ForwardsAmount:  500,
-							Fees:            10,
 							FlapCount:       peersResp[0].FlapCount,
 							PingTime:        peersResp[0].PingTime,
+							Rates: []local.Rates{
+								{Days: 7, NumForwards: 1, ForwardsAmount: 500, Fees: 10},
+							},
 						},
 					},
 					Heuristics: local.Heuristics{
-						Active:         heuristic.NewFull(0, 1, config.HeuristicWeights.Close.Active, false),
-						Capacity:       heuristic.NewFull[uint64](2_000_000, 2_000_000, config.HeuristicWeights.Close.Capacity, false),
-						NumForwards:    heuristic.NewFull[uint64](1, 1, config.HeuristicWeights.Close.NumForwards, false),
-						ForwardsAmount: heuristic.NewFull[uint64](500, 500, config.HeuristicWeights.Close.ForwardsAmount, false),
-						Fees:           heuristic.NewFull[uint64](10, 10, config.HeuristicWeights.Close.Fees, false),
-						BlockHeight:    heuristic.NewFull[uint64](174, 174, config.HeuristicWeights.Close.BlockHeight, true),
-						PingTime:       heuristic.NewFull[uint64](300, 300, config.HeuristicWeights.Close.PingTime, true),
-						FlapCount:      heuristic.NewFull[uint64](1, 1, config.HeuristicWeights.Close.FlapCount, true),
+						Active:      heuristic.NewFull(0, 1, config.HeuristicWeights.Close.Active, false),
+						Capacity:    heuristic.NewFull[uint64](2_000_000, 2_000_000, config.HeuristicWeights.Close.Capacity, false),
+						BlockHeight: heuristic.NewFull[uint64](174, 174, config.HeuristicWeights.Close.BlockHeight, true),
+						PingTime:    heuristic.NewFull[uint64](300, 300, config.HeuristicWeights.Close.PingTime, true),
+						FlapCount:   heuristic.NewFull[uint64](1, 1, config.HeuristicWeights.Close.FlapCount, true),
+						Rates: []local.RatesHeuristics{
+							{
+								Days:           7,
+								NumForwards:    heuristic.NewFull[float64](1, 1, config.HeuristicWeights.Close.NumForwards, false),
+								ForwardsAmount: heuristic.NewFull[float64](500, 500, config.HeuristicWeights.Close.ForwardsAmount, false),
+								Fees:           heuristic.NewFull[float64](10, 10, config.HeuristicWeights.Close.Fees, false),
+							},
+						},
 					},
 				},
 			}
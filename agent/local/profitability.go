@@ -0,0 +1,53 @@
+package local
+
+import (
+	"math"
+
+	"github.com/aftermath2/hydrus/config"
+)
+
+const (
+	daysPerYear = 365
+	// Virtual size of a cooperative close transaction spending the 2-of-2 funding output into two P2WPKH
+	// outputs
+	closeTxVBytes = 169
+	// Virtual size of a funding transaction with one P2WPKH input, the P2WSH funding output and change
+	fundingTxVBytes = 153
+)
+
+// Profitability contains the economic figures used to decide whether a channel is worth keeping. All
+// values are expressed in satoshis.
+type Profitability struct {
+	FeesPerDay        float64 `json:"fees_per_day"`
+	CapitalCostPerDay float64 `json:"capital_cost_per_day"`
+	CloseReopenCost   uint64  `json:"close_reopen_cost"`
+	// Net return of keeping the channel over the horizon, including the close-and-reopen cost saved by
+	// not closing it
+	ExpectedReturn float64 `json:"expected_return"`
+}
+
+// GetProfitability compares the fees the channel earned per day within the longest window with the cost of
+// the capital locked in it and with the cost of closing it and opening a new one at the current fee rate.
+func (c Channel) GetProfitability(config config.Profitability, satvB uint64) Profitability {
+	feesPerDay := c.LongestRates().Fees / 1000
+	opportunityRate := 0.0
+	if config.OpportunityRate != nil {
+		opportunityRate = *config.OpportunityRate
+	}
+	capitalCostPerDay := float64(c.Capacity) * opportunityRate / daysPerYear
+	closeReopenCost := (closeTxVBytes + fundingTxVBytes) * satvB
+	horizon := float64(config.Horizon) / float64(oneDay)
+
+	expectedReturn := (feesPerDay-capitalCostPerDay)*horizon + float64(closeReopenCost)
+
+	return Profitability{
+		FeesPerDay:        round(feesPerDay),
+		CapitalCostPerDay: round(capitalCostPerDay),
+		CloseReopenCost:   closeReopenCost,
+		ExpectedReturn:    round(expectedReturn),
+	}
+}
+
+func round(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
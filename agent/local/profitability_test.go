@@ -0,0 +1,81 @@
+package local_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetProfitability(t *testing.T) {
+	opportunityRate := 0.01
+	profitabilityConfig := config.Profitability{
+		OpportunityRate: &opportunityRate,
+		Horizon:         time.Hour * 24 * 90,
+	}
+
+	tests := []struct {
+		desc                  string
+		channel               local.Channel
+		satvB                 uint64
+		expectedProfitability local.Profitability
+	}{
+		{
+			desc: "Profitable",
+			channel: local.Channel{
+				Capacity: 1_000_000,
+				Rates: []local.Rates{
+					{Days: 7, Fees: 500_000},
+					{Days: 30, Fees: 1_000_000},
+				},
+			},
+			satvB: 10,
+			expectedProfitability: local.Profitability{
+				FeesPerDay:        1000,
+				CapitalCostPerDay: 27.397,
+				CloseReopenCost:   3220,
+				ExpectedReturn:    90754.247,
+			},
+		},
+		{
+			desc: "Idle",
+			channel: local.Channel{
+				Capacity: 5_000_000,
+			},
+			satvB: 2,
+			expectedProfitability: local.Profitability{
+				FeesPerDay:        0,
+				CapitalCostPerDay: 136.986,
+				CloseReopenCost:   644,
+				ExpectedReturn:    -11684.767,
+			},
+		},
+		{
+			desc: "Low fees",
+			channel: local.Channel{
+				Capacity: 1_000_000,
+				Rates: []local.Rates{
+					{Days: 90, Fees: 50_000},
+					{Days: 7, Fees: 200_000},
+				},
+			},
+			satvB: 1,
+			expectedProfitability: local.Profitability{
+				FeesPerDay:        50,
+				CapitalCostPerDay: 27.397,
+				CloseReopenCost:   322,
+				ExpectedReturn:    2356.247,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			profitability := tt.channel.GetProfitability(profitabilityConfig, tt.satvB)
+			assert.Equal(t, tt.expectedProfitability, profitability)
+		})
+	}
+}
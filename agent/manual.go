@@ -0,0 +1,266 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/channel"
+	"github.com/aftermath2/hydrus/graph"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/pkg/errors"
+)
+
+// ManualOpen contains the channels to open chosen by hand.
+type ManualOpen struct {
+	// map[public_key]funding_amount
+	Peers map[string]uint64
+	// Maximum number of the agent's own candidates added to the batch, limited by the channels and balance
+	// left after the manual picks
+	Candidates int
+}
+
+// PlanOpen checks the channels chosen by hand against the same rules applied to the agent's candidates and
+// returns the batch to open, completed with the agent's candidates if requested. The peers we aren't connected
+// to are included in the request so the channel manager connects to them when opening.
+func (a *agent) PlanOpen(
+	ctx context.Context,
+	localNode local.Node,
+	manual ManualOpen,
+) (channel.OpenRequest, error) {
+	if err := checkSatvB(a.config.ChannelManager.MaxSatvB, localNode); err != nil {
+		return channel.OpenRequest{}, err
+	}
+
+	channelGraph, err := a.graphCache.get(ctx, a.lnd)
+	if err != nil {
+		return channel.OpenRequest{}, errors.Wrap(err, "getting channel graph")
+	}
+
+	networkGraph, err := graph.FromChannelGraph(ctx, a.config.HeuristicWeights.Open, channelGraph)
+	if err != nil {
+		return channel.OpenRequest{}, errors.Wrap(err, "creating graph")
+	}
+
+	tagger := NewTagger(a.config, channelGraph)
+	limits := fundingLimits{
+		maxChannelSize: a.config.MaxChannelSize,
+		nodeCapacity:   nodeCapacity(localNode),
+	}
+	nodes := make(map[string]uint64, len(manual.Peers))
+	peers := make([]graph.Node, 0, len(manual.Peers))
+	total := uint64(0)
+	var problems []string
+	for _, publicKey := range slices.Sorted(maps.Keys(manual.Peers)) {
+		amount := manual.Peers[publicKey]
+		node, ok := FindNode(channelGraph, networkGraph, publicKey)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: not found in the network graph", publicKey))
+			continue
+		}
+
+		reasons := DiscardReasons(localNode, node, tagger)
+		minSize := max(a.config.MinChannelSize, a.peerMinChannelSizes.get(publicKey))
+		maxSize := limits.maxSize(tagger.Node(node))
+		if amount < minSize || amount > maxSize {
+			reasons = append(reasons, fmt.Sprintf("amount %d is out of the channel size range [%d, %d]",
+				amount, minSize, maxSize))
+		}
+		if len(reasons) > 0 {
+			problems = append(problems, fmt.Sprintf("%s: %s", publicKey, strings.Join(reasons, ", ")))
+			continue
+		}
+
+		nodes[publicKey] = amount
+		peers = append(peers, node)
+		total += amount
+	}
+
+	if len(problems) > 0 {
+		return channel.OpenRequest{}, errors.Errorf("discarded peers: %s", strings.Join(problems, "; "))
+	}
+
+	if uint64(len(nodes)) > localNode.MaxOpenChannels {
+		return channel.OpenRequest{}, errors.Errorf(
+			"number of channels to open (%d) is higher than the maximum (%d)",
+			len(nodes), localNode.MaxOpenChannels,
+		)
+	}
+
+	if total > localNode.AllocatedBalance {
+		return channel.OpenRequest{}, errors.Errorf(
+			"total funding amount (%d) is higher than the allocated balance (%d)",
+			total, localNode.AllocatedBalance,
+		)
+	}
+
+	if manual.Candidates > 0 {
+		// The agent's candidates share what is left after the manual picks, which are treated as channel peers
+		// so they aren't selected twice
+		remaining := localNode
+		remaining.MaxOpenChannels = min(uint64(manual.Candidates), localNode.MaxOpenChannels-uint64(len(nodes)))
+		remaining.AllocatedBalance = localNode.AllocatedBalance - total
+		remaining.ChannelPeers = maps.Clone(localNode.ChannelPeers)
+		if remaining.ChannelPeers == nil {
+			remaining.ChannelPeers = make(map[string]struct{}, len(nodes))
+		}
+		for publicKey := range nodes {
+			remaining.ChannelPeers[publicKey] = struct{}{}
+		}
+
+		candidates := getCandidateNodes(a.logger, remaining, networkGraph, tagger)
+		selector := newSelector(a.config.Selection, a.config.HeuristicWeights.Open.Centrality, remaining, networkGraph)
+		selected := a.selectNodes(ctx, remaining, candidates, selector, false)
+		for _, candidate := range candidates {
+			if _, ok := selected[candidate.PublicKey]; ok {
+				peers = append(peers, graph.Node{PublicKey: candidate.PublicKey, Addresses: candidate.Addresses})
+			}
+		}
+		maps.Copy(nodes, selected)
+	}
+
+	if len(nodes) == 0 {
+		return channel.OpenRequest{}, errors.New("no channels to open")
+	}
+
+	if uint64(len(nodes)) < a.config.MinBatchSize {
+		return channel.OpenRequest{}, errors.Errorf(
+			"number of channels to open (%d) is lower than the minimum batch size (%d)",
+			len(nodes), a.config.MinBatchSize,
+		)
+	}
+
+	// Connecting is left to the channel manager so planning has no side effects
+	addresses := make(map[string][]string)
+	for _, peer := range peers {
+		if _, ok := localNode.SyncPeers[peer.PublicKey]; !ok {
+			addresses[peer.PublicKey] = peer.Addresses
+		}
+	}
+
+	return channel.OpenRequest{
+		Nodes: nodes,
+		Peers: addresses,
+		SatvB: localNode.SatvB,
+	}, nil
+}
+
+// PlanClose checks the channels chosen by hand against the same rules applied to the agent's candidates and
+// returns the request to close them.
+func (a *agent) PlanClose(
+	ctx context.Context,
+	localNode local.Node,
+	channelPoints []string,
+) (channel.CloseRequest, error) {
+	if err := checkSatvB(a.config.ChannelManager.MaxSatvB, localNode); err != nil {
+		return channel.CloseRequest{}, err
+	}
+
+	tagger, err := a.tagger(ctx)
+	if err != nil {
+		return channel.CloseRequest{}, err
+	}
+
+	channels := make(map[string]bool, len(channelPoints))
+	var problems []string
+	for _, channelPoint := range channelPoints {
+		i := slices.IndexFunc(localNode.Channels.List, func(c local.Channel) bool {
+			return c.Point == channelPoint
+		})
+		if i == -1 {
+			problems = append(problems, fmt.Sprintf("%s: channel not found", channelPoint))
+			continue
+		}
+
+		ch := localNode.Channels.List[i]
+		tags := tagger.Channel(ch)
+		if err := discardChannel(localNode, ch, tags, a.config.Closing); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", channelPoint, err))
+			continue
+		}
+
+		if !ch.Active && !tags.allowForceCloses(a.config.AllowForceCloses) {
+			problems = append(problems, fmt.Sprintf("%s: channel is inactive and force closes aren't allowed",
+				channelPoint))
+			continue
+		}
+
+		channels[channelPoint] = !ch.Active
+	}
+
+	if len(problems) > 0 {
+		return channel.CloseRequest{}, errors.Errorf("discarded channels: %s", strings.Join(problems, "; "))
+	}
+
+	if len(channels) == 0 {
+		return channel.CloseRequest{}, errors.New("no channels to close")
+	}
+
+	if uint64(len(channels)) > localNode.MaxCloseChannels {
+		return channel.CloseRequest{}, errors.Errorf(
+			"number of channels to close (%d) is higher than the maximum (%d)",
+			len(channels), localNode.MaxCloseChannels,
+		)
+	}
+
+	return channel.CloseRequest{
+		Channels: channels,
+		SatvB:    localNode.SatvB,
+	}, nil
+}
+
+// checkSatvB returns an error if the estimated transaction fee is higher than the maximum.
+func checkSatvB(maxSatvB uint64, localNode local.Node) error {
+	if localNode.SatvB > maxSatvB {
+		return errors.Errorf("the estimated transaction fee per virtual byte (%d) is higher than the maximum (%d)",
+			localNode.SatvB, maxSatvB)
+	}
+
+	return nil
+}
+
+// FindNode returns the node from the scored graph or, if it was filtered out, builds it from the channel graph
+// with the information required by the discard rules and the tags.
+func FindNode(channelGraph *lnrpc.ChannelGraph, networkGraph graph.Graph, publicKey string) (graph.Node, bool) {
+	if node, ok := networkGraph.GetNode(publicKey); ok {
+		return node, true
+	}
+
+	i := slices.IndexFunc(channelGraph.Nodes, func(node *lnrpc.LightningNode) bool {
+		return node.PubKey == publicKey
+	})
+	if i == -1 {
+		return graph.Node{}, false
+	}
+
+	node := graph.Node{
+		Alias:     channelGraph.Nodes[i].Alias,
+		PublicKey: publicKey,
+		Addresses: graph.GetAddresses(channelGraph.Nodes[i].Addresses),
+	}
+	for _, edge := range channelGraph.Edges {
+		var peerPublicKey string
+		switch publicKey {
+		case edge.Node1Pub:
+			peerPublicKey = edge.Node2Pub
+		case edge.Node2Pub:
+			peerPublicKey = edge.Node1Pub
+		default:
+			continue
+		}
+
+		capacity := uint64(edge.Capacity)
+		node.Capacity += capacity
+		node.Channels = append(node.Channels, graph.Channel{
+			Point:         edge.ChanPoint,
+			PeerPublicKey: peerPublicKey,
+			Capacity:      capacity,
+		})
+	}
+
+	return node, true
+}
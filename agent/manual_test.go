@@ -0,0 +1,283 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/channel"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/graph"
+	"github.com/aftermath2/hydrus/lightning"
+	"github.com/aftermath2/hydrus/logger"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanOpen(t *testing.T) {
+	channelGraph := &lnrpc.ChannelGraph{
+		Nodes: []*lnrpc.LightningNode{
+			{PubKey: "alice", Addresses: []*lnrpc.NodeAddress{{Addr: "alice:9735"}}},
+			{PubKey: "bob", Addresses: []*lnrpc.NodeAddress{{Addr: "bob:9735"}}},
+			{PubKey: "carol", Addresses: []*lnrpc.NodeAddress{{Addr: "carol:9735"}}},
+		},
+		Edges: []*lnrpc.ChannelEdge{
+			{
+				ChanPoint:   "txid:0",
+				Node1Pub:    "alice",
+				Node2Pub:    "bob",
+				Capacity:    5_000_000,
+				Node1Policy: &lnrpc.RoutingPolicy{FeeRateMilliMsat: 100},
+				Node2Policy: &lnrpc.RoutingPolicy{FeeRateMilliMsat: 100},
+			},
+			{
+				ChanPoint:   "txid:1",
+				Node1Pub:    "bob",
+				Node2Pub:    "carol",
+				Capacity:    5_000_000,
+				Node1Policy: &lnrpc.RoutingPolicy{FeeRateMilliMsat: 100},
+				Node2Policy: &lnrpc.RoutingPolicy{FeeRateMilliMsat: 100},
+			},
+		},
+	}
+	localNode := local.Node{
+		PublicKey:        "local",
+		SyncPeers:        map[string]struct{}{"alice": {}},
+		AllocatedBalance: 5_000_000,
+		MaxOpenChannels:  2,
+		SatvB:            2,
+	}
+	agentConfig := config.Agent{
+		MinChannelSize: 1_000_000,
+		MaxChannelSize: 4_000_000,
+		Blocklist:      []string{"carol"},
+		ChannelManager: config.ChannelManager{MaxSatvB: 10},
+	}
+
+	tests := []struct {
+		desc     string
+		manual   ManualOpen
+		config   func(c config.Agent) config.Agent
+		expected channel.OpenRequest
+		fail     bool
+	}{
+		{
+			desc:   "Valid peers",
+			manual: ManualOpen{Peers: map[string]uint64{"alice": 2_000_000, "bob": 3_000_000}},
+			expected: channel.OpenRequest{
+				Nodes: map[string]uint64{"alice": 2_000_000, "bob": 3_000_000},
+				Peers: map[string][]string{"bob": {"bob:9735"}},
+				SatvB: localNode.SatvB,
+			},
+		},
+		{
+			desc:   "With candidates",
+			manual: ManualOpen{Peers: map[string]uint64{"alice": 2_000_000}, Candidates: 1},
+			expected: channel.OpenRequest{
+				Nodes: map[string]uint64{"alice": 2_000_000, "bob": 3_000_000},
+				Peers: map[string][]string{"bob": {"bob:9735"}},
+				SatvB: localNode.SatvB,
+			},
+		},
+		{
+			desc:   "Blocklisted peer",
+			manual: ManualOpen{Peers: map[string]uint64{"carol": 2_000_000}},
+			fail:   true,
+		},
+		{
+			desc:   "Unknown peer",
+			manual: ManualOpen{Peers: map[string]uint64{"dave": 2_000_000}},
+			fail:   true,
+		},
+		{
+			desc:   "Amount out of range",
+			manual: ManualOpen{Peers: map[string]uint64{"alice": 500_000}},
+			fail:   true,
+		},
+		{
+			desc:   "Allocated balance exceeded",
+			manual: ManualOpen{Peers: map[string]uint64{"alice": 3_000_000, "bob": 3_000_000}},
+			fail:   true,
+		},
+		{
+			desc:   "Fee too high",
+			manual: ManualOpen{Peers: map[string]uint64{"alice": 2_000_000}},
+			config: func(c config.Agent) config.Agent {
+				c.ChannelManager.MaxSatvB = 1
+				return c
+			},
+			fail: true,
+		},
+		{
+			desc:   "Minimum batch size",
+			manual: ManualOpen{Peers: map[string]uint64{"alice": 2_000_000}},
+			config: func(c config.Agent) config.Agent {
+				c.MinBatchSize = 2
+				return c
+			},
+			fail: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			lndMock := lightning.NewClientMock()
+			lndMock.On("DescribeGraph", t.Context()).Return(channelGraph, nil)
+
+			c := agentConfig
+			if tt.config != nil {
+				c = tt.config(c)
+			}
+			agent := agent{
+				lnd:    lndMock,
+				logger: logger.New(""),
+				config: c,
+			}
+
+			req, err := agent.PlanOpen(t.Context(), localNode, tt.manual)
+			if tt.fail {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, req)
+			lndMock.AssertNotCalled(t, "ConnectPeer")
+		})
+	}
+}
+
+func TestFindNode(t *testing.T) {
+	channelGraph := &lnrpc.ChannelGraph{
+		Nodes: []*lnrpc.LightningNode{
+			{PubKey: "alice", Alias: "Alice", Addresses: []*lnrpc.NodeAddress{{Addr: "alice:9735"}}},
+			{PubKey: "bob"},
+		},
+		Edges: []*lnrpc.ChannelEdge{
+			{ChanPoint: "txid:0", Node1Pub: "alice", Node2Pub: "bob", Capacity: 2_000_000},
+			{ChanPoint: "txid:1", Node1Pub: "carol", Node2Pub: "alice", Capacity: 3_000_000},
+			{ChanPoint: "txid:2", Node1Pub: "bob", Node2Pub: "carol", Capacity: 1_000_000},
+		},
+	}
+	scored := graph.Node{PublicKey: "bob", Alias: "Bob"}
+	networkGraph := graph.Graph{Nodes: []graph.Node{scored}}
+
+	node, ok := FindNode(channelGraph, networkGraph, "bob")
+	assert.True(t, ok)
+	assert.Equal(t, scored, node)
+
+	node, ok = FindNode(channelGraph, networkGraph, "alice")
+	assert.True(t, ok)
+	expected := graph.Node{
+		Alias:     "Alice",
+		PublicKey: "alice",
+		Capacity:  5_000_000,
+		Addresses: []string{"alice:9735"},
+		Channels: []graph.Channel{
+			{Point: "txid:0", PeerPublicKey: "bob", Capacity: 2_000_000},
+			{Point: "txid:1", PeerPublicKey: "carol", Capacity: 3_000_000},
+		},
+	}
+	assert.Equal(t, expected, node)
+
+	_, ok = FindNode(channelGraph, networkGraph, "dave")
+	assert.False(t, ok)
+}
+
+func TestPlanClose(t *testing.T) {
+	localNode := local.Node{
+		CurrentBlockHeight: 100_000,
+		MaxCloseChannels:   2,
+		SatvB:              2,
+		Channels: local.Channels{
+			List: []local.Channel{
+				{Point: "txid:0", Active: true, BlockHeight: 10_000},
+				{Point: "txid:1", Active: false, BlockHeight: 10_000},
+				{Point: "txid:2", Active: true, BlockHeight: 99_990},
+				{Point: "txid:3", Active: true, BlockHeight: 10_000},
+				{Point: "txid:4", Active: true, BlockHeight: 10_000},
+			},
+		},
+	}
+	gracePeriod := 30 * 24 * time.Hour
+	agentConfig := config.Agent{
+		Keeplist: []string{"txid:3"},
+		Closing: config.Closing{
+			GracePeriod: &gracePeriod,
+		},
+		ChannelManager: config.ChannelManager{MaxSatvB: 10},
+	}
+
+	tests := []struct {
+		desc             string
+		channelPoints    []string
+		allowForceCloses bool
+		expected         channel.CloseRequest
+		fail             bool
+	}{
+		{
+			desc:          "Active channel",
+			channelPoints: []string{"txid:0"},
+			expected: channel.CloseRequest{
+				Channels: map[string]bool{"txid:0": false},
+				SatvB:    localNode.SatvB,
+			},
+		},
+		{
+			desc:             "Inactive channel",
+			channelPoints:    []string{"txid:0", "txid:1"},
+			allowForceCloses: true,
+			expected: channel.CloseRequest{
+				Channels: map[string]bool{"txid:0": false, "txid:1": true},
+				SatvB:    localNode.SatvB,
+			},
+		},
+		{
+			desc:          "Force closes not allowed",
+			channelPoints: []string{"txid:1"},
+			fail:          true,
+		},
+		{
+			desc:          "Grace period",
+			channelPoints: []string{"txid:2"},
+			fail:          true,
+		},
+		{
+			desc:          "Keeplist",
+			channelPoints: []string{"txid:3"},
+			fail:          true,
+		},
+		{
+			desc:          "Unknown channel",
+			channelPoints: []string{"txid:5"},
+			fail:          true,
+		},
+		{
+			desc:             "Too many channels",
+			channelPoints:    []string{"txid:0", "txid:1", "txid:4"},
+			allowForceCloses: true,
+			fail:             true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			c := agentConfig
+			c.AllowForceCloses = tt.allowForceCloses
+			agent := agent{
+				logger: logger.New(""),
+				config: c,
+			}
+
+			req, err := agent.PlanClose(t.Context(), localNode, tt.channelPoints)
+			if tt.fail {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, req)
+		})
+	}
+}
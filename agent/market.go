@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aftermath2/hydrus/lightning"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+)
+
+// Period of time a channel graph obtained from LND is reused by the tasks.
+const graphCacheMaxAge = time.Hour
+
+// graphCache shares the latest channel graph between the tasks, as describing the graph of a large network
+// is expensive.
+//
+// All methods are safe to call on a nil graphCache, in which case the graph is always requested.
+type graphCache struct {
+	updatedAt time.Time
+	graph     *lnrpc.ChannelGraph
+	mu        sync.Mutex
+}
+
+func newGraphCache() *graphCache {
+	return &graphCache{}
+}
+
+// get returns the cached channel graph or requests a new one if it's too old.
+func (g *graphCache) get(ctx context.Context, lnd lightning.Client) (*lnrpc.ChannelGraph, error) {
+	if g == nil {
+		return lnd.DescribeGraph(ctx)
+	}
+
+	// Hold the lock while describing the graph so concurrent tasks wait for the same request
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.graph != nil && time.Since(g.updatedAt) < graphCacheMaxAge {
+		return g.graph, nil
+	}
+
+	graph, err := lnd.DescribeGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	g.graph = graph
+	g.updatedAt = time.Now()
+	return graph, nil
+}
+
+// Market contains the fees charged by the other nodes to forward payments to a peer.
+type Market struct {
+	// Competitors channels sorted by fee rate in ascending order
+	Channels []MarketChannel
+}
+
+// MarketChannel is the policy of a competitor's channel towards the peer.
+type MarketChannel struct {
+	PublicKey   string
+	Capacity    uint64
+	BaseFeeMsat uint64
+	FeeRatePPM  uint64
+}
+
+// Percentile returns the fee rate at the percentile of the competitors channels.
+func (m Market) Percentile(percentile float64) uint64 {
+	feeRates := make([]uint64, 0, len(m.Channels))
+	for _, channel := range m.Channels {
+		feeRates = append(feeRates, channel.FeeRatePPM)
+	}
+
+	return getPercentile(feeRates, percentile)
+}
+
+// Median returns the median fee rate of the competitors channels.
+func (m Market) Median() uint64 {
+	return m.Percentile(0.5)
+}
+
+// Cheapest returns the competitor channel with the lowest fee rate among those with at least the capacity
+// specified.
+func (m Market) Cheapest(minCapacity uint64) (MarketChannel, bool) {
+	for _, channel := range m.Channels {
+		if channel.Capacity >= minCapacity {
+			return channel, true
+		}
+	}
+
+	return MarketChannel{}, false
+}
+
+// markets indexes the channels pointing to each node of the network graph, excluding ours.
+type markets map[string][]MarketChannel
+
+func newMarkets(graph *lnrpc.ChannelGraph, localPublicKey string) markets {
+	m := make(markets, len(graph.Nodes))
+	add := func(publicKey, peerPublicKey string, capacity int64, policy *lnrpc.RoutingPolicy) {
+		if policy == nil || policy.Disabled {
+			return
+		}
+
+		m[peerPublicKey] = append(m[peerPublicKey], MarketChannel{
+			PublicKey:   publicKey,
+			Capacity:    uint64(capacity),
+			BaseFeeMsat: uint64(policy.FeeBaseMsat),
+			FeeRatePPM:  uint64(policy.FeeRateMilliMsat),
+		})
+	}
+
+	for _, edge := range graph.Edges {
+		if edge.Node1Pub == localPublicKey || edge.Node2Pub == localPublicKey {
+			continue
+		}
+
+		// Each node's policy is the fee it charges to forward payments to the other node
+		add(edge.Node1Pub, edge.Node2Pub, edge.Capacity, edge.Node1Policy)
+		add(edge.Node2Pub, edge.Node1Pub, edge.Capacity, edge.Node2Policy)
+	}
+
+	for _, channels := range m {
+		slices.SortFunc(channels, func(a, b MarketChannel) int {
+			return cmp.Compare(a.FeeRatePPM, b.FeeRatePPM)
+		})
+	}
+
+	return m
+}
+
+// get returns the market of the channels pointing to the node.
+func (m markets) get(publicKey string) Market {
+	return Market{Channels: m[publicKey]}
+}
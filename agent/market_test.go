@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aftermath2/hydrus/lightning"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphCache(t *testing.T) {
+	ctx := t.Context()
+	lndMock := lightning.NewClientMock()
+	channelGraph := &lnrpc.ChannelGraph{Nodes: []*lnrpc.LightningNode{{PubKey: "a"}}}
+	lndMock.On("DescribeGraph", ctx).Return(channelGraph, nil).Once()
+
+	cache := newGraphCache()
+	for range 2 {
+		graph, err := cache.get(ctx, lndMock)
+		assert.NoError(t, err)
+		assert.Equal(t, channelGraph, graph)
+	}
+	lndMock.AssertNumberOfCalls(t, "DescribeGraph", 1)
+
+	// Request a new graph once the cached one expires
+	cache.updatedAt = time.Now().Add(-graphCacheMaxAge)
+	lndMock.On("DescribeGraph", ctx).Return(channelGraph, nil).Once()
+
+	_, err := cache.get(ctx, lndMock)
+	assert.NoError(t, err)
+	lndMock.AssertNumberOfCalls(t, "DescribeGraph", 2)
+}
+
+func TestNewMarkets(t *testing.T) {
+	localPublicKey := "local"
+	channelGraph := &lnrpc.ChannelGraph{
+		Edges: []*lnrpc.ChannelEdge{
+			{
+				Capacity:    2_000_000,
+				Node1Pub:    "a",
+				Node1Policy: &lnrpc.RoutingPolicy{FeeRateMilliMsat: 500, FeeBaseMsat: 1_000},
+				Node2Pub:    "peer",
+				Node2Policy: &lnrpc.RoutingPolicy{FeeRateMilliMsat: 10},
+			},
+			{
+				Capacity:    500_000,
+				Node1Pub:    "peer",
+				Node1Policy: &lnrpc.RoutingPolicy{FeeRateMilliMsat: 20},
+				Node2Pub:    "b",
+				Node2Policy: &lnrpc.RoutingPolicy{FeeRateMilliMsat: 100},
+			},
+			{
+				Capacity:    1_000_000,
+				Node1Pub:    "c",
+				Node1Policy: &lnrpc.RoutingPolicy{FeeRateMilliMsat: 50, Disabled: true},
+				Node2Pub:    "peer",
+			},
+			{
+				Capacity:    5_000_000,
+				Node1Pub:    localPublicKey,
+				Node1Policy: &lnrpc.RoutingPolicy{FeeRateMilliMsat: 1},
+				Node2Pub:    "peer",
+				Node2Policy: &lnrpc.RoutingPolicy{FeeRateMilliMsat: 1},
+			},
+		},
+	}
+
+	m := newMarkets(channelGraph, localPublicKey)
+
+	expected := Market{
+		Channels: []MarketChannel{
+			{PublicKey: "b", Capacity: 500_000, FeeRatePPM: 100},
+			{PublicKey: "a", Capacity: 2_000_000, BaseFeeMsat: 1_000, FeeRatePPM: 500},
+		},
+	}
+	assert.Equal(t, expected, m.get("peer"))
+	assert.Empty(t, m.get("unknown").Channels)
+
+	var nilMarkets markets
+	assert.Empty(t, nilMarkets.get("peer").Channels)
+}
+
+func TestMarket(t *testing.T) {
+	market := Market{
+		Channels: []MarketChannel{
+			{PublicKey: "a", Capacity: 100_000, FeeRatePPM: 10},
+			{PublicKey: "b", Capacity: 2_000_000, FeeRatePPM: 200},
+			{PublicKey: "c", Capacity: 500_000, FeeRatePPM: 300},
+			{PublicKey: "d", Capacity: 5_000_000, FeeRatePPM: 1_000},
+		},
+	}
+
+	assert.Equal(t, uint64(200), market.Median())
+	assert.Equal(t, uint64(10), market.Percentile(0.25))
+	assert.Equal(t, uint64(300), market.Percentile(0.75))
+
+	cheapest, ok := market.Cheapest(0)
+	assert.True(t, ok)
+	assert.Equal(t, "a", cheapest.PublicKey)
+
+	cheapest, ok = market.Cheapest(1_000_000)
+	assert.True(t, ok)
+	assert.Equal(t, "b", cheapest.PublicKey)
+
+	_, ok = market.Cheapest(10_000_000)
+	assert.False(t, ok)
+
+	assert.Equal(t, uint64(0), Market{}.Median())
+}
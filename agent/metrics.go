@@ -0,0 +1,20 @@
+package agent
+
+import (
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/metrics"
+)
+
+// recordNodeMetrics updates the gauges describing the local node and its channels.
+func recordNodeMetrics(localNode local.Node) {
+	metrics.WalletBalance.Set(float64(localNode.WalletBalance))
+	metrics.AllocatedBalance.Set(float64(localNode.AllocatedBalance))
+	metrics.Channels.Set(float64(localNode.NumChannels))
+
+	// Remove the channels that were closed since the last update
+	metrics.ChannelScore.Reset()
+	for _, channel := range localNode.Channels.List {
+		score := localNode.Channels.Heuristics.GetScore(channel)
+		metrics.ChannelScore.WithLabelValues(channel.Point, channel.RemotePublicKey).Set(score)
+	}
+}
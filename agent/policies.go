@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+)
+
+// timeLockDeltas contains the time lock deltas announced in the network graph, sorted in ascending order.
+type timeLockDeltas struct {
+	nodes   map[string][]uint32
+	network []uint32
+}
+
+// newTimeLockDeltas collects the time lock deltas of all the channel policies in the network graph.
+func newTimeLockDeltas(graph *lnrpc.ChannelGraph) *timeLockDeltas {
+	deltas := &timeLockDeltas{
+		nodes:   make(map[string][]uint32, len(graph.Nodes)),
+		network: make([]uint32, 0, len(graph.Edges)*2),
+	}
+	add := func(publicKey string, policy *lnrpc.RoutingPolicy) {
+		if policy == nil || policy.Disabled {
+			return
+		}
+		deltas.nodes[publicKey] = append(deltas.nodes[publicKey], policy.TimeLockDelta)
+		deltas.network = append(deltas.network, policy.TimeLockDelta)
+	}
+
+	for _, edge := range graph.Edges {
+		add(edge.Node1Pub, edge.Node1Policy)
+		add(edge.Node2Pub, edge.Node2Policy)
+	}
+
+	for _, nodeDeltas := range deltas.nodes {
+		slices.Sort(nodeDeltas)
+	}
+	slices.Sort(deltas.network)
+
+	return deltas
+}
+
+// get returns the highest of the peer's and the network's time lock deltas at the percentile.
+func (t *timeLockDeltas) get(publicKey string, percentile float64) uint32 {
+	return max(getPercentile(t.nodes[publicKey], percentile), getPercentile(t.network, percentile))
+}
+
+// getPercentile returns the value at the percentile of a sorted list.
+func getPercentile[T uint32 | uint64](values []T, percentile float64) T {
+	if len(values) == 0 {
+		return 0
+	}
+
+	i := int(math.Ceil(percentile*float64(len(values)))) - 1
+	return values[min(max(i, 0), len(values)-1)]
+}
+
+// applyRoutingPolicies sets the base fee, time lock delta and HTLC limits of the channel policy.
+func applyRoutingPolicies(
+	config config.RoutingPolicies,
+	channel local.Channel,
+	deltas *timeLockDeltas,
+	forwards []*lnrpc.ForwardingEvent,
+	interval time.Duration,
+	policy Policy,
+) Policy {
+	if config.BaseFee.Enabled {
+		policy.BaseFeeMsat = config.BaseFee.Msat
+	}
+
+	if config.TimeLockDelta.Enabled && deltas != nil {
+		delta := deltas.get(channel.RemotePublicKey, config.TimeLockDelta.Percentile)
+		delta = min(max(delta, config.TimeLockDelta.Min), config.TimeLockDelta.Max)
+		policy.TimeLockDelta = uint64(delta)
+	}
+
+	policy.MaxHTLCMsat = calculateNewMaxHTLC(channel)
+
+	if config.MinHTLC.Enabled {
+		policy.MinHTLCMsat = calculateNewMinHTLC(config.MinHTLC, channel, forwards, interval)
+	}
+	// LND rejects policies whose minimum HTLC is higher than the maximum
+	policy.MinHTLCMsat = min(policy.MinHTLCMsat, policy.MaxHTLCMsat)
+
+	return policy
+}
+
+// calculateNewMinHTLC raises the minimum HTLC of busy channels so that small payments do not take the HTLC
+// slots required by the larger ones.
+func calculateNewMinHTLC(
+	config config.MinHTLCPolicy,
+	channel local.Channel,
+	forwards []*lnrpc.ForwardingEvent,
+	interval time.Duration,
+) uint64 {
+	numForwardsOut := 0
+	for _, forward := range forwards {
+		if forward.ChanIdOut == channel.ID {
+			numForwardsOut++
+		}
+	}
+
+	days := interval.Hours() / 24
+	minHTLC := config.Msat
+	if days > 0 && float64(numForwardsOut)/days >= float64(config.BusyForwards) {
+		minHTLC = config.DustMsat
+	}
+
+	return max(minHTLC, channel.MinHTLCMsat)
+}
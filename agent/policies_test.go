@@ -0,0 +1,197 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTimeLockDeltas(t *testing.T) {
+	channelGraph := &lnrpc.ChannelGraph{
+		Edges: []*lnrpc.ChannelEdge{
+			{
+				Node1Pub:    "a",
+				Node1Policy: &lnrpc.RoutingPolicy{TimeLockDelta: 144},
+				Node2Pub:    "b",
+				Node2Policy: &lnrpc.RoutingPolicy{TimeLockDelta: 40},
+			},
+			{
+				Node1Pub:    "a",
+				Node1Policy: &lnrpc.RoutingPolicy{TimeLockDelta: 100},
+				Node2Pub:    "c",
+				Node2Policy: &lnrpc.RoutingPolicy{TimeLockDelta: 80},
+			},
+			{
+				Node1Pub:    "b",
+				Node1Policy: &lnrpc.RoutingPolicy{TimeLockDelta: 500, Disabled: true},
+				Node2Pub:    "c",
+			},
+		},
+	}
+
+	deltas := newTimeLockDeltas(channelGraph)
+
+	assert.Equal(t, []uint32{40, 80, 100, 144}, deltas.network)
+	assert.Equal(t, []uint32{100, 144}, deltas.nodes["a"])
+	assert.Equal(t, []uint32{40}, deltas.nodes["b"])
+
+	assert.Equal(t, uint32(100), deltas.get("a", 0.5))
+	assert.Equal(t, uint32(80), deltas.get("b", 0.5))
+	assert.Equal(t, uint32(80), deltas.get("unknown", 0.5))
+	assert.Equal(t, uint32(144), deltas.get("b", 1))
+}
+
+func TestGetPercentile(t *testing.T) {
+	values := []uint32{10, 20, 30, 40, 50}
+
+	assert.Equal(t, uint32(0), getPercentile([]uint32{}, 0.5))
+	assert.Equal(t, uint32(10), getPercentile(values, 0))
+	assert.Equal(t, uint32(30), getPercentile(values, 0.5))
+	assert.Equal(t, uint32(40), getPercentile(values, 0.75))
+	assert.Equal(t, uint32(50), getPercentile(values, 1))
+}
+
+func TestApplyRoutingPolicies(t *testing.T) {
+	channelID := uint64(1)
+	channel := local.Channel{
+		ID:              channelID,
+		RemotePublicKey: "a",
+		LocalBalance:    1_000_000,
+	}
+	deltas := &timeLockDeltas{
+		nodes:   map[string][]uint32{"a": {20, 30}},
+		network: []uint32{20, 30, 40},
+	}
+	current := Policy{
+		BaseFeeMsat:   1_000,
+		FeeRatePPM:    100,
+		MinHTLCMsat:   1,
+		TimeLockDelta: 80,
+	}
+	busyForwards := []*lnrpc.ForwardingEvent{
+		{ChanIdOut: channelID},
+		{ChanIdOut: channelID},
+		{ChanIdIn: channelID},
+	}
+
+	tests := []struct {
+		desc     string
+		config   config.RoutingPolicies
+		forwards []*lnrpc.ForwardingEvent
+		expected Policy
+	}{
+		{
+			desc:   "Disabled",
+			config: config.RoutingPolicies{},
+			expected: Policy{
+				BaseFeeMsat:   1_000,
+				FeeRatePPM:    100,
+				MinHTLCMsat:   1,
+				MaxHTLCMsat:   800_000_000,
+				TimeLockDelta: 80,
+			},
+		},
+		{
+			desc: "Base fee and time lock delta",
+			config: config.RoutingPolicies{
+				BaseFee: config.BaseFeePolicy{Enabled: true, Msat: 0},
+				TimeLockDelta: config.TimeLockDeltaPolicy{
+					Enabled:    true,
+					Percentile: 0.5,
+					Min:        25,
+					Max:        144,
+				},
+			},
+			expected: Policy{
+				BaseFeeMsat:   0,
+				FeeRatePPM:    100,
+				MinHTLCMsat:   1,
+				MaxHTLCMsat:   800_000_000,
+				TimeLockDelta: 30,
+			},
+		},
+		{
+			desc: "Time lock delta below the minimum",
+			config: config.RoutingPolicies{
+				TimeLockDelta: config.TimeLockDeltaPolicy{
+					Enabled:    true,
+					Percentile: 0.5,
+					Min:        40,
+					Max:        144,
+				},
+			},
+			expected: Policy{
+				BaseFeeMsat:   1_000,
+				FeeRatePPM:    100,
+				MinHTLCMsat:   1,
+				MaxHTLCMsat:   800_000_000,
+				TimeLockDelta: 40,
+			},
+		},
+		{
+			desc: "Busy channel",
+			config: config.RoutingPolicies{
+				MinHTLC: config.MinHTLCPolicy{
+					Enabled:      true,
+					BusyForwards: 8,
+					DustMsat:     1_000_000,
+					Msat:         1_000,
+				},
+			},
+			forwards: busyForwards,
+			expected: Policy{
+				BaseFeeMsat:   1_000,
+				FeeRatePPM:    100,
+				MinHTLCMsat:   1_000_000,
+				MaxHTLCMsat:   800_000_000,
+				TimeLockDelta: 80,
+			},
+		},
+		{
+			desc: "Quiet channel",
+			config: config.RoutingPolicies{
+				MinHTLC: config.MinHTLCPolicy{
+					Enabled:      true,
+					BusyForwards: 10,
+					DustMsat:     1_000_000,
+					Msat:         1_000,
+				},
+			},
+			forwards: busyForwards,
+			expected: Policy{
+				BaseFeeMsat:   1_000,
+				FeeRatePPM:    100,
+				MinHTLCMsat:   1_000,
+				MaxHTLCMsat:   800_000_000,
+				TimeLockDelta: 80,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			// Two forwards out in 6 hours are eight per day
+			policy := applyRoutingPolicies(tt.config, channel, deltas, tt.forwards, 6*time.Hour, current)
+			assert.Equal(t, tt.expected, policy)
+		})
+	}
+}
+
+func TestCalculateNewMinHTLC(t *testing.T) {
+	minHTLC := config.MinHTLCPolicy{
+		BusyForwards: 1,
+		DustMsat:     1_000_000,
+		Msat:         1_000,
+	}
+	channel := local.Channel{ID: 1, MinHTLCMsat: 5_000}
+
+	assert.Equal(t, uint64(5_000), calculateNewMinHTLC(minHTLC, channel, nil, time.Hour))
+
+	forwards := []*lnrpc.ForwardingEvent{{ChanIdOut: 1}}
+	assert.Equal(t, uint64(1_000_000), calculateNewMinHTLC(minHTLC, channel, forwards, time.Hour))
+}
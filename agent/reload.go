@@ -0,0 +1,176 @@
+package agent
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/aftermath2/hydrus/channel"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/notifier"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/pkg/errors"
+)
+
+// reloader holds the latest configuration loaded and the dependencies built from it, which are swapped
+// when the configuration is reloaded.
+//
+// Tasks take a snapshot of the agent when they start, so each execution uses a single configuration.
+type reloader struct {
+	ctx            context.Context
+	scheduler      gocron.Scheduler
+	jobs           map[string]gocron.Job
+	channelManager channel.Manager
+	notifier       *notifier.Dispatcher
+	feeStrategies  *feeStrategies
+	config         config.Agent
+	mu             sync.RWMutex
+}
+
+// setJobs stores the jobs to reschedule when the intervals change.
+func (r *reloader) setJobs(ctx context.Context, scheduler gocron.Scheduler, jobs map[string]gocron.Job) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ctx = ctx
+	r.scheduler = scheduler
+	r.jobs = jobs
+}
+
+// snapshot returns a copy of the agent using the latest configuration loaded.
+func (a *agent) snapshot() *agent {
+	if a.reloader == nil {
+		return a
+	}
+
+	a.reloader.mu.RLock()
+	defer a.reloader.mu.RUnlock()
+
+	snapshot := *a
+	snapshot.config = a.reloader.config
+	snapshot.channelManager = a.reloader.channelManager
+	snapshot.notifier = a.reloader.notifier
+	snapshot.feeStrategies = a.reloader.feeStrategies
+	return &snapshot
+}
+
+// Reload replaces the agent configuration, the tasks being executed keep using the previous one until
+// they finish. The configuration must have been validated already.
+func (a *agent) Reload(config config.Agent) error {
+	r := a.reloader
+	if r == nil {
+		return errors.New("the agent does not support reloading the configuration")
+	}
+
+	r.mu.Lock()
+	previousNotifier, err := r.swap(a, config)
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// The notifications still being delivered would be lost if the agent stopped right after
+	previousNotifier.Wait()
+
+	a.logger.Info("Configuration reloaded")
+	return nil
+}
+
+// swap replaces the configuration and the dependencies built from it, leaving them untouched if it fails.
+// It returns the notifier dispatcher replaced, if any. It must be called with the lock held.
+func (r *reloader) swap(a *agent, config config.Agent) (*notifier.Dispatcher, error) {
+	if config.API != r.config.API || config.Metrics != r.config.Metrics || config.DataDir != r.config.DataDir {
+		return nil, errors.New("changing the api, metrics or data directory configuration requires a restart")
+	}
+
+	if err := r.reschedule(a, config.Intervals); err != nil {
+		return nil, err
+	}
+
+	if config.DryRun != r.config.DryRun {
+		a.state.setDryRun(config.DryRun)
+	}
+
+	// Keep the strategies state, like the PID controllers, unless their configuration changed
+	if !reflect.DeepEqual(config.Fees, r.config.Fees) || !reflect.DeepEqual(config.Tags, r.config.Tags) {
+		r.feeStrategies = newFeeStrategies(config.Fees, config.Tags)
+	}
+
+	// Keep the rate limits state unless the notifiers changed
+	var previousNotifier *notifier.Dispatcher
+	if !reflect.DeepEqual(config.Notifiers, r.config.Notifiers) {
+		previousNotifier = r.notifier
+		r.notifier = notifier.NewDispatcher(config.Notifiers)
+	}
+	r.channelManager = channel.NewManager(config.ChannelManager, config.DataDir, a.lnd, r.notifier)
+	r.config = config
+
+	return previousNotifier, nil
+}
+
+// reschedule updates the jobs whose intervals changed. The next execution takes place one interval after
+// the update. If any job can't be updated, the ones updated already are restored to their previous
+// interval.
+func (r *reloader) reschedule(a *agent, intervals config.Intervals) error {
+	if r.scheduler == nil {
+		return nil
+	}
+
+	previous, next := taskIntervals(r.config.Intervals), taskIntervals(intervals)
+	for name, interval := range next {
+		if interval <= 0 {
+			return errors.Errorf("%s task interval must be greater than zero", name)
+		}
+	}
+
+	var updated []string
+	for name, interval := range next {
+		if interval == previous[name] {
+			continue
+		}
+
+		if err := r.updateJob(a, name, interval); err != nil {
+			for _, name := range updated {
+				if err := r.updateJob(a, name, previous[name]); err != nil {
+					a.logger.Errorf("Restoring %s task interval: %v", name, err)
+				}
+			}
+			return err
+		}
+		updated = append(updated, name)
+	}
+
+	for _, name := range updated {
+		a.logger.Infof("Rescheduled %s task to run every %s", name, next[name])
+	}
+
+	return nil
+}
+
+// updateJob sets the interval of the task job.
+func (r *reloader) updateJob(a *agent, name string, interval time.Duration) error {
+	job, err := r.scheduler.Update(
+		r.jobs[name].ID(),
+		gocron.DurationJob(interval),
+		gocron.NewTask(a.runTask, r.ctx, name),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "rescheduling %s task", name)
+	}
+
+	r.jobs[name] = job
+	return nil
+}
+
+func taskIntervals(intervals config.Intervals) map[string]time.Duration {
+	return map[string]time.Duration{
+		channelsTaskName:        intervals.Channels,
+		routingPoliciesTaskName: intervals.RoutingPolicies,
+	}
+}
@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/lightning"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	initial := config.Agent{
+		Blocklist: []string{"a"},
+		Intervals: config.Intervals{Channels: time.Hour, RoutingPolicies: time.Hour},
+		API:       config.API{Address: "127.0.0.1:7070", Token: "token"},
+		Notifiers: []config.Notifier{
+			{Type: config.NotifierTypeWebhook, Webhook: config.Webhook{URL: "http://127.0.0.1:8080"}},
+		},
+	}
+
+	tests := []struct {
+		desc   string
+		update func(c *config.Agent)
+		fail   bool
+	}{
+		{
+			desc: "Lists and limits",
+			update: func(c *config.Agent) {
+				c.Blocklist = []string{"b"}
+				c.MaxChannels = 20
+			},
+		},
+		{
+			desc: "Intervals",
+			update: func(c *config.Agent) {
+				c.Intervals.Channels = 2 * time.Hour
+			},
+		},
+		{
+			desc: "Dry run",
+			update: func(c *config.Agent) {
+				c.DryRun = true
+			},
+		},
+		{
+			desc: "Notifiers",
+			update: func(c *config.Agent) {
+				c.Notifiers = nil
+			},
+		},
+		{
+			desc: "API",
+			update: func(c *config.Agent) {
+				c.API.Token = "other"
+			},
+			fail: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ctx := t.Context()
+			a := New(initial, lightning.NewClientMock()).(*agent)
+
+			scheduler, err := gocron.NewScheduler()
+			require.NoError(t, err)
+			defer scheduler.Shutdown()
+
+			jobs := make(map[string]gocron.Job, 2)
+			for name, interval := range taskIntervals(initial.Intervals) {
+				job, err := scheduler.NewJob(gocron.DurationJob(interval), gocron.NewTask(a.runTask, ctx, name))
+				require.NoError(t, err)
+				jobs[name] = job
+			}
+			a.reloader.setJobs(ctx, scheduler, jobs)
+			scheduler.Start()
+
+			previous := a.snapshot()
+			updated := initial
+			tt.update(&updated)
+
+			err = a.Reload(updated)
+			if tt.fail {
+				assert.Error(t, err)
+				assert.Equal(t, initial, a.snapshot().config)
+				return
+			}
+			require.NoError(t, err)
+
+			snapshot := a.snapshot()
+			assert.Equal(t, updated, snapshot.config)
+			assert.Equal(t, updated.DryRun, snapshot.dryRun())
+			// The agent itself keeps the configuration it was created with
+			assert.Equal(t, initial, a.config)
+			if len(updated.Notifiers) == len(initial.Notifiers) {
+				assert.Same(t, previous.notifier, snapshot.notifier)
+			} else {
+				assert.NotSame(t, previous.notifier, snapshot.notifier)
+			}
+
+			for name, interval := range taskIntervals(updated.Intervals) {
+				nextRun, err := jobs[name].NextRun()
+				require.NoError(t, err)
+				assert.WithinDuration(t, time.Now().Add(interval), nextRun, time.Minute)
+			}
+		})
+	}
+}
+
+// failingScheduler fails to update the job specified.
+type failingScheduler struct {
+	gocron.Scheduler
+	failID uuid.UUID
+}
+
+func (s failingScheduler) Update(id uuid.UUID, definition gocron.JobDefinition, task gocron.Task,
+	options ...gocron.JobOption,
+) (gocron.Job, error) {
+	if id == s.failID {
+		return nil, errors.New("update failed")
+	}
+	return s.Scheduler.Update(id, definition, task, options...)
+}
+
+func TestReloadRescheduleFailure(t *testing.T) {
+	ctx := t.Context()
+	initial := config.Agent{Intervals: config.Intervals{Channels: time.Hour, RoutingPolicies: time.Hour}}
+	a := New(initial, lightning.NewClientMock()).(*agent)
+
+	scheduler, err := gocron.NewScheduler()
+	require.NoError(t, err)
+	defer scheduler.Shutdown()
+
+	jobs := make(map[string]gocron.Job, 2)
+	for name, interval := range taskIntervals(initial.Intervals) {
+		job, err := scheduler.NewJob(gocron.DurationJob(interval), gocron.NewTask(a.runTask, ctx, name))
+		require.NoError(t, err)
+		jobs[name] = job
+	}
+	failing := failingScheduler{Scheduler: scheduler, failID: jobs[routingPoliciesTaskName].ID()}
+	a.reloader.setJobs(ctx, failing, jobs)
+	scheduler.Start()
+
+	// The channels job must keep its interval whether it was updated before the failure or not
+	updated := initial
+	updated.Intervals = config.Intervals{Channels: 3 * time.Hour, RoutingPolicies: 3 * time.Hour}
+	assert.Error(t, a.Reload(updated))
+	assert.Equal(t, initial, a.snapshot().config)
+
+	for name := range taskIntervals(initial.Intervals) {
+		nextRun, err := jobs[name].NextRun()
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), nextRun, time.Minute)
+	}
+}
@@ -0,0 +1,183 @@
+package agent
+
+import (
+	"slices"
+	"time"
+
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+)
+
+// PolicyMatch contains the routing policy rule matching a channel.
+type PolicyMatch struct {
+	ChannelPoint    string `json:"channel_point"`
+	RemotePublicKey string `json:"remote_public_key"`
+	// Empty if no rule matches the channel
+	Rule string `json:"rule,omitempty"`
+	// Fee strategy updating the channel when no rule matches it
+	FeeStrategy string `json:"fee_strategy,omitempty"`
+	// Tags assigned to the channel
+	Tags []string `json:"tags,omitempty"`
+}
+
+// peerStats contains the properties of a node in the network graph.
+type peerStats struct {
+	capacity    uint64
+	numChannels int
+}
+
+// ruleMatcher finds the routing policy rule matching each channel.
+type ruleMatcher struct {
+	peers              map[string]peerStats
+	config             config.RoutingPolicies
+	interval           time.Duration
+	currentBlockHeight uint32
+}
+
+func newRuleMatcher(
+	config config.RoutingPolicies,
+	graph *lnrpc.ChannelGraph,
+	currentBlockHeight uint32,
+	interval time.Duration,
+) ruleMatcher {
+	return ruleMatcher{
+		peers:              newPeerStats(graph),
+		config:             config,
+		interval:           interval,
+		currentBlockHeight: currentBlockHeight,
+	}
+}
+
+// newPeerStats returns the total capacity and number of channels of each node in the graph.
+func newPeerStats(graph *lnrpc.ChannelGraph) map[string]peerStats {
+	if graph == nil {
+		return nil
+	}
+
+	peers := make(map[string]peerStats, len(graph.Nodes))
+	add := func(publicKey string, capacity int64) {
+		stats := peers[publicKey]
+		stats.capacity += uint64(capacity)
+		stats.numChannels++
+		peers[publicKey] = stats
+	}
+
+	for _, edge := range graph.Edges {
+		add(edge.Node1Pub, edge.Capacity)
+		add(edge.Node2Pub, edge.Capacity)
+	}
+
+	return peers
+}
+
+// usesPeerConditions returns whether any rule requires the peers network graph properties.
+func usesPeerConditions(rules []config.PolicyRule) bool {
+	return slices.ContainsFunc(rules, func(rule config.PolicyRule) bool {
+		return rule.Conditions.PeerConditions()
+	})
+}
+
+// match returns the first rule whose conditions the channel meets.
+func (m ruleMatcher) match(channel local.Channel, forwards []*lnrpc.ForwardingEvent) (config.PolicyRule, bool) {
+	var numForwardsIn, numForwardsOut int
+	for _, forward := range forwards {
+		if forward.ChanIdIn == channel.ID {
+			numForwardsIn++
+		}
+		if forward.ChanIdOut == channel.ID {
+			numForwardsOut++
+		}
+	}
+
+	forwardsInPerDay, forwardsOutPerDay := 0.0, 0.0
+	if days := m.interval.Hours() / 24; days > 0 {
+		forwardsInPerDay = float64(numForwardsIn) / days
+		forwardsOutPerDay = float64(numForwardsOut) / days
+	}
+
+	for _, rule := range m.config.Rules {
+		c := rule.Conditions
+		ok := m.matchPeer(c, channel.RemotePublicKey) &&
+			(len(c.Channels) == 0 || slices.Contains(c.Channels, channel.Point)) &&
+			(c.Active == nil || *c.Active == channel.Active) &&
+			(c.MaxFlapCount == 0 || channel.FlapCount < c.MaxFlapCount) &&
+			inRange(channel.Capacity, c.MinCapacity, c.MaxCapacity) &&
+			inRange(channel.Age(m.currentBlockHeight), c.MinAge, c.MaxAge) &&
+			inRange(forwardsInPerDay, c.MinForwardsInPerDay, c.MaxForwardsInPerDay) &&
+			inRange(forwardsOutPerDay, c.MinForwardsOutPerDay, c.MaxForwardsOutPerDay)
+		if ok && channel.Capacity > 0 {
+			ok = inRange(localRatio(channel), c.MinLocalRatio, c.MaxLocalRatio)
+		}
+
+		if ok {
+			return rule, true
+		}
+	}
+
+	return config.PolicyRule{}, false
+}
+
+// matchPeer returns whether the peer meets the rule conditions on its identity and network graph properties.
+func (m ruleMatcher) matchPeer(c config.RuleConditions, publicKey string) bool {
+	if len(c.Peers) > 0 && !slices.Contains(c.Peers, publicKey) {
+		return false
+	}
+
+	if len(c.Groups) > 0 && !slices.ContainsFunc(c.Groups, func(group string) bool {
+		return slices.Contains(m.config.Groups[group], publicKey)
+	}) {
+		return false
+	}
+
+	peer := m.peers[publicKey]
+	return inRange(peer.capacity, c.MinPeerCapacity, c.MaxPeerCapacity) &&
+		inRange(peer.numChannels, c.MinPeerChannels, c.MaxPeerChannels)
+}
+
+// inRange returns whether the value is equal to or higher than the minimum and lower than the maximum. Zero
+// bounds are ignored.
+func inRange[T uint64 | int | float64 | time.Duration](value, minValue, maxValue T) bool {
+	return value >= minValue && (maxValue == 0 || value < maxValue)
+}
+
+// applyRule sets the routing policy values of the rule actions.
+func applyRule(actions config.RuleActions, channel local.Channel, policy Policy) Policy {
+	if actions.FeeRatePPM != nil {
+		policy.FeeRatePPM = *actions.FeeRatePPM
+	}
+
+	if actions.BaseFeeMsat != nil {
+		policy.BaseFeeMsat = *actions.BaseFeeMsat
+	}
+
+	if actions.MaxHTLCRatio > 0 {
+		spendable := channel.LocalBalance - min(channel.LocalReserve, channel.LocalBalance)
+		maxHTLC := uint64(float64(spendable)*actions.MaxHTLCRatio) * 1000
+		if channel.MaxPendingMsat > 0 {
+			maxHTLC = min(maxHTLC, channel.MaxPendingMsat)
+		}
+		policy.MaxHTLCMsat = max(maxHTLC, 1_000)
+	}
+
+	if actions.MinHTLCMsat != nil {
+		policy.MinHTLCMsat = *actions.MinHTLCMsat
+	}
+	// LND rejects policies whose minimum HTLC is higher than the maximum
+	policy.MinHTLCMsat = min(policy.MinHTLCMsat, policy.MaxHTLCMsat)
+
+	if actions.TimeLockDelta != nil {
+		policy.TimeLockDelta = uint64(*actions.TimeLockDelta)
+	}
+
+	if actions.InboundBaseFeeMsat != nil {
+		policy.InboundBaseFeeMsat = *actions.InboundBaseFeeMsat
+	}
+
+	if actions.InboundFeeRatePPM != nil {
+		policy.InboundFeeRatePPM = *actions.InboundFeeRatePPM
+	}
+
+	return policy
+}
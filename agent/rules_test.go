@@ -0,0 +1,150 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleMatcherMatch(t *testing.T) {
+	active := true
+	keep := config.RuleActions{Keep: true}
+	policies := config.RoutingPolicies{
+		Groups: map[string][]string{"sinks": {"sink"}},
+		Rules: []config.PolicyRule{
+			{
+				Name:       "depleted sinks",
+				Conditions: config.RuleConditions{Groups: []string{"sinks"}, MaxLocalRatio: 0.2},
+				Actions:    keep,
+			},
+			{
+				Name:       "new channels",
+				Conditions: config.RuleConditions{MaxAge: oneWeekInBlocks * 10 * time.Minute},
+				Actions:    keep,
+			},
+			{
+				Name:       "busy",
+				Conditions: config.RuleConditions{Active: &active, MinForwardsOutPerDay: 2},
+				Actions:    keep,
+			},
+			{
+				Name:       "large peers",
+				Conditions: config.RuleConditions{MinPeerCapacity: 10_000_000, MinPeerChannels: 2},
+				Actions:    keep,
+			},
+		},
+	}
+	channelGraph := &lnrpc.ChannelGraph{
+		Edges: []*lnrpc.ChannelEdge{
+			{Node1Pub: "large", Node2Pub: "a", Capacity: 6_000_000},
+			{Node1Pub: "b", Node2Pub: "large", Capacity: 6_000_000},
+		},
+	}
+	matcher := newRuleMatcher(policies, channelGraph, 900_000, 24*time.Hour)
+	old := uint32(800_000)
+
+	tests := []struct {
+		name     string
+		rule     string
+		forwards []*lnrpc.ForwardingEvent
+		channel  local.Channel
+	}{
+		{
+			name:    "Group and local ratio",
+			channel: local.Channel{RemotePublicKey: "sink", BlockHeight: old, Capacity: 100, LocalBalance: 10},
+			rule:    "depleted sinks",
+		},
+		{
+			name:    "Group with a high local ratio",
+			channel: local.Channel{RemotePublicKey: "sink", BlockHeight: old, Capacity: 100, LocalBalance: 20},
+		},
+		{
+			name:    "Age",
+			channel: local.Channel{RemotePublicKey: "sink", BlockHeight: 899_500, Capacity: 100, LocalBalance: 50},
+			rule:    "new channels",
+		},
+		{
+			name:    "Forwards out",
+			channel: local.Channel{ID: 1, Active: true, BlockHeight: old},
+			forwards: []*lnrpc.ForwardingEvent{
+				{ChanIdIn: 2, ChanIdOut: 1},
+				{ChanIdIn: 2, ChanIdOut: 1},
+			},
+			rule: "busy",
+		},
+		{
+			name:    "Inactive",
+			channel: local.Channel{ID: 1, BlockHeight: old},
+			forwards: []*lnrpc.ForwardingEvent{
+				{ChanIdIn: 2, ChanIdOut: 1},
+				{ChanIdIn: 2, ChanIdOut: 1},
+			},
+		},
+		{
+			name:    "Peer graph properties",
+			channel: local.Channel{RemotePublicKey: "large", BlockHeight: old},
+			rule:    "large peers",
+		},
+		{
+			name:    "No match",
+			channel: local.Channel{RemotePublicKey: "a", BlockHeight: old},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := matcher.match(tt.channel, tt.forwards)
+			assert.Equal(t, tt.rule != "", ok)
+			assert.Equal(t, tt.rule, rule.Name)
+		})
+	}
+}
+
+func TestApplyRule(t *testing.T) {
+	feeRate := uint64(1_500)
+	minHTLC := uint64(2_000_000)
+	inboundFeeRate := int32(-100)
+	channel := local.Channel{LocalBalance: 1_000_000, LocalReserve: 10_000, Capacity: 2_000_000}
+	current := Policy{
+		BaseFeeMsat:   1_000,
+		FeeRatePPM:    100,
+		MinHTLCMsat:   1_000,
+		MaxHTLCMsat:   792_000_000,
+		TimeLockDelta: 80,
+	}
+
+	actions := config.RuleActions{
+		FeeRatePPM:        &feeRate,
+		MaxHTLCRatio:      0.5,
+		MinHTLCMsat:       &minHTLC,
+		InboundFeeRatePPM: &inboundFeeRate,
+	}
+	expected := Policy{
+		BaseFeeMsat:       1_000,
+		FeeRatePPM:        1_500,
+		MinHTLCMsat:       2_000_000,
+		MaxHTLCMsat:       495_000_000,
+		TimeLockDelta:     80,
+		InboundFeeRatePPM: -100,
+	}
+	assert.Equal(t, expected, applyRule(actions, channel, current))
+
+	// The minimum HTLC is capped by the maximum
+	channel.MaxPendingMsat = 1_000_000
+	expected.MaxHTLCMsat = 1_000_000
+	expected.MinHTLCMsat = 1_000_000
+	assert.Equal(t, expected, applyRule(actions, channel, current))
+}
+
+func TestUsesPeerConditions(t *testing.T) {
+	rules := []config.PolicyRule{{Conditions: config.RuleConditions{MaxLocalRatio: 0.2}}}
+	assert.False(t, usesPeerConditions(rules))
+
+	rules = append(rules, config.PolicyRule{Conditions: config.RuleConditions{MaxPeerChannels: 10}})
+	assert.True(t, usesPeerConditions(rules))
+}
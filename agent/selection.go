@@ -0,0 +1,215 @@
+package agent
+
+import (
+	"maps"
+	"math"
+	"slices"
+
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/graph"
+)
+
+// selector picks the candidates that compose a batch of channels to open.
+type selector interface {
+	// next returns the index of the remaining candidate that should be added to the batch, given the
+	// candidates selected so far, and the centrality gain if it was computed. The remaining candidates are
+	// sorted by score.
+	next(selected, remaining []nodeCandidate) (int, *centralityGain)
+}
+
+// centralityGain is the improvement of our node's centrality after opening a channel to a candidate.
+type centralityGain struct {
+	Closeness   float64 `json:"closeness"`
+	Betweenness float64 `json:"betweenness"`
+	// Weighted sum of the gains, normalized by the highest gains among the candidates evaluated
+	Score float64 `json:"score"`
+}
+
+// newSelector returns the selector corresponding to the selection mode.
+func newSelector(
+	selection config.Selection,
+	weights config.CentralityWeights,
+	localNode local.Node,
+	networkGraph graph.Graph,
+) selector {
+	switch selection.Mode {
+	case config.SelectionModeDiversity:
+		return newDiversitySelector(selection.Diversity, networkGraph)
+	case config.SelectionModeMarginalGain:
+		return newMarginalGainSelector(selection.MarginalGain, weights, localNode, networkGraph)
+	default:
+		return scoreSelector{}
+	}
+}
+
+// scoreSelector picks the candidates with the highest scores.
+type scoreSelector struct{}
+
+func (scoreSelector) next([]nodeCandidate, []nodeCandidate) (int, *centralityGain) {
+	return 0, nil
+}
+
+// diversitySelector spreads the batch across the network communities, penalizing candidates that belong to
+// the same community or share many peers with the candidates already selected.
+type diversitySelector struct {
+	communities        map[string]int
+	peers              map[string]map[string]struct{}
+	communityPenalty   float64
+	sharedPeersPenalty float64
+}
+
+func newDiversitySelector(config config.Diversity, networkGraph graph.Graph) diversitySelector {
+	peers := make(map[string]map[string]struct{}, len(networkGraph.Nodes))
+	for _, node := range networkGraph.Nodes {
+		nodePeers := make(map[string]struct{}, len(node.Channels))
+		for _, channel := range node.Channels {
+			nodePeers[channel.PeerPublicKey] = struct{}{}
+		}
+		peers[node.PublicKey] = nodePeers
+	}
+
+	// Unset penalties, only possible if the defaults weren't applied, don't penalize the candidates
+	selector := diversitySelector{
+		communities: networkGraph.Communities(),
+		peers:       peers,
+	}
+	if config.CommunityPenalty != nil {
+		selector.communityPenalty = *config.CommunityPenalty
+	}
+	if config.SharedPeersPenalty != nil {
+		selector.sharedPeersPenalty = *config.SharedPeersPenalty
+	}
+
+	return selector
+}
+
+func (d diversitySelector) next(selected, remaining []nodeCandidate) (int, *centralityGain) {
+	selectedPeers := make(map[string]struct{})
+	for _, candidate := range selected {
+		for peer := range d.peers[candidate.PublicKey] {
+			selectedPeers[peer] = struct{}{}
+		}
+	}
+
+	bestIndex := 0
+	bestScore := math.Inf(-1)
+	for i, candidate := range remaining {
+		score := d.getScore(candidate, selected, selectedPeers)
+		if score > bestScore {
+			bestIndex = i
+			bestScore = score
+		}
+	}
+
+	return bestIndex, nil
+}
+
+// getScore returns the candidate's score after applying the community and shared peers penalties.
+func (d diversitySelector) getScore(
+	candidate nodeCandidate,
+	selected []nodeCandidate,
+	selectedPeers map[string]struct{},
+) float64 {
+	community, ok := d.communities[candidate.PublicKey]
+
+	sameCommunity := 0
+	if ok {
+		for _, s := range selected {
+			if c, ok := d.communities[s.PublicKey]; ok && c == community {
+				sameCommunity++
+			}
+		}
+	}
+
+	sharedRatio := 0.0
+	peers := d.peers[candidate.PublicKey]
+	if len(peers) > 0 {
+		shared := 0
+		for peer := range peers {
+			if _, ok := selectedPeers[peer]; ok {
+				shared++
+			}
+		}
+		sharedRatio = float64(shared) / float64(len(peers))
+	}
+
+	score := candidate.Score * math.Pow(1-d.communityPenalty, float64(sameCommunity))
+	return score * (1 - d.sharedPeersPenalty*sharedRatio)
+}
+
+// marginalGainSelector adds hypothetical channels from our node to the top-scored candidates and picks the one
+// that improves our closeness and betweenness centrality the most. The candidates picked are kept in the
+// simulation so the following picks take them into account.
+type marginalGainSelector struct {
+	simulation *graph.Simulation
+	weights    config.CentralityWeights
+	topK       int
+	// Number of selected candidates already added to the simulation
+	committed int
+}
+
+func newMarginalGainSelector(
+	config config.MarginalGain,
+	weights config.CentralityWeights,
+	localNode local.Node,
+	networkGraph graph.Graph,
+) *marginalGainSelector {
+	peers := slices.Collect(maps.Keys(localNode.ChannelPeers))
+
+	return &marginalGainSelector{
+		simulation: networkGraph.NewSimulation(localNode.PublicKey, peers, config.Samples),
+		weights:    weights,
+		topK:       config.TopK,
+	}
+}
+
+func (m *marginalGainSelector) next(selected, remaining []nodeCandidate) (int, *centralityGain) {
+	for _, candidate := range selected[m.committed:] {
+		// Candidates always come from the graph, the error can be ignored
+		_ = m.simulation.AddChannel(candidate.PublicKey)
+	}
+	m.committed = len(selected)
+
+	k := min(m.topK, len(remaining))
+	if k == 0 {
+		return 0, nil
+	}
+
+	base := m.simulation.Position()
+	gains := make([]centralityGain, k)
+	maxCloseness := 0.0
+	maxBetweenness := 0.0
+
+	for i := range k {
+		position, err := m.simulation.Evaluate(remaining[i].PublicKey)
+		if err != nil {
+			continue
+		}
+
+		gains[i] = centralityGain{
+			Closeness:   position.Closeness - base.Closeness,
+			Betweenness: position.Betweenness - base.Betweenness,
+		}
+		maxCloseness = max(maxCloseness, gains[i].Closeness)
+		maxBetweenness = max(maxBetweenness, gains[i].Betweenness)
+	}
+
+	best := 0
+	for i := range gains {
+		score := 0.0
+		if maxCloseness > 0 {
+			score += m.weights.Closeness * gains[i].Closeness / maxCloseness
+		}
+		if maxBetweenness > 0 {
+			score += m.weights.Betweenness * gains[i].Betweenness / maxBetweenness
+		}
+		gains[i].Score = math.Round(score*1000) / 1000
+
+		if gains[i].Score > gains[best].Score {
+			best = i
+		}
+	}
+
+	return best, &gains[best]
+}
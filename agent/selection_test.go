@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/graph"
+	"github.com/aftermath2/hydrus/lightning"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectorNext(t *testing.T) {
+	// Alice and Bob share their peers and belong to the same community, Carol is on her own with Zack
+	channelGraph := &lnrpc.ChannelGraph{}
+	for _, publicKey := range []string{"alice", "bob", "carol", "xavier", "yolanda", "zack"} {
+		channelGraph.Nodes = append(channelGraph.Nodes, &lnrpc.LightningNode{
+			PubKey:    publicKey,
+			Addresses: []*lnrpc.NodeAddress{{Addr: "localhost"}},
+		})
+	}
+	for i, peers := range [][2]string{
+		{"alice", "xavier"},
+		{"alice", "yolanda"},
+		{"bob", "xavier"},
+		{"bob", "yolanda"},
+		{"carol", "zack"},
+	} {
+		channelGraph.Edges = append(channelGraph.Edges, &lnrpc.ChannelEdge{
+			ChannelId:   uint64(i + 1),
+			Node1Pub:    peers[0],
+			Node2Pub:    peers[1],
+			Capacity:    1_000_000,
+			Node1Policy: &lnrpc.RoutingPolicy{},
+			Node2Policy: &lnrpc.RoutingPolicy{},
+		})
+	}
+
+	lndMock := lightning.NewClientMock()
+	lndMock.On("DescribeGraph", t.Context()).Return(channelGraph, nil)
+
+	networkGraph, err := graph.New(t.Context(), config.DefaultOpenWeights, lndMock)
+	assert.NoError(t, err)
+
+	candidates := []nodeCandidate{
+		{PublicKey: "alice", Score: 3},
+		{PublicKey: "bob", Score: 2.9},
+		{PublicKey: "carol", Score: 2},
+	}
+
+	penalty, noPenalty := 0.5, 0.0
+	tests := []struct {
+		desc          string
+		selection     config.Selection
+		expectedBatch []string
+	}{
+		{
+			desc:          "Score",
+			selection:     config.Selection{Mode: config.SelectionModeScore},
+			expectedBatch: []string{"alice", "bob", "carol"},
+		},
+		{
+			desc: "Diversity",
+			selection: config.Selection{
+				Mode: config.SelectionModeDiversity,
+				Diversity: config.Diversity{
+					CommunityPenalty:   &penalty,
+					SharedPeersPenalty: &penalty,
+				},
+			},
+			expectedBatch: []string{"alice", "carol", "bob"},
+		},
+		{
+			desc: "Diversity without penalties",
+			selection: config.Selection{
+				Mode: config.SelectionModeDiversity,
+				Diversity: config.Diversity{
+					CommunityPenalty:   &noPenalty,
+					SharedPeersPenalty: &noPenalty,
+				},
+			},
+			expectedBatch: []string{"alice", "bob", "carol"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			selector := newSelector(tt.selection, config.DefaultOpenWeights.Centrality, local.Node{}, networkGraph)
+
+			selected := make([]nodeCandidate, 0, len(candidates))
+			remaining := append([]nodeCandidate{}, candidates...)
+			for len(remaining) > 0 {
+				i, _ := selector.next(selected, remaining)
+				selected = append(selected, remaining[i])
+				remaining = append(remaining[:i], remaining[i+1:]...)
+			}
+
+			batch := make([]string, 0, len(selected))
+			for _, candidate := range selected {
+				batch = append(batch, candidate.PublicKey)
+			}
+			assert.Equal(t, tt.expectedBatch, batch)
+		})
+	}
+}
+
+func TestMarginalGainSelectorNext(t *testing.T) {
+	// us - alice - bob - carol - dave - erin
+	publicKeys := []string{"us", "alice", "bob", "carol", "dave", "erin"}
+	channelGraph := &lnrpc.ChannelGraph{}
+	for i, publicKey := range publicKeys {
+		channelGraph.Nodes = append(channelGraph.Nodes, &lnrpc.LightningNode{
+			PubKey:    publicKey,
+			Addresses: []*lnrpc.NodeAddress{{Addr: "localhost"}},
+		})
+
+		if i == 0 {
+			continue
+		}
+		channelGraph.Edges = append(channelGraph.Edges, &lnrpc.ChannelEdge{
+			ChannelId:   uint64(i),
+			Node1Pub:    publicKeys[i-1],
+			Node2Pub:    publicKey,
+			Capacity:    1_000_000,
+			Node1Policy: &lnrpc.RoutingPolicy{},
+			Node2Policy: &lnrpc.RoutingPolicy{},
+		})
+	}
+
+	lndMock := lightning.NewClientMock()
+	lndMock.On("DescribeGraph", t.Context()).Return(channelGraph, nil)
+
+	networkGraph, err := graph.New(t.Context(), config.DefaultOpenWeights, lndMock)
+	assert.NoError(t, err)
+
+	localNode := local.Node{
+		PublicKey:    "us",
+		ChannelPeers: map[string]struct{}{"alice": {}},
+	}
+	selection := config.Selection{
+		Mode: config.SelectionModeMarginalGain,
+		MarginalGain: config.MarginalGain{
+			TopK:    2,
+			Samples: 10,
+		},
+	}
+	selector := newSelector(selection, config.DefaultOpenWeights.Centrality, localNode, networkGraph)
+
+	// Bob is better scored but we are already close to him
+	remaining := []nodeCandidate{
+		{PublicKey: "bob", Score: 3},
+		{PublicKey: "erin", Score: 2},
+	}
+
+	i, gain := selector.next(nil, remaining)
+	assert.Equal(t, 1, i)
+	assert.NotNil(t, gain)
+	assert.Greater(t, gain.Closeness, 0.0)
+	assert.Equal(t, config.DefaultOpenWeights.Centrality.Closeness+config.DefaultOpenWeights.Centrality.Betweenness,
+		gain.Score)
+
+	i, gain = selector.next(remaining[1:], remaining[:1])
+	assert.Equal(t, 0, i)
+	assert.NotNil(t, gain)
+}
@@ -0,0 +1,203 @@
+package agent
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/aftermath2/hydrus/api"
+
+	"github.com/pkg/errors"
+)
+
+// Tasks executed by the agent on intervals.
+const (
+	channelsTaskName        = "channels"
+	routingPoliciesTaskName = "routing_policies"
+)
+
+var errAgentPaused = errors.New("agent is paused")
+
+// state holds the agent information that changes while it's running and is exposed through the API.
+//
+// All methods are safe to call on a nil state, in which case they do nothing and return zero values.
+type state struct {
+	runs    map[string]api.Run
+	running map[string]bool
+	// Tasks whose skipped runs were already notified since the agent was paused
+	pausedSkips map[string]bool
+	nodes       api.Ranking
+	channels    api.Ranking
+	mu          sync.RWMutex
+	dryRun      bool
+	paused      bool
+}
+
+func newState(dryRun bool) *state {
+	return &state{
+		runs:        make(map[string]api.Run),
+		running:     make(map[string]bool),
+		pausedSkips: make(map[string]bool),
+		dryRun:      dryRun,
+	}
+}
+
+// begin marks the task as running. Scheduled executions are rejected while the agent is paused.
+func (s *state) begin(name string, manual bool) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.paused && !manual {
+		return errAgentPaused
+	}
+
+	if s.running[name] {
+		return api.ErrTaskRunning
+	}
+
+	s.running[name] = true
+	return nil
+}
+
+// end marks the task as finished and records the run results.
+func (s *state) end(name string, run api.Run) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.running[name] = false
+	s.runs[name] = run
+}
+
+func (s *state) task(name string) api.Task {
+	if s == nil {
+		return api.Task{Name: name}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	task := api.Task{
+		Name:    name,
+		Running: s.running[name],
+	}
+	if run, ok := s.runs[name]; ok {
+		task.LastRun = &run
+	}
+
+	return task
+}
+
+func (s *state) isDryRun() bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dryRun
+}
+
+func (s *state) setDryRun(enabled bool) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dryRun = enabled
+}
+
+func (s *state) isPaused() bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.paused
+}
+
+func (s *state) setPaused(paused bool) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.paused != paused {
+		clear(s.pausedSkips)
+	}
+	s.paused = paused
+}
+
+// firstPausedSkip records a run of the task skipped because the agent is paused and returns whether it's the
+// first one since the agent was paused.
+func (s *state) firstPausedSkip(name string) bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pausedSkips[name] {
+		return false
+	}
+	s.pausedSkips[name] = true
+	return true
+}
+
+func (s *state) setNodes(candidates []nodeCandidate) {
+	if s == nil {
+		return
+	}
+
+	ranking := newRanking(candidates)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes = ranking
+}
+
+func (s *state) setChannels(candidates []channelCandidate) {
+	if s == nil {
+		return
+	}
+
+	ranking := newRanking(candidates)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.channels = ranking
+}
+
+func (s *state) rankings() api.Rankings {
+	if s == nil {
+		return api.Rankings{}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return api.Rankings{
+		Nodes:    s.nodes,
+		Channels: s.channels,
+	}
+}
+
+func newRanking[T any](candidates []T) api.Ranking {
+	// The candidates contain only basic types, encoding can't fail
+	candidatesB, _ := json.Marshal(candidates)
+	return api.Ranking{
+		UpdatedAt:  time.Now(),
+		Candidates: candidatesB,
+	}
+}
@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/aftermath2/hydrus/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateBegin(t *testing.T) {
+	tests := []struct {
+		desc        string
+		paused      bool
+		running     bool
+		manual      bool
+		expectedErr error
+	}{
+		{
+			desc: "Scheduled",
+		},
+		{
+			desc:        "Scheduled while paused",
+			paused:      true,
+			expectedErr: errAgentPaused,
+		},
+		{
+			desc:   "Manual while paused",
+			paused: true,
+			manual: true,
+		},
+		{
+			desc:        "Already running",
+			running:     true,
+			manual:      true,
+			expectedErr: api.ErrTaskRunning,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			s := newState(false)
+			s.setPaused(tt.paused)
+			s.running[channelsTaskName] = tt.running
+
+			err := s.begin(channelsTaskName, tt.manual)
+			assert.Equal(t, tt.expectedErr, err)
+
+			if tt.expectedErr == nil {
+				assert.True(t, s.task(channelsTaskName).Running)
+
+				run := api.Run{Duration: "1s", Manual: tt.manual}
+				s.end(channelsTaskName, run)
+
+				task := s.task(channelsTaskName)
+				assert.False(t, task.Running)
+				assert.Equal(t, &run, task.LastRun)
+			}
+		})
+	}
+}
+
+func TestStateFirstPausedSkip(t *testing.T) {
+	s := newState(false)
+	s.setPaused(true)
+
+	assert.True(t, s.firstPausedSkip(channelsTaskName))
+	assert.False(t, s.firstPausedSkip(channelsTaskName))
+	assert.True(t, s.firstPausedSkip(routingPoliciesTaskName))
+
+	// Pausing again doesn't reset the skips, resuming does
+	s.setPaused(true)
+	assert.False(t, s.firstPausedSkip(channelsTaskName))
+
+	s.setPaused(false)
+	s.setPaused(true)
+	assert.True(t, s.firstPausedSkip(channelsTaskName))
+}
+
+func TestStateNil(t *testing.T) {
+	var s *state
+
+	assert.NoError(t, s.begin(channelsTaskName, false))
+	s.end(channelsTaskName, api.Run{})
+	s.setNodes([]nodeCandidate{{PublicKey: "alice"}})
+	s.setChannels([]channelCandidate{{ChannelPoint: "1"}})
+	s.setDryRun(true)
+	s.setPaused(true)
+	assert.False(t, s.isDryRun())
+	assert.False(t, s.isPaused())
+	assert.False(t, s.firstPausedSkip(channelsTaskName))
+	assert.Equal(t, api.Task{Name: channelsTaskName}, s.task(channelsTaskName))
+	assert.Equal(t, api.Rankings{}, s.rankings())
+}
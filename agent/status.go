@@ -0,0 +1,329 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/pkg/errors"
+)
+
+const (
+	statusHistoryFile = "channel_status.json"
+	// Period of time the status toggles are kept for, the last one of each channel is always kept
+	statusHistoryRetention = 30 * 24 * time.Hour
+)
+
+// statusToggle is a change of the status of our direction of a channel.
+type statusToggle struct {
+	Time     time.Time `json:"time"`
+	Disabled bool      `json:"disabled"`
+	Reason   string    `json:"reason"`
+}
+
+// flapSample is the number of flaps of a channel peer observed at a point in time.
+type flapSample struct {
+	Time      time.Time `json:"time"`
+	FlapCount int32     `json:"flap_count"`
+}
+
+// channelStatus contains the status toggles of a channel and the flap counts of its peer, both sorted from
+// oldest to newest.
+type channelStatus struct {
+	Toggles []statusToggle `json:"toggles,omitempty"`
+	Flaps   []flapSample   `json:"flaps,omitempty"`
+}
+
+// statusHistory records the channels disabled and enabled by the agent and the flap counts of their peers in
+// a file, so that the channels disabled are enabled back after restarts. The file is read the first time the
+// history is used.
+//
+// All methods are safe to call on a nil statusHistory, in which case nothing is recorded.
+type statusHistory struct {
+	channels map[string]channelStatus
+	path     string
+	mu       sync.Mutex
+	loaded   bool
+}
+
+func newStatusHistory(dataDir string) *statusHistory {
+	if dataDir == "" {
+		return nil
+	}
+
+	return &statusHistory{path: filepath.Join(dataDir, statusHistoryFile)}
+}
+
+// disabled returns whether the channel was disabled by the agent.
+func (h *statusHistory) disabled(channelPoint string) (bool, error) {
+	if h == nil {
+		return false, nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.load(); err != nil {
+		return false, err
+	}
+
+	toggles := h.channels[channelPoint].Toggles
+	return len(toggles) > 0 && toggles[len(toggles)-1].Disabled, nil
+}
+
+// flaps records the flap count of the channel peer and returns the number of flaps within the window.
+func (h *statusHistory) flaps(channelPoint string, sample flapSample, window time.Duration) (int, error) {
+	if h == nil {
+		return 0, nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.load(); err != nil {
+		return 0, err
+	}
+
+	status := h.channels[channelPoint]
+	samples := append(status.Flaps, sample)
+
+	// Keep the newest sample taken before the window as the starting point
+	start := sample.Time.Add(-window)
+	i := 0
+	for j, s := range samples {
+		if !s.Time.After(start) {
+			i = j
+		}
+	}
+	samples = samples[i:]
+
+	count := 0
+	for j := 1; j < len(samples); j++ {
+		delta := samples[j].FlapCount - samples[j-1].FlapCount
+		if delta < 0 {
+			// The counter is reset when LND restarts
+			delta = samples[j].FlapCount
+		}
+		count += int(delta)
+	}
+
+	status.Flaps = samples
+	h.channels[channelPoint] = status
+	return count, h.save()
+}
+
+// toggle records a change of the channel status and persists it, discarding the toggles older than the
+// retention period.
+func (h *statusHistory) toggle(channelPoint string, toggle statusToggle) error {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.load(); err != nil {
+		return err
+	}
+
+	oldest := toggle.Time.Add(-statusHistoryRetention)
+	status := h.channels[channelPoint]
+	status.Toggles = slices.DeleteFunc(status.Toggles, func(t statusToggle) bool {
+		return t.Time.Before(oldest)
+	})
+	status.Toggles = append(status.Toggles, toggle)
+	h.channels[channelPoint] = status
+
+	return h.save()
+}
+
+// prune removes the channels that are no longer open.
+func (h *statusHistory) prune(channelPoints []string) error {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.load(); err != nil {
+		return err
+	}
+
+	removed := false
+	for point := range h.channels {
+		if !slices.Contains(channelPoints, point) {
+			delete(h.channels, point)
+			removed = true
+		}
+	}
+
+	if !removed {
+		return nil
+	}
+
+	return h.save()
+}
+
+// load reads the history file if it wasn't read already. It must be called with the lock held.
+func (h *statusHistory) load() error {
+	if h.loaded {
+		return nil
+	}
+
+	channels := make(map[string]channelStatus)
+	data, err := os.ReadFile(h.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrap(err, "reading channel status history")
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &channels); err != nil {
+			return errors.Wrap(err, "decoding channel status history")
+		}
+	}
+
+	h.channels = channels
+	h.loaded = true
+	return nil
+}
+
+// save writes the history to a temporary file and replaces the previous one with it. It must be called with
+// the lock held.
+func (h *statusHistory) save() error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return errors.Wrap(err, "creating data directory")
+	}
+
+	data, err := json.Marshal(h.channels)
+	if err != nil {
+		return errors.Wrap(err, "encoding channel status history")
+	}
+
+	tmpPath := h.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return errors.Wrap(err, "writing channel status history")
+	}
+
+	if err := os.Rename(tmpPath, h.path); err != nil {
+		return errors.Wrap(err, "replacing channel status history")
+	}
+
+	return nil
+}
+
+// statusChange returns whether the channel must be disabled, or enabled otherwise, and the reason. The
+// reason is empty if the status must be left as it is.
+func statusChange(
+	config config.ChannelStatusPolicy,
+	channel local.Channel,
+	disabled bool,
+	flaps int,
+) (bool, string) {
+	ratio := 1.0
+	if channel.Capacity > 0 {
+		ratio = localRatio(channel)
+	}
+	disableFlaps, enableFlaps := config.Flaps()
+	checkFlaps := disableFlaps > 0
+
+	if !disabled {
+		if ratio < config.DisableLocalRatio {
+			return true, fmt.Sprintf("local balance ratio %.4f is lower than %.4f", ratio, config.DisableLocalRatio)
+		}
+		if checkFlaps && flaps >= disableFlaps {
+			return true, fmt.Sprintf("peer flapped %d times within %s", flaps, config.FlapWindow)
+		}
+		return false, ""
+	}
+
+	if ratio < config.EnableLocalRatio || (checkFlaps && flaps > enableFlaps) {
+		return false, ""
+	}
+
+	reason := fmt.Sprintf("local balance ratio %.4f is at least %.4f", ratio, config.EnableLocalRatio)
+	if checkFlaps {
+		reason += fmt.Sprintf(" and the peer flapped %d times within %s", flaps, config.FlapWindow)
+	}
+	return false, reason
+}
+
+// updateStatuses disables our direction of the channels running out of local balance or whose peer is
+// unstable, and enables back the ones that recovered.
+func (a *agent) updateStatuses(ctx context.Context, localNode local.Node, now time.Time) error {
+	policy := a.config.RoutingPolicies.Status
+	if !policy.Enabled {
+		return nil
+	}
+
+	if a.statusHistory == nil {
+		a.logger.Warning("The channels status can't be managed without a data directory, skipping")
+		return nil
+	}
+
+	points := make([]string, 0, len(localNode.Channels.List))
+	for _, ch := range localNode.Channels.List {
+		points = append(points, ch.Point)
+
+		disabled, err := a.statusHistory.disabled(ch.Point)
+		if err != nil {
+			return err
+		}
+
+		flaps := 0
+		if disableFlaps, _ := policy.Flaps(); disableFlaps > 0 {
+			sample := flapSample{Time: now, FlapCount: ch.FlapCount}
+			flaps, err = a.statusHistory.flaps(ch.Point, sample, policy.FlapWindow)
+			if err != nil {
+				return err
+			}
+		}
+
+		disable, reason := statusChange(policy, ch, disabled, flaps)
+		if reason == "" {
+			continue
+		}
+
+		action := routerrpc.ChanStatusAction_ENABLE
+		if disable {
+			action = routerrpc.ChanStatusAction_DISABLE
+			a.logger.Infof("Disabling channel %q: %s", ch.Point, reason)
+		} else {
+			a.logger.Infof("Enabling channel %q: %s", ch.Point, reason)
+		}
+
+		if a.dryRun() {
+			continue
+		}
+
+		if err := a.lnd.UpdateChanStatus(ctx, ch.Point, action); err != nil {
+			a.logger.Errorf("Updating %q channel status: %v", ch.Point, err)
+			continue
+		}
+
+		if !disable {
+			// Hand the status back to LND once enabled, so that it disables the channel while the peer is
+			// offline. AUTO alone doesn't announce the channel as enabled
+			err := a.lnd.UpdateChanStatus(ctx, ch.Point, routerrpc.ChanStatusAction_AUTO)
+			if err != nil {
+				a.logger.Errorf("Handing %q channel status back to LND: %v", ch.Point, err)
+			}
+		}
+
+		toggle := statusToggle{Time: now, Disabled: disable, Reason: reason}
+		if err := a.statusHistory.toggle(ch.Point, toggle); err != nil {
+			a.logger.Errorf("Recording %q channel status toggle: %v", ch.Point, err)
+		}
+	}
+
+	return a.statusHistory.prune(points)
+}
@@ -0,0 +1,151 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/lightning"
+	"github.com/aftermath2/hydrus/logger"
+
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStatusHistoryFlaps(t *testing.T) {
+	history := newStatusHistory(t.TempDir())
+	now := time.Now().Truncate(time.Second)
+	window := 24 * time.Hour
+
+	samples := []struct {
+		sample   flapSample
+		expected int
+	}{
+		{sample: flapSample{Time: now.Add(-48 * time.Hour), FlapCount: 2}, expected: 0},
+		{sample: flapSample{Time: now.Add(-30 * time.Hour), FlapCount: 5}, expected: 3},
+		{sample: flapSample{Time: now.Add(-12 * time.Hour), FlapCount: 8}, expected: 6},
+		// LND restarted
+		{sample: flapSample{Time: now, FlapCount: 4}, expected: 7},
+	}
+	for _, s := range samples {
+		flaps, err := history.flaps("txid:0", s.sample, window)
+		assert.NoError(t, err)
+		assert.Equal(t, s.expected, flaps)
+	}
+
+	// The samples before the newest one preceding the window are discarded
+	assert.Len(t, history.channels["txid:0"].Flaps, 3)
+}
+
+func TestStatusHistoryToggle(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+	history := newStatusHistory(dir)
+
+	disabled, err := history.disabled("txid:0")
+	assert.NoError(t, err)
+	assert.False(t, disabled)
+
+	old := statusToggle{Time: now.Add(-statusHistoryRetention - time.Hour), Disabled: true}
+	assert.NoError(t, history.toggle("txid:0", old))
+	assert.NoError(t, history.toggle("txid:1", statusToggle{Time: now, Disabled: true}))
+
+	// Read the history persisted from a new instance, the last toggle is kept even if it's old
+	history = newStatusHistory(dir)
+	disabled, err = history.disabled("txid:0")
+	assert.NoError(t, err)
+	assert.True(t, disabled)
+
+	assert.NoError(t, history.toggle("txid:0", statusToggle{Time: now}))
+	disabled, err = history.disabled("txid:0")
+	assert.NoError(t, err)
+	assert.False(t, disabled)
+	assert.Len(t, history.channels["txid:0"].Toggles, 1)
+
+	assert.NoError(t, history.prune([]string{"txid:0"}))
+	disabled, err = history.disabled("txid:1")
+	assert.NoError(t, err)
+	assert.False(t, disabled)
+}
+
+func TestStatusChange(t *testing.T) {
+	disableFlaps, enableFlaps := 10, 2
+	policy := config.ChannelStatusPolicy{
+		Enabled:           true,
+		DisableLocalRatio: 0.01,
+		EnableLocalRatio:  0.05,
+		DisableFlaps:      &disableFlaps,
+		EnableFlaps:       &enableFlaps,
+		FlapWindow:        24 * time.Hour,
+	}
+
+	tests := []struct {
+		name         string
+		localBalance uint64
+		flaps        int
+		disabled     bool
+		disable      bool
+		change       bool
+	}{
+		{name: "Healthy", localBalance: 500_000},
+		{name: "Depleted", localBalance: 5_000, disable: true, change: true},
+		{name: "Flapping", localBalance: 500_000, flaps: 10, disable: true, change: true},
+		{name: "Recovering", localBalance: 30_000, disabled: true},
+		{name: "Still flapping", localBalance: 500_000, flaps: 3, disabled: true},
+		{name: "Recovered", localBalance: 50_000, flaps: 2, disabled: true, change: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channel := local.Channel{Capacity: 1_000_000, LocalBalance: tt.localBalance}
+			disable, reason := statusChange(policy, channel, tt.disabled, tt.flaps)
+			assert.Equal(t, tt.disable, disable)
+			assert.Equal(t, tt.change, reason != "")
+		})
+	}
+}
+
+func TestUpdateStatuses(t *testing.T) {
+	ctx := t.Context()
+	lndMock := lightning.NewClientMock()
+	a := agent{
+		lnd:           lndMock,
+		logger:        logger.New(""),
+		statusHistory: newStatusHistory(t.TempDir()),
+		config: config.Agent{
+			RoutingPolicies: config.RoutingPolicies{
+				Status: config.ChannelStatusPolicy{
+					Enabled:           true,
+					DisableLocalRatio: 0.01,
+					EnableLocalRatio:  0.05,
+				},
+			},
+		},
+	}
+	depleted := local.Channel{Point: "txid:0", Capacity: 1_000_000, LocalBalance: 1_000}
+	healthy := local.Channel{Point: "txid:1", Capacity: 1_000_000, LocalBalance: 500_000}
+	localNode := local.Node{Channels: local.Channels{List: []local.Channel{depleted, healthy}}}
+	now := time.Now()
+
+	lndMock.On("UpdateChanStatus", ctx, "txid:0", routerrpc.ChanStatusAction_DISABLE).Return(nil).Once()
+	assert.NoError(t, a.updateStatuses(ctx, localNode, now))
+
+	// Already disabled
+	assert.NoError(t, a.updateStatuses(ctx, localNode, now))
+
+	localNode.Channels.List[0].LocalBalance = 100_000
+	lndMock.On("UpdateChanStatus", ctx, "txid:0", routerrpc.ChanStatusAction_ENABLE).Return(nil).Once()
+	lndMock.On("UpdateChanStatus", ctx, "txid:0", routerrpc.ChanStatusAction_AUTO).Return(nil).Once()
+	assert.NoError(t, a.updateStatuses(ctx, localNode, now))
+
+	lndMock.AssertExpectations(t)
+	lndMock.AssertNumberOfCalls(t, "UpdateChanStatus", 3)
+	lndMock.AssertNotCalled(t, "UpdateChanStatus", ctx, "txid:1", mock.Anything)
+
+	toggles := a.statusHistory.channels["txid:0"].Toggles
+	assert.Len(t, toggles, 2)
+	assert.True(t, toggles[0].Disabled)
+	assert.False(t, toggles[1].Disabled)
+}
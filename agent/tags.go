@@ -0,0 +1,200 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"slices"
+
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/graph"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/pkg/errors"
+)
+
+// TagSet contains the tags assigned to a channel or peer, sorted as they are configured.
+type TagSet []config.Tag
+
+// Names returns the names of the tags.
+func (s TagSet) Names() []string {
+	names := make([]string, 0, len(s))
+	for _, tag := range s {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+// MarshalJSON encodes the tags names only.
+func (s TagSet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Names())
+}
+
+// neverClose returns the name of the first tag forbidding to close the channel.
+func (s TagSet) neverClose() (string, bool) {
+	i := slices.IndexFunc(s, func(tag config.Tag) bool { return tag.NeverClose })
+	if i == -1 {
+		return "", false
+	}
+	return s[i].Name, true
+}
+
+// neverOpen returns the name of the first tag forbidding to open a channel with the peer.
+func (s TagSet) neverOpen() (string, bool) {
+	i := slices.IndexFunc(s, func(tag config.Tag) bool { return tag.NeverOpen })
+	if i == -1 {
+		return "", false
+	}
+	return s[i].Name, true
+}
+
+// allowForceCloses returns the value of the first tag overriding the global one.
+func (s TagSet) allowForceCloses(global bool) bool {
+	for _, tag := range s {
+		if tag.AllowForceCloses != nil {
+			return *tag.AllowForceCloses
+		}
+	}
+	return global
+}
+
+// maxCapacityShare returns the lowest capacity share limit of the tags, zero if there is none.
+func (s TagSet) maxCapacityShare() float64 {
+	share := 0.0
+	for _, tag := range s {
+		if tag.MaxCapacityShare > 0 && (share == 0 || tag.MaxCapacityShare < share) {
+			share = tag.MaxCapacityShare
+		}
+	}
+	return share
+}
+
+// feeStrategy returns the name of the first tag setting a fee strategy.
+func (s TagSet) feeStrategy() (string, bool) {
+	i := slices.IndexFunc(s, func(tag config.Tag) bool { return tag.Fees != nil })
+	if i == -1 {
+		return "", false
+	}
+	return s[i].Name, true
+}
+
+// Tagger resolves the tags of the channels and peers, including the ones assigned from the blocklist and
+// keeplist.
+//
+// All methods are safe to call on a nil Tagger, in which case no tags are assigned.
+type Tagger struct {
+	tags []config.Tag
+	// Compiled alias expressions, indexed like the tags. Nil for the tags without one
+	aliases []*regexp.Regexp
+	// map[public_key]alias
+	nodeAliases map[string]string
+	peers       map[string]peerStats
+}
+
+// NewTagger returns the tagger for the agent configuration. The channel graph provides the aliases and the
+// network graph properties of the local channels peers, it may be nil if no tag uses them.
+func NewTagger(agentConfig config.Agent, channelGraph *lnrpc.ChannelGraph) *Tagger {
+	tags := make([]config.Tag, 0, len(agentConfig.Tags)+2)
+	if len(agentConfig.Blocklist) > 0 {
+		tags = append(tags, config.Tag{
+			Name:      config.TagBlocklist,
+			Match:     config.TagMatch{Peers: agentConfig.Blocklist},
+			NeverOpen: true,
+		})
+	}
+	if len(agentConfig.Keeplist) > 0 {
+		// The keeplist takes both channel points and peers public keys
+		tags = append(tags, config.Tag{
+			Name:       config.TagKeeplist,
+			Match:      config.TagMatch{Peers: agentConfig.Keeplist, Channels: agentConfig.Keeplist},
+			NeverClose: true,
+		})
+	}
+	tags = append(tags, agentConfig.Tags...)
+
+	aliases := make([]*regexp.Regexp, len(tags))
+	for i, tag := range tags {
+		if tag.Match.Alias != "" {
+			// The expressions were validated with the configuration
+			aliases[i] = regexp.MustCompile(tag.Match.Alias)
+		}
+	}
+
+	var nodeAliases map[string]string
+	if channelGraph != nil {
+		nodeAliases = make(map[string]string, len(channelGraph.Nodes))
+		for _, node := range channelGraph.Nodes {
+			nodeAliases[node.PubKey] = node.Alias
+		}
+	}
+
+	return &Tagger{
+		tags:        tags,
+		aliases:     aliases,
+		nodeAliases: nodeAliases,
+		peers:       newPeerStats(channelGraph),
+	}
+}
+
+// Channel returns the tags assigned to the local channel.
+func (t *Tagger) Channel(channel local.Channel) TagSet {
+	if t == nil {
+		return nil
+	}
+
+	publicKey := channel.RemotePublicKey
+	return t.resolve(publicKey, channel.Point, t.nodeAliases[publicKey], t.peers[publicKey])
+}
+
+// Node returns the tags assigned to the peer node.
+func (t *Tagger) Node(node graph.Node) TagSet {
+	if t == nil {
+		return nil
+	}
+
+	stats := peerStats{capacity: node.Capacity, numChannels: len(node.Channels)}
+	return t.resolve(node.PublicKey, "", node.Alias, stats)
+}
+
+// resolve returns the tags matching the peer, or the channel with it if the channel point isn't empty.
+func (t *Tagger) resolve(publicKey, channelPoint, alias string, stats peerStats) TagSet {
+	var tags TagSet
+	for i, tag := range t.tags {
+		match := tag.Match
+		if match.Selectors() &&
+			!slices.Contains(match.Peers, publicKey) &&
+			(channelPoint == "" || !slices.Contains(match.Channels, channelPoint)) &&
+			(t.aliases[i] == nil || !t.aliases[i].MatchString(alias)) {
+			continue
+		}
+
+		if inRange(stats.capacity, match.MinPeerCapacity, match.MaxPeerCapacity) &&
+			inRange(stats.numChannels, match.MinPeerChannels, match.MaxPeerChannels) {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// tagsUseGraph returns whether any tag requires the channels peers aliases or network graph properties.
+func tagsUseGraph(tags []config.Tag) bool {
+	return slices.ContainsFunc(tags, func(tag config.Tag) bool {
+		return tag.Match.Alias != "" || tag.Match.PeerConditions()
+	})
+}
+
+// tagger returns the tagger of the local channels, the channel graph is only fetched if the tags use it.
+func (a *agent) tagger(ctx context.Context) (*Tagger, error) {
+	var channelGraph *lnrpc.ChannelGraph
+	if tagsUseGraph(a.config.Tags) {
+		var err error
+		channelGraph, err = a.graphCache.get(ctx, a.lnd)
+		if err != nil {
+			return nil, errors.Wrap(err, "getting channel graph")
+		}
+	}
+
+	return NewTagger(a.config, channelGraph), nil
+}
@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/graph"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaggerChannel(t *testing.T) {
+	agentConfig := config.Agent{
+		Blocklist: []string{"mallory"},
+		Keeplist:  []string{"txid:0", "bob"},
+		Tags: []config.Tag{
+			{
+				Name:  "exchanges",
+				Match: config.TagMatch{Alias: "(?i)exchange"},
+			},
+			{
+				Name:  "large",
+				Match: config.TagMatch{MinPeerCapacity: 10_000_000, MinPeerChannels: 2},
+			},
+			{
+				Name:  "large exchanges",
+				Match: config.TagMatch{Alias: "(?i)exchange", MinPeerCapacity: 10_000_000},
+			},
+			{
+				Name:  "manual",
+				Match: config.TagMatch{Peers: []string{"carol"}, Channels: []string{"txid:9"}},
+			},
+		},
+	}
+	channelGraph := &lnrpc.ChannelGraph{
+		Nodes: []*lnrpc.LightningNode{
+			{PubKey: "carol", Alias: "Carol's Exchange"},
+			{PubKey: "dave", Alias: "EXCHANGE"},
+		},
+		Edges: []*lnrpc.ChannelEdge{
+			{Node1Pub: "carol", Node2Pub: "alice", Capacity: 6_000_000},
+			{Node1Pub: "erin", Node2Pub: "carol", Capacity: 6_000_000},
+			{Node1Pub: "dave", Node2Pub: "alice", Capacity: 1_000_000},
+		},
+	}
+	tagger := NewTagger(agentConfig, channelGraph)
+
+	tests := []struct {
+		desc     string
+		channel  local.Channel
+		expected []string
+	}{
+		{
+			desc:     "Keeplist channel point",
+			channel:  local.Channel{Point: "txid:0", RemotePublicKey: "frank"},
+			expected: []string{config.TagKeeplist},
+		},
+		{
+			desc:     "Keeplist peer",
+			channel:  local.Channel{Point: "txid:1", RemotePublicKey: "bob"},
+			expected: []string{config.TagKeeplist},
+		},
+		{
+			desc:     "Blocklist",
+			channel:  local.Channel{Point: "txid:2", RemotePublicKey: "mallory"},
+			expected: []string{config.TagBlocklist},
+		},
+		{
+			desc:     "Alias and graph conditions",
+			channel:  local.Channel{Point: "txid:3", RemotePublicKey: "carol"},
+			expected: []string{"exchanges", "large", "large exchanges", "manual"},
+		},
+		{
+			desc:     "Alias only",
+			channel:  local.Channel{Point: "txid:4", RemotePublicKey: "dave"},
+			expected: []string{"exchanges"},
+		},
+		{
+			desc:     "Channel point",
+			channel:  local.Channel{Point: "txid:9", RemotePublicKey: "dave"},
+			expected: []string{"exchanges", "manual"},
+		},
+		{
+			desc:     "No tags",
+			channel:  local.Channel{Point: "txid:5", RemotePublicKey: "erin"},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tagger.Channel(tt.channel).Names())
+		})
+	}
+
+	var nilTagger *Tagger
+	assert.Empty(t, nilTagger.Channel(local.Channel{Point: "txid:0"}))
+}
+
+func TestTaggerNode(t *testing.T) {
+	agentConfig := config.Agent{
+		Blocklist: []string{"mallory"},
+		Tags: []config.Tag{
+			{
+				Name:      "small",
+				Match:     config.TagMatch{MaxPeerChannels: 2},
+				NeverOpen: true,
+			},
+			{
+				Name:  "channel",
+				Match: config.TagMatch{Channels: []string{"txid:0"}},
+			},
+		},
+	}
+	tagger := NewTagger(agentConfig, nil)
+
+	small := graph.Node{PublicKey: "bob", Channels: []graph.Channel{{Point: "txid:0"}}}
+	assert.Equal(t, []string{"small"}, tagger.Node(small).Names())
+
+	large := graph.Node{PublicKey: "carol", Channels: make([]graph.Channel, 3)}
+	assert.Empty(t, tagger.Node(large))
+
+	blocked := graph.Node{PublicKey: "mallory", Channels: make([]graph.Channel, 3)}
+	assert.Equal(t, []string{config.TagBlocklist}, tagger.Node(blocked).Names())
+}
+
+func TestTagSet(t *testing.T) {
+	allow, deny := true, false
+	tags := TagSet{
+		{Name: "a", MaxCapacityShare: 0.2},
+		{Name: "b", NeverClose: true, AllowForceCloses: &deny},
+		{Name: "c", NeverClose: true, AllowForceCloses: &allow, MaxCapacityShare: 0.1},
+		{Name: "d", Fees: &config.FeeStrategy{Name: config.FeeStrategyFlat}},
+	}
+
+	name, ok := tags.neverClose()
+	assert.True(t, ok)
+	assert.Equal(t, "b", name)
+
+	_, ok = tags.neverOpen()
+	assert.False(t, ok)
+
+	assert.False(t, tags.allowForceCloses(true))
+	assert.True(t, tags[:1].allowForceCloses(true))
+	assert.Equal(t, 0.1, tags.maxCapacityShare())
+
+	name, ok = tags.feeStrategy()
+	assert.True(t, ok)
+	assert.Equal(t, "d", name)
+
+	data, err := json.Marshal(tags)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `["a","b","c","d"]`, string(data))
+}
+
+func TestTagsUseGraph(t *testing.T) {
+	tags := []config.Tag{{Match: config.TagMatch{Peers: []string{"a"}}}}
+	assert.False(t, tagsUseGraph(tags))
+
+	tags = append(tags, config.Tag{Match: config.TagMatch{Alias: "node"}})
+	assert.True(t, tagsUseGraph(tags))
+}
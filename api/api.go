@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrTaskNotFound is returned when the task requested does not exist.
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrTaskRunning is returned when the task requested is already being executed.
+	ErrTaskRunning = errors.New("task is already running")
+)
+
+// Controller exposes the state of a running agent and the operations that can be performed on it.
+type Controller interface {
+	Status() Status
+	Rankings() Rankings
+	// RunTask starts executing the task in the background, even if the agent is paused.
+	RunTask(name string) error
+	// Pause stops the scheduled executions of the tasks until Resume is called.
+	Pause()
+	Resume()
+	SetDryRun(enabled bool)
+}
+
+// Status of the agent.
+type Status struct {
+	Paused bool   `json:"paused"`
+	DryRun bool   `json:"dry_run"`
+	Tasks  []Task `json:"tasks"`
+}
+
+// Task contains information about one of the agent's tasks.
+type Task struct {
+	Name    string    `json:"name"`
+	Running bool      `json:"running"`
+	NextRun time.Time `json:"next_run,omitzero"`
+	LastRun *Run      `json:"last_run,omitempty"`
+}
+
+// Run contains the results of a task execution.
+type Run struct {
+	StartedAt time.Time `json:"started_at"`
+	Duration  string    `json:"duration"`
+	Manual    bool      `json:"manual,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Rankings contains the candidates evaluated during the last executions of the channels task.
+type Rankings struct {
+	Nodes    Ranking `json:"nodes"`
+	Channels Ranking `json:"channels"`
+}
+
+// Ranking is a list of candidates sorted from best to worst for nodes and from worst to best for channels.
+type Ranking struct {
+	UpdatedAt  time.Time       `json:"updated_at,omitzero"`
+	Candidates json.RawMessage `json:"candidates,omitempty"`
+}
+
+type dryRunRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/pkg/errors"
+)
+
+const clientTimeout = 30 * time.Second
+
+// Client communicates with the API of a running agent.
+type Client struct {
+	http    *http.Client
+	baseURL string
+	token   string
+}
+
+// NewClient returns a new API client.
+func NewClient(config config.API) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	baseURL := "http://" + config.Address
+
+	if config.Socket != "" {
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", config.Socket)
+		}
+		// The host is ignored when dialing the unix socket
+		baseURL = "http://hydrus"
+	}
+
+	return &Client{
+		http: &http.Client{
+			Transport: transport,
+			Timeout:   clientTimeout,
+		},
+		baseURL: baseURL,
+		token:   config.Token,
+	}
+}
+
+// Status returns the agent's status.
+func (c *Client) Status(ctx context.Context) (Status, error) {
+	var status Status
+	err := c.do(ctx, http.MethodGet, "/v1/status", nil, &status)
+	return status, err
+}
+
+// Rankings returns the candidates evaluated by the agent.
+func (c *Client) Rankings(ctx context.Context) (Rankings, error) {
+	var rankings Rankings
+	err := c.do(ctx, http.MethodGet, "/v1/rankings", nil, &rankings)
+	return rankings, err
+}
+
+// RunTask triggers the execution of a task.
+func (c *Client) RunTask(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodPost, "/v1/tasks/"+name+"/run", nil, nil)
+}
+
+// Pause stops the scheduled executions of the agent's tasks.
+func (c *Client) Pause(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/v1/scheduler/pause", nil, nil)
+}
+
+// Resume restarts the scheduled executions of the agent's tasks.
+func (c *Client) Resume(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/v1/scheduler/resume", nil, nil)
+}
+
+// SetDryRun enables or disables the agent's dry run mode.
+func (c *Client) SetDryRun(ctx context.Context, enabled bool) error {
+	return c.do(ctx, http.MethodPut, "/v1/dry_run", dryRunRequest{Enabled: enabled}, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "encoding request")
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return errors.Wrap(err, "creating request")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "sending request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var errResp errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil || errResp.Error == "" {
+			return errors.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+		return errors.New(errResp.Error)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.Wrap(err, "decoding response")
+	}
+
+	return nil
+}
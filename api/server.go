@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/logger"
+
+	"github.com/pkg/errors"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// Server exposes the agent controller through an HTTP/JSON API.
+type Server struct {
+	controller Controller
+	logger     logger.Logger
+	config     config.API
+}
+
+// NewServer returns a new API server.
+func NewServer(config config.API, controller Controller) *Server {
+	return &Server{
+		controller: controller,
+		logger:     logger.New("API"),
+		config:     config,
+	}
+}
+
+// Serve listens for requests until the context is canceled.
+func (s *Server) Serve(ctx context.Context) error {
+	listener, err := listen(s.config)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			s.logger.Errorf("Shutting down server: %v", err)
+		}
+	}()
+
+	s.logger.Infof("Listening on %s", listener.Addr())
+
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return errors.Wrap(err, "serving requests")
+	}
+
+	return nil
+}
+
+// Handler returns the API HTTP handler.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/status", s.getStatus)
+	mux.HandleFunc("GET /v1/rankings", s.getRankings)
+	mux.HandleFunc("POST /v1/tasks/{name}/run", s.runTask)
+	mux.HandleFunc("POST /v1/scheduler/pause", s.pause)
+	mux.HandleFunc("POST /v1/scheduler/resume", s.resume)
+	mux.HandleFunc("PUT /v1/dry_run", s.setDryRun)
+
+	return s.authenticate(mux)
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.Token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid token"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) getStatus(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.controller.Status())
+}
+
+func (s *Server) getRankings(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.controller.Rankings())
+}
+
+func (s *Server) runTask(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := s.controller.RunTask(name); err != nil {
+		switch {
+		case errors.Is(err, ErrTaskNotFound):
+			writeError(w, http.StatusNotFound, err)
+		case errors.Is(err, ErrTaskRunning):
+			writeError(w, http.StatusConflict, err)
+		default:
+			writeError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	s.logger.Infof("Task %q triggered", name)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) pause(w http.ResponseWriter, _ *http.Request) {
+	s.controller.Pause()
+	s.logger.Info("Scheduler paused")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) resume(w http.ResponseWriter, _ *http.Request) {
+	s.controller.Resume()
+	s.logger.Info("Scheduler resumed")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) setDryRun(w http.ResponseWriter, r *http.Request) {
+	var req dryRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.Wrap(err, "decoding request"))
+		return
+	}
+
+	s.controller.SetDryRun(req.Enabled)
+	s.logger.Infof("Dry run set to %t", req.Enabled)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listen returns a listener on the unix socket or the TCP address configured.
+func listen(config config.API) (net.Listener, error) {
+	if config.Socket == "" {
+		listener, err := net.Listen("tcp", config.Address)
+		return listener, errors.Wrap(err, "listening on address")
+	}
+
+	// Remove the socket left by a previous execution
+	if err := os.Remove(config.Socket); err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "removing unix socket")
+	}
+
+	listener, err := net.Listen("unix", config.Socket)
+	if err != nil {
+		return nil, errors.Wrap(err, "listening on unix socket")
+	}
+
+	if err := os.Chmod(config.Socket, 0o600); err != nil {
+		listener.Close()
+		return nil, errors.Wrap(err, "setting unix socket permissions")
+	}
+
+	return listener, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
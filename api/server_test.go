@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type controllerMock struct {
+	status   Status
+	rankings Rankings
+	runErr   error
+	ran      []string
+	paused   bool
+	dryRun   bool
+}
+
+func (c *controllerMock) Status() Status     { return c.status }
+func (c *controllerMock) Rankings() Rankings { return c.rankings }
+func (c *controllerMock) Pause()             { c.paused = true }
+func (c *controllerMock) Resume()            { c.paused = false }
+func (c *controllerMock) SetDryRun(e bool)   { c.dryRun = e }
+
+func (c *controllerMock) RunTask(name string) error {
+	if c.runErr != nil {
+		return c.runErr
+	}
+	c.ran = append(c.ran, name)
+	return nil
+}
+
+func newTestServer(t *testing.T, controller Controller) *Client {
+	t.Helper()
+	apiConfig := config.API{Token: "secret"}
+	server := httptest.NewServer(NewServer(apiConfig, controller).Handler())
+	t.Cleanup(server.Close)
+
+	apiConfig.Address = strings.TrimPrefix(server.URL, "http://")
+	return NewClient(apiConfig)
+}
+
+func TestServer(t *testing.T) {
+	ctx := t.Context()
+	startedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	controller := &controllerMock{
+		status: Status{
+			DryRun: true,
+			Tasks: []Task{
+				{
+					Name:    "channels",
+					LastRun: &Run{StartedAt: startedAt, Duration: "1s", Error: "failed"},
+				},
+			},
+		},
+		rankings: Rankings{
+			Channels: Ranking{
+				UpdatedAt:  startedAt,
+				Candidates: json.RawMessage(`[{"channel_point":"1"}]`),
+			},
+		},
+	}
+	client := newTestServer(t, controller)
+
+	status, err := client.Status(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, controller.status, status)
+
+	rankings, err := client.Rankings(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, controller.rankings, rankings)
+
+	assert.NoError(t, client.RunTask(ctx, "channels"))
+	assert.Equal(t, []string{"channels"}, controller.ran)
+
+	assert.NoError(t, client.Pause(ctx))
+	assert.True(t, controller.paused)
+	assert.NoError(t, client.Resume(ctx))
+	assert.False(t, controller.paused)
+
+	assert.NoError(t, client.SetDryRun(ctx, true))
+	assert.True(t, controller.dryRun)
+}
+
+func TestServerErrors(t *testing.T) {
+	ctx := t.Context()
+
+	tests := []struct {
+		desc        string
+		runErr      error
+		token       string
+		expectedErr string
+	}{
+		{
+			desc:        "Invalid token",
+			token:       "wrong",
+			expectedErr: "invalid token",
+		},
+		{
+			desc:        "Task not found",
+			token:       "secret",
+			runErr:      ErrTaskNotFound,
+			expectedErr: ErrTaskNotFound.Error(),
+		},
+		{
+			desc:        "Task running",
+			token:       "secret",
+			runErr:      ErrTaskRunning,
+			expectedErr: ErrTaskRunning.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			client := newTestServer(t, &controllerMock{runErr: tt.runErr})
+			client.token = tt.token
+
+			err := client.RunTask(ctx, "channels")
+			assert.EqualError(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestServeUnixSocket(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	apiConfig := config.API{
+		Socket: filepath.Join(t.TempDir(), "hydrus.sock"),
+		Token:  "secret",
+	}
+	controller := &controllerMock{status: Status{Paused: true}}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer(apiConfig, controller).Serve(ctx)
+	}()
+
+	client := NewClient(apiConfig)
+	assert.Eventually(t, func() bool {
+		status, err := client.Status(ctx)
+		return err == nil && status.Paused
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
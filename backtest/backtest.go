@@ -0,0 +1,306 @@
+// Package backtest replays the fee strategies over the forwarding history of the node to estimate how they
+// would have performed.
+package backtest
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/aftermath2/hydrus/agent"
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/graph"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/pkg/errors"
+)
+
+// Average time between blocks
+const blockInterval = 10 * time.Minute
+
+// Options contains the parameters of the simulation.
+type Options struct {
+	// Fee strategies evaluated
+	Fees config.Fees
+	// Tags setting the fee strategies of their channels
+	Tags []config.Tag
+	// Resolves the tags of each channel, nil if no tag sets a fee strategy
+	Tagger *agent.Tagger
+	// Period of time between routing policy updates
+	Interval time.Duration
+	// Fee rate the channels have when the simulation starts
+	InitialFeeRatePPM uint64
+	// Base fee the channels have when the simulation starts, the fee strategies keep it unchanged
+	InitialBaseFeeMsat uint64
+	// Sensitivity of the payments to fee rates higher than the ones actually charged, with 0 they are
+	// forwarded regardless of the fee rate
+	Elasticity float64
+	// Local balance ratio below which a channel is considered depleted
+	DepletedRatio float64
+}
+
+// Result contains the outcome of the simulation compared with what actually happened.
+type Result struct {
+	Start                time.Time       `json:"start"`
+	End                  time.Time       `json:"end"`
+	Channels             []ChannelResult `json:"channels"`
+	ActualRevenueMsat    uint64          `json:"actual_revenue_msat"`
+	SimulatedRevenueMsat uint64          `json:"simulated_revenue_msat"`
+}
+
+// ChannelResult contains the outcome of the simulation of a channel.
+type ChannelResult struct {
+	Point                string `json:"point"`
+	ID                   uint64 `json:"id"`
+	ActualRevenueMsat    uint64 `json:"actual_revenue_msat"`
+	SimulatedRevenueMsat uint64 `json:"simulated_revenue_msat"`
+	// Fraction of the intervals the channel was depleted
+	ActualDepleted    float64 `json:"actual_depleted"`
+	SimulatedDepleted float64 `json:"simulated_depleted"`
+	// Outgoing forwards the simulated local balance couldn't have carried
+	MissedForwards int `json:"missed_forwards"`
+}
+
+// sample is the balance of a channel recorded at a point in time.
+type sample struct {
+	time        time.Time
+	blockHeight uint32
+	balanceMsat uint64
+}
+
+// Run replays the fee strategies over the forwards that took place between the first and last balance
+// snapshots.
+//
+// Every interval the strategy sets a new fee rate from the simulated local balance and forwards. The
+// outgoing forwards are then replayed with that fee rate: a share of them is lost when it's higher than the
+// one actually charged, depending on the elasticity, and those exceeding the simulated local balance fail.
+// The balance changes that weren't caused by forwards, like payments or rebalances, are applied as they
+// were recorded.
+func Run(snapshots []agent.BalanceSnapshot, forwards []*lnrpc.ForwardingEvent, opts Options) (Result, error) {
+	if len(snapshots) < 2 {
+		return Result{}, errors.New("at least two balance snapshots are required")
+	}
+
+	if opts.Interval <= 0 {
+		return Result{}, errors.New("the interval must be greater than zero")
+	}
+
+	start := snapshots[0].Time
+	end := snapshots[len(snapshots)-1].Time
+	result := Result{Start: start, End: end}
+
+	channels := make([]agent.ChannelBalance, 0)
+	samples := make(map[uint64][]sample)
+	for _, snapshot := range snapshots {
+		for _, channel := range snapshot.Channels {
+			if _, ok := samples[channel.ID]; !ok {
+				channels = append(channels, channel)
+			}
+
+			samples[channel.ID] = append(samples[channel.ID], sample{
+				time:        snapshot.Time,
+				blockHeight: snapshot.BlockHeight,
+				balanceMsat: channel.LocalBalance * 1000,
+			})
+		}
+	}
+
+	events := slices.Clone(forwards)
+	slices.SortStableFunc(events, func(a, b *lnrpc.ForwardingEvent) int {
+		return compareUint64(a.TimestampNs, b.TimestampNs)
+	})
+
+	for _, channel := range channels {
+		tags := opts.Tagger.Channel(local.Channel{
+			Point:           channel.Point,
+			ID:              channel.ID,
+			RemotePublicKey: channel.RemotePublicKey,
+			Capacity:        channel.Capacity,
+			LocalBalance:    channel.LocalBalance,
+		})
+		strategy := agent.NewFeeStrategy(opts.Fees, opts.Tags, channel.Point, tags)
+		channelEvents := getChannelEvents(channel.ID, events)
+		channelResult := simulateChannel(channel, samples[channel.ID], channelEvents, strategy, opts)
+
+		result.ActualRevenueMsat += channelResult.ActualRevenueMsat
+		result.SimulatedRevenueMsat += channelResult.SimulatedRevenueMsat
+		result.Channels = append(result.Channels, channelResult)
+	}
+
+	return result, nil
+}
+
+func simulateChannel(
+	channel agent.ChannelBalance,
+	samples []sample,
+	events []*lnrpc.ForwardingEvent,
+	strategy agent.FeeStrategy,
+	opts Options,
+) ChannelResult {
+	result := ChannelResult{Point: channel.Point, ID: channel.ID}
+	if len(samples) < 2 {
+		return result
+	}
+
+	capacityMsat := channel.Capacity * 1000
+	externalFlows := getExternalFlows(channel.ID, samples, events)
+	depletedMsat := uint64(float64(capacityMsat) * opts.DepletedRatio)
+	depleted := func(balanceMsat uint64) bool {
+		return balanceMsat < depletedMsat
+	}
+
+	balanceMsat := samples[0].balanceMsat
+	policy := agent.Policy{BaseFeeMsat: opts.InitialBaseFeeMsat, FeeRatePPM: opts.InitialFeeRatePPM}
+	forwards := agent.Forwards{}
+	end := samples[len(samples)-1].time
+	sampleIndex := 0
+	eventIndex := 0
+	steps, actualDepleted, simulatedDepleted := 0, 0, 0
+
+	for t := samples[0].time; t.Before(end); t = t.Add(opts.Interval) {
+		// Apply the balance changes that weren't caused by forwards once their sample time is reached
+		for sampleIndex+1 < len(samples) && !samples[sampleIndex+1].time.After(t) {
+			sampleIndex++
+			balanceMsat = addFlow(balanceMsat, externalFlows[sampleIndex-1], capacityMsat)
+		}
+		actual := samples[sampleIndex]
+
+		state := agent.ChannelState{
+			Channel: local.Channel{
+				ID:           channel.ID,
+				Point:        channel.Point,
+				BlockHeight:  graph.GetChannelBlockHeight(channel.ID),
+				Capacity:     channel.Capacity,
+				LocalBalance: balanceMsat / 1000,
+			},
+			CurrentBlockHeight: actual.blockHeight + uint32(t.Sub(actual.time)/blockInterval),
+		}
+		// Damping isn't replayed, every policy returned by the strategy is applied
+		policy = strategy.Policy(state, forwards, policy)
+
+		steps++
+		if depleted(actual.balanceMsat) {
+			actualDepleted++
+		}
+		if depleted(balanceMsat) {
+			simulatedDepleted++
+		}
+
+		// Replay the forwards of the interval with the simulated fee rate
+		next := t.Add(opts.Interval)
+		forwards = agent.Forwards{}
+		for ; eventIndex < len(events) && eventTime(events[eventIndex]).Before(next); eventIndex++ {
+			event := events[eventIndex]
+			if eventTime(event).Before(t) {
+				continue
+			}
+
+			if event.ChanIdIn == channel.ID {
+				balanceMsat = addFlow(balanceMsat, int64(event.AmtInMsat), capacityMsat)
+				forwards.AmountIn += event.AmtIn
+			}
+
+			if event.ChanIdOut == channel.ID {
+				result.ActualRevenueMsat += event.FeeMsat
+
+				share := forwardedShare(event, policy, opts.Elasticity)
+				amountMsat := uint64(float64(event.AmtOutMsat) * share)
+				if amountMsat > balanceMsat {
+					result.MissedForwards++
+					continue
+				}
+
+				// The actual fees include the base fee, charge it on the share of the forward that took place
+				balanceMsat -= amountMsat
+				result.SimulatedRevenueMsat += uint64(math.Round(float64(policy.BaseFeeMsat)*share +
+					float64(amountMsat)*float64(policy.FeeRatePPM)/1e6))
+				forwards.AmountOut += amountMsat / 1000
+			}
+		}
+	}
+
+	if steps > 0 {
+		result.ActualDepleted = float64(actualDepleted) / float64(steps)
+		result.SimulatedDepleted = float64(simulatedDepleted) / float64(steps)
+	}
+
+	return result
+}
+
+// getExternalFlows returns the balance changes between each pair of consecutive samples that weren't caused
+// by forwards.
+func getExternalFlows(channelID uint64, samples []sample, events []*lnrpc.ForwardingEvent) []int64 {
+	flows := make([]int64, len(samples)-1)
+	for i := range flows {
+		from, to := samples[i].time, samples[i+1].time
+		forwardsFlow := int64(0)
+		for _, event := range events {
+			timestamp := eventTime(event)
+			if timestamp.Before(from) || !timestamp.Before(to) {
+				continue
+			}
+
+			if event.ChanIdIn == channelID {
+				forwardsFlow += int64(event.AmtInMsat)
+			}
+			if event.ChanIdOut == channelID {
+				forwardsFlow -= int64(event.AmtOutMsat)
+			}
+		}
+
+		flows[i] = int64(samples[i+1].balanceMsat) - int64(samples[i].balanceMsat) - forwardsFlow
+	}
+
+	return flows
+}
+
+// forwardedShare estimates the share of a forward that would have taken place with the policy specified. The
+// effective fee rates, including the base fees, are compared.
+func forwardedShare(event *lnrpc.ForwardingEvent, policy agent.Policy, elasticity float64) float64 {
+	if event.AmtOutMsat == 0 {
+		return 0
+	}
+
+	// Consider at least 1 ppm so that the forwards that paid no fees are not lost entirely
+	actualFeeRate := max(float64(event.FeeMsat)/float64(event.AmtOutMsat)*1e6, 1)
+	feeRate := float64(policy.FeeRatePPM) + float64(policy.BaseFeeMsat)/float64(event.AmtOutMsat)*1e6
+	if elasticity == 0 || feeRate <= actualFeeRate {
+		return 1
+	}
+
+	return math.Pow(actualFeeRate/feeRate, elasticity)
+}
+
+// addFlow adds the flow to the balance keeping it within the channel capacity.
+func addFlow(balanceMsat uint64, flowMsat int64, capacityMsat uint64) uint64 {
+	balance := int64(balanceMsat) + flowMsat
+	return uint64(min(max(balance, 0), int64(capacityMsat)))
+}
+
+// getChannelEvents returns the forwards that went through the channel.
+func getChannelEvents(channelID uint64, events []*lnrpc.ForwardingEvent) []*lnrpc.ForwardingEvent {
+	filtered := make([]*lnrpc.ForwardingEvent, 0)
+	for _, event := range events {
+		if event.ChanIdIn == channelID || event.ChanIdOut == channelID {
+			filtered = append(filtered, event)
+		}
+	}
+
+	return filtered
+}
+
+func eventTime(event *lnrpc.ForwardingEvent) time.Time {
+	return time.Unix(0, int64(event.TimestampNs))
+}
+
+func compareUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,197 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aftermath2/hydrus/agent"
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	channelID := uint64(1)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	snapshots := func(first, last uint64) []agent.BalanceSnapshot {
+		return []agent.BalanceSnapshot{
+			{
+				Time: start,
+				Channels: []agent.ChannelBalance{
+					{Point: "a:0", ID: channelID, Capacity: 1_000_000, LocalBalance: first},
+				},
+			},
+			{
+				Time: start.Add(2 * time.Hour),
+				Channels: []agent.ChannelBalance{
+					{Point: "a:0", ID: channelID, Capacity: 1_000_000, LocalBalance: last},
+				},
+			},
+		}
+	}
+	forwards := []*lnrpc.ForwardingEvent{
+		{
+			TimestampNs: uint64(start.Add(30 * time.Minute).UnixNano()),
+			ChanIdIn:    2,
+			ChanIdOut:   channelID,
+			AmtOutMsat:  100_000_000,
+			FeeMsat:     100_000,
+		},
+	}
+	options := func(feeRatePPM uint64) Options {
+		return Options{
+			InitialBaseFeeMsat: 1_000,
+			Fees: config.Fees{
+				FeeStrategy: config.FeeStrategy{
+					Name: config.FeeStrategyFlat,
+					Flat: config.FlatFees{FeeRatePPM: feeRatePPM},
+				},
+			},
+			Interval:      time.Hour,
+			Elasticity:    1,
+			DepletedRatio: 0.1,
+		}
+	}
+
+	tests := []struct {
+		desc      string
+		snapshots []agent.BalanceSnapshot
+		opts      Options
+		expected  ChannelResult
+	}{
+		{
+			desc:      "Lower fee rate",
+			snapshots: snapshots(500_000, 400_000),
+			opts:      options(500),
+			expected: ChannelResult{
+				Point:                "a:0",
+				ID:                   channelID,
+				ActualRevenueMsat:    100_000,
+				SimulatedRevenueMsat: 51_000,
+			},
+		},
+		{
+			desc:      "Higher fee rate",
+			snapshots: snapshots(500_000, 400_000),
+			opts:      options(2_000),
+			expected: ChannelResult{
+				Point:                "a:0",
+				ID:                   channelID,
+				ActualRevenueMsat:    100_000,
+				SimulatedRevenueMsat: 100_000,
+			},
+		},
+		{
+			desc:      "Depleted",
+			snapshots: snapshots(50_000, 0),
+			opts:      options(500),
+			expected: ChannelResult{
+				Point:             "a:0",
+				ID:                channelID,
+				ActualRevenueMsat: 100_000,
+				ActualDepleted:    1,
+				SimulatedDepleted: 1,
+				MissedForwards:    1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			result, err := Run(tt.snapshots, forwards, tt.opts)
+			assert.NoError(t, err)
+
+			assert.Equal(t, []ChannelResult{tt.expected}, result.Channels)
+			assert.Equal(t, tt.expected.ActualRevenueMsat, result.ActualRevenueMsat)
+			assert.Equal(t, tt.expected.SimulatedRevenueMsat, result.SimulatedRevenueMsat)
+		})
+	}
+}
+
+func TestRunTagStrategy(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	channel := agent.ChannelBalance{Point: "a:0", ID: 1, RemotePublicKey: "peer", Capacity: 1_000_000}
+	snapshots := []agent.BalanceSnapshot{
+		{Time: start, Channels: []agent.ChannelBalance{channel}},
+		{Time: start.Add(2 * time.Hour), Channels: []agent.ChannelBalance{channel}},
+	}
+	snapshots[0].Channels[0].LocalBalance = 500_000
+	snapshots[1].Channels[0].LocalBalance = 400_000
+	forwards := []*lnrpc.ForwardingEvent{
+		{
+			TimestampNs: uint64(start.Add(30 * time.Minute).UnixNano()),
+			ChanIdIn:    2,
+			ChanIdOut:   1,
+			AmtOutMsat:  100_000_000,
+			FeeMsat:     100_000,
+		},
+	}
+
+	agentConfig := config.Agent{
+		Tags: []config.Tag{
+			{
+				Name:  "flat",
+				Match: config.TagMatch{Peers: []string{"peer"}},
+				Fees: &config.FeeStrategy{
+					Name: config.FeeStrategyFlat,
+					Flat: config.FlatFees{FeeRatePPM: 500},
+				},
+			},
+		},
+	}
+	opts := Options{
+		Fees: config.Fees{
+			FeeStrategy: config.FeeStrategy{
+				Name: config.FeeStrategyFlat,
+				Flat: config.FlatFees{FeeRatePPM: 2_000},
+			},
+		},
+		Tags:          agentConfig.Tags,
+		Tagger:        agent.NewTagger(agentConfig, nil),
+		Interval:      time.Hour,
+		Elasticity:    1,
+		DepletedRatio: 0.1,
+	}
+
+	// The tag strategy, with a lower fee rate than the one charged, takes precedence over the global one
+	result, err := Run(snapshots, forwards, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(50_000), result.SimulatedRevenueMsat)
+}
+
+func TestRunErrors(t *testing.T) {
+	_, err := Run([]agent.BalanceSnapshot{{}}, nil, Options{Interval: time.Hour})
+	assert.Error(t, err)
+
+	_, err = Run([]agent.BalanceSnapshot{{}, {}}, nil, Options{})
+	assert.Error(t, err)
+}
+
+func TestGetExternalFlows(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	samples := []sample{
+		{time: start, balanceMsat: 1_000},
+		{time: start.Add(time.Hour), balanceMsat: 1_500},
+		{time: start.Add(2 * time.Hour), balanceMsat: 3_500},
+	}
+	events := []*lnrpc.ForwardingEvent{
+		{TimestampNs: uint64(start.Add(time.Minute).UnixNano()), ChanIdIn: 1, AmtInMsat: 1_000},
+		{TimestampNs: uint64(start.Add(90 * time.Minute).UnixNano()), ChanIdOut: 1, AmtOutMsat: 500},
+	}
+
+	// A 500 msat payment in the first hour and a 2,500 msat rebalance in the second one
+	assert.Equal(t, []int64{-500, 2_500}, getExternalFlows(1, samples, events))
+}
+
+func TestForwardedShare(t *testing.T) {
+	event := &lnrpc.ForwardingEvent{AmtOutMsat: 1_000_000, FeeMsat: 100}
+
+	assert.Equal(t, 1.0, forwardedShare(event, agent.Policy{FeeRatePPM: 50}, 1))
+	assert.Equal(t, 1.0, forwardedShare(event, agent.Policy{FeeRatePPM: 100}, 1))
+	assert.Equal(t, 0.5, forwardedShare(event, agent.Policy{FeeRatePPM: 200}, 1))
+	assert.Equal(t, 0.5, forwardedShare(event, agent.Policy{BaseFeeMsat: 100, FeeRatePPM: 100}, 1))
+	assert.Equal(t, 0.25, forwardedShare(event, agent.Policy{FeeRatePPM: 200}, 2))
+	assert.Equal(t, 1.0, forwardedShare(event, agent.Policy{FeeRatePPM: 200}, 0))
+	assert.Equal(t, 0.0, forwardedShare(&lnrpc.ForwardingEvent{}, agent.Policy{FeeRatePPM: 200}, 1))
+}
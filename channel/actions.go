@@ -0,0 +1,128 @@
+package channel
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ActionsFile is the name of the file inside the data directory where the channels opened and closed by the
+// manager are recorded.
+const ActionsFile = "channel_actions.jsonl"
+
+// Types of the actions recorded by the manager.
+const (
+	ActionOpen  = "open"
+	ActionClose = "close"
+)
+
+// Action is a channel opened or closed by the manager.
+type Action struct {
+	Time time.Time `json:"time"`
+	Type string    `json:"type"`
+	TxID string    `json:"txid"`
+	// Peer of the channel opened, the channel point isn't known until the funding transaction is broadcast
+	PublicKey string `json:"public_key,omitempty"`
+	// Channel closed
+	ChannelPoint string `json:"channel_point,omitempty"`
+	Amount       uint64 `json:"amount,omitempty"`
+	Force        bool   `json:"force,omitempty"`
+}
+
+// actionRecorder appends the actions taken by the manager to a file, so that they can be displayed later.
+//
+// All methods are safe to call on a nil actionRecorder, in which case nothing is recorded.
+type actionRecorder struct {
+	path string
+	mu   sync.Mutex
+}
+
+func newActionRecorder(dataDir string) *actionRecorder {
+	if dataDir == "" {
+		return nil
+	}
+
+	return &actionRecorder{path: filepath.Join(dataDir, ActionsFile)}
+}
+
+// record appends the actions to the file.
+func (r *actionRecorder) record(actions ...Action) error {
+	if r == nil {
+		return nil
+	}
+
+	var data []byte
+	for _, action := range actions {
+		line, err := json.Marshal(action)
+		if err != nil {
+			return errors.Wrap(err, "encoding channel action")
+		}
+		data = append(append(data, line...), '\n')
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o700); err != nil {
+		return errors.Wrap(err, "creating data directory")
+	}
+
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "opening channel actions file")
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return errors.Wrap(err, "writing channel actions")
+	}
+
+	return nil
+}
+
+// ReadActions returns the actions recorded by the manager in the data directory, sorted from newest to
+// oldest.
+func ReadActions(dataDir string) ([]Action, error) {
+	if dataDir == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(filepath.Join(dataDir, ActionsFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "opening channel actions file")
+	}
+	defer f.Close()
+
+	var actions []Action
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var action Action
+		if err := json.Unmarshal(scanner.Bytes(), &action); err != nil {
+			return nil, errors.Wrapf(err, "decoding channel action on line %d", line)
+		}
+		actions = append(actions, action)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading channel actions file")
+	}
+
+	slices.SortStableFunc(actions, func(a, b Action) int {
+		return b.Time.Compare(a.Time)
+	})
+
+	return actions, nil
+}
@@ -0,0 +1,33 @@
+package channel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActions(t *testing.T) {
+	dataDir := t.TempDir()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	actions, err := ReadActions(dataDir)
+	assert.NoError(t, err)
+	assert.Empty(t, actions)
+
+	recorder := newActionRecorder(dataDir)
+	open := Action{Time: now.Add(-time.Hour), Type: ActionOpen, TxID: "a", PublicKey: "alice", Amount: 1_000_000}
+	closeAction := Action{Time: now, Type: ActionClose, TxID: "b", ChannelPoint: "txid:0", Force: true}
+	assert.NoError(t, recorder.record(open))
+	assert.NoError(t, recorder.record(closeAction))
+
+	actions, err = ReadActions(dataDir)
+	assert.NoError(t, err)
+	assert.Equal(t, []Action{closeAction, open}, actions)
+}
+
+func TestActionRecorderNil(t *testing.T) {
+	recorder := newActionRecorder("")
+	assert.Nil(t, recorder)
+	assert.NoError(t, recorder.record(Action{Type: ActionOpen}))
+}
@@ -3,10 +3,15 @@ package channel
 import (
 	"context"
 	"encoding/hex"
+	"maps"
+	"slices"
+	"time"
 
 	"github.com/aftermath2/hydrus/config"
 	"github.com/aftermath2/hydrus/lightning"
 	"github.com/aftermath2/hydrus/logger"
+	"github.com/aftermath2/hydrus/metrics"
+	"github.com/aftermath2/hydrus/notifier"
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/lightningnetwork/lnd/lnrpc"
@@ -14,10 +19,17 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
+// TransactionLabel is the label of the funding transactions created by the manager, used to attribute their
+// costs.
+const TransactionLabel = "Hydrus"
+
 // OpenRequest contains the information necessary to open a set of channels.
 type OpenRequest struct {
 	// map[public_key]funding_amount
 	Nodes map[string]uint64
+	// Addresses of the peers we must connect to before opening the channels.
+	// map[public_key]addresses
+	Peers map[string][]string
 	SatvB uint64
 }
 
@@ -30,11 +42,14 @@ type CloseRequest struct {
 
 // UpdatePolicyRequest contains the information necessary to update the policy of a channel.
 type UpdatePolicyRequest struct {
-	ChannelPoint  string
-	BaseFeeMsat   uint64
-	FeeRatePPM    uint64
-	MaxHTLCMsat   uint64
-	TimeLockDelta uint64
+	ChannelPoint       string
+	BaseFeeMsat        uint64
+	FeeRatePPM         uint64
+	MinHTLCMsat        uint64
+	MaxHTLCMsat        uint64
+	TimeLockDelta      uint64
+	InboundBaseFeeMsat int32
+	InboundFeeRatePPM  int32
 }
 
 // Manager handles the opening, closing an re-sizing of channels.
@@ -48,20 +63,37 @@ type Manager interface {
 type manager struct {
 	lnd           lightning.Client
 	logger        logger.Logger
+	notifier      *notifier.Dispatcher
+	actions       *actionRecorder
 	subscriptions map[string]struct{}
 	config        config.ChannelManager
 }
 
-// NewManager returns a channel manager that opens, closes and re-sizes channels.
-func NewManager(config config.ChannelManager, lnd lightning.Client) Manager {
+// NewManager returns a channel manager that opens, closes and re-sizes channels. The channels opened and
+// closed are recorded in the data directory, unless it's empty.
+func NewManager(
+	config config.ChannelManager,
+	dataDir string,
+	lnd lightning.Client,
+	notifier *notifier.Dispatcher,
+) Manager {
 	return &manager{
-		config: config,
-		lnd:    lnd,
-		logger: logger.New("CHM"),
+		config:   config,
+		lnd:      lnd,
+		notifier: notifier,
+		actions:  newActionRecorder(dataDir),
+		logger:   logger.New("CHM"),
 	}
 }
 
 func (m *manager) Open(ctx context.Context, req OpenRequest) error {
+	for _, publicKey := range slices.Sorted(maps.Keys(req.Peers)) {
+		m.logger.Debugf("Connecting with peer %q", publicKey)
+		if err := m.lnd.ConnectPeer(ctx, publicKey, req.Peers[publicKey]); err != nil {
+			return errors.Wrapf(err, "connecting with peer %q", publicKey)
+		}
+	}
+
 	batch := make([]*lnrpc.BatchOpenChannel, 0, len(req.Nodes))
 
 	for publicKey, amount := range req.Nodes {
@@ -87,14 +119,31 @@ func (m *manager) Open(ctx context.Context, req OpenRequest) error {
 		MinConfs:              m.config.MinConf,
 		SatPerVbyte:           int64(req.SatvB),
 		SpendUnconfirmed:      false,
-		Label:                 "Hydrus",
+		Label:                 TransactionLabel,
 		CoinSelectionStrategy: lnrpc.CoinSelectionStrategy_STRATEGY_USE_GLOBAL_CONFIG,
 	})
 	if err != nil {
 		return errors.Wrap(err, "batch opening channels")
 	}
 
+	metrics.ChannelsOpened.Add(float64(len(batch)))
+	m.notifier.Notify(ctx, notifier.NewChannelsOpened(txID, req.Nodes))
 	m.logger.Infof("Opening channels in transaction %q", txID)
+
+	now := time.Now()
+	actions := make([]Action, 0, len(req.Nodes))
+	for _, publicKey := range slices.Sorted(maps.Keys(req.Nodes)) {
+		actions = append(actions, Action{
+			Time:      now,
+			Type:      ActionOpen,
+			TxID:      txID,
+			PublicKey: publicKey,
+			Amount:    req.Nodes[publicKey],
+		})
+	}
+	if err := m.actions.record(actions...); err != nil {
+		m.logger.Errorf("Recording channels opened: %v", err)
+	}
 	return nil
 }
 
@@ -141,21 +190,53 @@ func (m *manager) close(ctx context.Context, satvB uint64, channelPoint string,
 				return errors.Wrap(err, "parsing transaction ID")
 			}
 
+			metrics.ChannelsClosed.Inc()
+			m.notifier.Notify(ctx, notifier.NewChannelClosed(channelPoint, txID.String(), force))
 			m.logger.Infof("Closing channel on outpoint %q in transaction %s",
 				channelPoint, txID.String(),
 			)
+
+			action := Action{
+				Time:         time.Now(),
+				Type:         ActionClose,
+				TxID:         txID.String(),
+				ChannelPoint: channelPoint,
+				Force:        force,
+			}
+			if err := m.actions.record(action); err != nil {
+				m.logger.Errorf("Recording %q channel close: %v", channelPoint, err)
+			}
 			return nil
 		}
 	}
 }
 
 func (m *manager) UpdatePolicy(ctx context.Context, req UpdatePolicyRequest) error {
-	return m.lnd.UpdateChannelPolicy(
-		ctx,
-		req.ChannelPoint,
-		req.BaseFeeMsat,
-		req.FeeRatePPM,
-		req.MaxHTLCMsat,
-		req.TimeLockDelta,
-	)
+	chanPoint, err := lightning.ParseChannelPoint(req.ChannelPoint)
+	if err != nil {
+		return errors.Wrap(err, "parsing channel point")
+	}
+
+	err = m.lnd.UpdateChannelPolicy(ctx, &lnrpc.PolicyUpdateRequest{
+		Scope: &lnrpc.PolicyUpdateRequest_ChanPoint{
+			ChanPoint: chanPoint,
+		},
+		BaseFeeMsat:   int64(req.BaseFeeMsat),
+		FeeRatePpm:    uint32(req.FeeRatePPM),
+		MaxHtlcMsat:   req.MaxHTLCMsat,
+		TimeLockDelta: uint32(req.TimeLockDelta),
+		// Leave the minimum HTLC unchanged if it's unknown
+		MinHtlcMsat:          req.MinHTLCMsat,
+		MinHtlcMsatSpecified: req.MinHTLCMsat > 0,
+		InboundFee: &lnrpc.InboundFee{
+			BaseFeeMsat: req.InboundBaseFeeMsat,
+			FeeRatePpm:  req.InboundFeeRatePPM,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	metrics.PolicyUpdates.Inc()
+	return nil
 }
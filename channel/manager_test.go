@@ -26,10 +26,14 @@ func TestManagerOpen(t *testing.T) {
 		Nodes: map[string]uint64{
 			publicKey: 1_000_000,
 		},
+		Peers: map[string][]string{
+			publicKey: {"127.0.0.1:9735"},
+		},
 		SatvB: 2,
 	}
 
 	lndMock := lightning.NewClientMock()
+	lndMock.On("ConnectPeer", ctx, publicKey, req.Peers[publicKey]).Return(nil)
 	batchReq := &lnrpc.BatchOpenChannelRequest{
 		Channels: []*lnrpc.BatchOpenChannel{
 			{
@@ -49,10 +53,20 @@ func TestManagerOpen(t *testing.T) {
 	}
 	lndMock.On("BatchOpenChannel", ctx, batchReq).Return("1", nil)
 
-	manager := NewManager(config, lndMock)
+	dataDir := t.TempDir()
+	manager := NewManager(config, dataDir, lndMock, nil)
 
 	err = manager.Open(ctx, req)
 	assert.NoError(t, err)
+	lndMock.AssertCalled(t, "ConnectPeer", ctx, publicKey, req.Peers[publicKey])
+
+	actions, err := ReadActions(dataDir)
+	assert.NoError(t, err)
+	assert.Len(t, actions, 1)
+	assert.Equal(t, ActionOpen, actions[0].Type)
+	assert.Equal(t, "1", actions[0].TxID)
+	assert.Equal(t, publicKey, actions[0].PublicKey)
+	assert.Equal(t, uint64(1_000_000), actions[0].Amount)
 }
 
 func TestManagerClose(t *testing.T) {
@@ -95,7 +109,7 @@ func TestManagerClose(t *testing.T) {
 			lndMock := lightning.NewClientMock()
 			lndMock.On("CloseChannel", mock.Anything, closeReq).Return(&mockStream{}, nil)
 
-			manager := NewManager(config, lndMock)
+			manager := NewManager(config, "", lndMock, nil)
 
 			err = manager.Close(ctx, req)
 			assert.NoError(t, err)
@@ -106,25 +120,41 @@ func TestManagerClose(t *testing.T) {
 func TestManagerUpdatePolicy(t *testing.T) {
 	ctx := t.Context()
 	config := config.ChannelManager{}
-	channelPoint := "e5b8ccc43b4eea6e2664a843e27d82c6d71d2885e7aef73777dd35c737c1d7bc:1"
-	baseFeeMsat := uint64(0)
-	feeRatePPM := uint64(20)
-	maxHTLCMsat := uint64(1_000_000)
-	timeLockDelta := uint64(80)
+	req := UpdatePolicyRequest{
+		ChannelPoint:       "e5b8ccc43b4eea6e2664a843e27d82c6d71d2885e7aef73777dd35c737c1d7bc:1",
+		BaseFeeMsat:        0,
+		FeeRatePPM:         20,
+		MaxHTLCMsat:        1_000_000,
+		TimeLockDelta:      80,
+		InboundBaseFeeMsat: -100,
+		InboundFeeRatePPM:  -10,
+	}
 
 	lndMock := lightning.NewClientMock()
-	lndMock.On("UpdateChannelPolicy", ctx, channelPoint, baseFeeMsat, feeRatePPM, maxHTLCMsat, timeLockDelta).Return(nil)
-	manager := NewManager(config, lndMock)
-
-	req := UpdatePolicyRequest{
-		ChannelPoint:  channelPoint,
-		BaseFeeMsat:   baseFeeMsat,
-		FeeRatePPM:    feeRatePPM,
-		MaxHTLCMsat:   maxHTLCMsat,
-		TimeLockDelta: timeLockDelta,
+	policyReq := &lnrpc.PolicyUpdateRequest{
+		Scope: &lnrpc.PolicyUpdateRequest_ChanPoint{
+			ChanPoint: &lnrpc.ChannelPoint{
+				FundingTxid: &lnrpc.ChannelPoint_FundingTxidStr{
+					FundingTxidStr: "e5b8ccc43b4eea6e2664a843e27d82c6d71d2885e7aef73777dd35c737c1d7bc",
+				},
+				OutputIndex: 1,
+			},
+		},
+		BaseFeeMsat:   0,
+		FeeRatePpm:    20,
+		MaxHtlcMsat:   1_000_000,
+		TimeLockDelta: 80,
+		InboundFee: &lnrpc.InboundFee{
+			BaseFeeMsat: -100,
+			FeeRatePpm:  -10,
+		},
 	}
+	lndMock.On("UpdateChannelPolicy", ctx, policyReq).Return(nil)
+	manager := NewManager(config, "", lndMock, nil)
+
 	err := manager.UpdatePolicy(ctx, req)
 	assert.NoError(t, err)
+	lndMock.AssertExpectations(t)
 }
 
 type mockStream struct{}
@@ -11,7 +11,14 @@ func NewCmd() *cobra.Command {
 		Short: "Agent operations",
 	}
 
-	cmd.AddCommand(NewRunCmd())
+	cmd.AddCommand(
+		NewRunCmd(),
+		NewStatusCmd(),
+		NewTriggerCmd(),
+		NewPauseCmd(),
+		NewResumeCmd(),
+		NewDryRunCmd(),
+	)
 
 	return cmd
 }
@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aftermath2/hydrus/api"
+	"github.com/aftermath2/hydrus/cmd"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/logger"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewDryRunCmd returns a new dryrun command.
+func NewDryRunCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "dryrun <true|false>",
+		Short: "Enable or disable the dry run mode of the running agent",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			enabled, err := strconv.ParseBool(args[0])
+			if err != nil {
+				return errors.Wrap(err, "parsing dry run value")
+			}
+
+			run := cmd.RunAPI(func(ctx context.Context, _ *config.Config, client *api.Client, logger logger.Logger) error {
+				if err := client.SetDryRun(ctx, enabled); err != nil {
+					return errors.Wrap(err, "setting dry run")
+				}
+
+				logger.Infof("Dry run set to %t", enabled)
+				return nil
+			})
+			return run(c, args)
+		},
+	}
+}
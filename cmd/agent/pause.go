@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/aftermath2/hydrus/api"
+	"github.com/aftermath2/hydrus/cmd"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/logger"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewPauseCmd returns a new pause command.
+func NewPauseCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pause",
+		Short: "Pause the scheduled executions of the running agent",
+		RunE: cmd.RunAPI(func(ctx context.Context, _ *config.Config, client *api.Client, logger logger.Logger) error {
+			if err := client.Pause(ctx); err != nil {
+				return errors.Wrap(err, "pausing agent")
+			}
+
+			logger.Info("Agent paused")
+			return nil
+		}),
+	}
+}
+
+// NewResumeCmd returns a new resume command.
+func NewResumeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume",
+		Short: "Resume the scheduled executions of the running agent",
+		RunE: cmd.RunAPI(func(ctx context.Context, _ *config.Config, client *api.Client, logger logger.Logger) error {
+			if err := client.Resume(ctx); err != nil {
+				return errors.Wrap(err, "resuming agent")
+			}
+
+			logger.Info("Agent resumed")
+			return nil
+		}),
+	}
+}
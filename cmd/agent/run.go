@@ -2,6 +2,9 @@ package agent
 
 import (
 	"context"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/aftermath2/hydrus/agent"
 	"github.com/aftermath2/hydrus/cmd"
@@ -9,6 +12,7 @@ import (
 	"github.com/aftermath2/hydrus/lightning"
 	"github.com/aftermath2/hydrus/logger"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -17,9 +21,70 @@ func NewRunCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "run",
 		Short: "Run the agent, executing channels and routing policies evaluations on intervals",
-		RunE: cmd.Run(func(ctx context.Context, config *config.Config, lnd lightning.Client, _ logger.Logger) error {
-			agent := agent.New(config.Agent, lnd)
-			return agent.Run(ctx)
-		}),
+		Long: "Run the agent, executing channels and routing policies evaluations on intervals.\n\n" +
+			"Sending a SIGHUP signal to the process reloads the configuration file.",
+		RunE: func(command *cobra.Command, args []string) error {
+			configPath := command.InheritedFlags().Lookup("config").Value.String()
+
+			run := cmd.Run(func(ctx context.Context, config *config.Config, lnd lightning.Client, logger logger.Logger) error {
+				agent := agent.New(config.Agent, lnd)
+
+				hangup := make(chan os.Signal, 1)
+				signal.Notify(hangup, syscall.SIGHUP)
+				defer signal.Stop(hangup)
+
+				go reloadOnHangup(ctx, hangup, configPath, config, agent, logger)
+				return agent.Run(ctx)
+			})
+
+			return run(command, args)
+		},
+	}
+}
+
+// reloadOnHangup reloads the agent configuration every time a hangup signal is received. Invalid
+// configurations are rejected and the agent keeps running with the previous one.
+func reloadOnHangup(
+	ctx context.Context,
+	hangup <-chan os.Signal,
+	path string,
+	current *config.Config,
+	agent agent.Agent,
+	logger logger.Logger,
+) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			logger.Info("Hangup signal received, reloading configuration")
+
+			config, err := reload(path, current, agent)
+			if err != nil {
+				logger.Errorf("Reloading configuration: %v", err)
+				continue
+			}
+
+			current = config
+		}
 	}
 }
+
+func reload(path string, current *config.Config, agent agent.Agent) (*config.Config, error) {
+	// The logging level is applied only once the agent accepted the new configuration
+	config, err := config.Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Lightning != current.Lightning {
+		return nil, errors.New("changing the lightning configuration requires a restart")
+	}
+
+	if err := agent.Reload(config.Agent); err != nil {
+		return nil, err
+	}
+
+	config.SetLoggingLevel()
+	return config, nil
+}
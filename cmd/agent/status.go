@@ -0,0 +1,36 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aftermath2/hydrus/api"
+	"github.com/aftermath2/hydrus/cmd"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/logger"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewStatusCmd returns a new status command.
+func NewStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the status of the running agent and the results of its last runs",
+		RunE: cmd.RunAPI(func(ctx context.Context, _ *config.Config, client *api.Client, logger logger.Logger) error {
+			status, err := client.Status(ctx)
+			if err != nil {
+				return errors.Wrap(err, "getting agent status")
+			}
+
+			statusB, err := json.Marshal(status)
+			if err != nil {
+				return errors.Wrap(err, "encoding agent status")
+			}
+
+			logger.Infof("Status: %s", statusB)
+			return nil
+		}),
+	}
+}
@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/aftermath2/hydrus/api"
+	"github.com/aftermath2/hydrus/cmd"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/logger"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewTriggerCmd returns a new trigger command.
+func NewTriggerCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:       "trigger <channels|routing_policies>",
+		Short:     "Execute a task of the running agent immediately",
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"channels", "routing_policies"},
+		RunE: func(c *cobra.Command, args []string) error {
+			run := cmd.RunAPI(func(ctx context.Context, _ *config.Config, client *api.Client, logger logger.Logger) error {
+				if err := client.RunTask(ctx, args[0]); err != nil {
+					return errors.Wrapf(err, "triggering %s task", args[0])
+				}
+
+				logger.Infof("Task %q triggered", args[0])
+				return nil
+			})
+			return run(c, args)
+		},
+	}
+}
@@ -0,0 +1,19 @@
+package backtest
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewCmd returns a new backtest command.
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backtest",
+		Short: "Evaluate strategies against the node's history",
+	}
+
+	cmd.AddCommand(
+		NewFeesCmd(),
+	)
+
+	return cmd
+}
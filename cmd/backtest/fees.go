@@ -0,0 +1,200 @@
+package backtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/aftermath2/hydrus/agent"
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/backtest"
+	"github.com/aftermath2/hydrus/cmd"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/lightning"
+	"github.com/aftermath2/hydrus/logger"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// NewFeesCmd returns a new backtest fees command.
+func NewFeesCmd() *cobra.Command {
+	var (
+		balancesPath  string
+		forwardsPath  string
+		strategy      string
+		elasticity    float64
+		depletedRatio float64
+	)
+
+	command := &cobra.Command{
+		Use:   "fees",
+		Short: "Replay a fee strategy over the forwarding history and compare it with the actual results",
+		RunE: cmd.Run(func(ctx context.Context, config *config.Config, lnd lightning.Client, logger logger.Logger) error {
+			if strategy != "" {
+				// Evaluate the strategy on all the channels
+				config.Agent.Fees.Name = strategy
+				config.Agent.Fees.Channels = nil
+				for i := range config.Agent.Tags {
+					config.Agent.Tags[i].Fees = nil
+				}
+				if err := config.Validate(); err != nil {
+					return errors.Wrap(err, "invalid strategy")
+				}
+			}
+
+			if balancesPath == "" {
+				balancesPath = filepath.Join(config.Agent.DataDir, agent.BalanceSnapshotsFile)
+			}
+
+			snapshots, err := agent.ReadBalanceSnapshots(balancesPath)
+			if err != nil {
+				return err
+			}
+
+			if len(snapshots) < 2 {
+				logger.Info("Not enough balance snapshots recorded, at least two routing policies runs are required")
+				return nil
+			}
+
+			forwards, err := getForwards(ctx, lnd, forwardsPath, snapshots[0].Time)
+			if err != nil {
+				return err
+			}
+
+			tagger, err := getTagger(ctx, lnd, config.Agent, snapshots)
+			if err != nil {
+				return err
+			}
+
+			result, err := backtest.Run(snapshots, forwards, backtest.Options{
+				Fees:               config.Agent.Fees,
+				Tags:               config.Agent.Tags,
+				Tagger:             tagger,
+				Interval:           config.Agent.Intervals.RoutingPolicies,
+				InitialFeeRatePPM:  config.Agent.ChannelManager.FeeRatePPM,
+				InitialBaseFeeMsat: config.Agent.ChannelManager.BaseFeeMsat,
+				Elasticity:         elasticity,
+				DepletedRatio:      depletedRatio,
+			})
+			if err != nil {
+				return errors.Wrap(err, "running backtest")
+			}
+
+			logger.Infof("Backtest from %s to %s with %d forwards",
+				result.Start.Format(time.RFC3339),
+				result.End.Format(time.RFC3339),
+				len(forwards),
+			)
+			for _, channel := range result.Channels {
+				logger.Infof(
+					"Channel %s: revenue %d msat (actual %d msat), depleted %.1f%% of the time (actual %.1f%%), "+
+						"missed forwards %d",
+					channel.Point,
+					channel.SimulatedRevenueMsat,
+					channel.ActualRevenueMsat,
+					channel.SimulatedDepleted*100,
+					channel.ActualDepleted*100,
+					channel.MissedForwards,
+				)
+			}
+			logger.Infof("Total revenue %d msat (actual %d msat)",
+				result.SimulatedRevenueMsat,
+				result.ActualRevenueMsat,
+			)
+			return nil
+		}),
+	}
+
+	flags := command.Flags()
+	flags.StringVar(&balancesPath, "balances", "",
+		"Path to the balance snapshots file, defaults to the one recorded in the data directory")
+	flags.StringVar(&forwardsPath, "forwards", "",
+		"Path to a forwarding history exported with 'lncli fwdinghistory', fetched from the node if empty")
+	flags.StringVar(&strategy, "strategy", "", "Fee strategy evaluated on all channels instead of the configured ones")
+	flags.Float64Var(&elasticity, "elasticity", 1,
+		"Sensitivity of the forwards to fee rates higher than the ones actually charged")
+	flags.Float64Var(&depletedRatio, "depleted-ratio", 0.05,
+		"Local balance ratio below which a channel is considered depleted")
+
+	return command
+}
+
+// getForwards returns the forwards from the file exported from LND or, if no path is specified, fetches the
+// ones that took place since the start time from the node.
+func getForwards(
+	ctx context.Context,
+	lnd lightning.Client,
+	path string,
+	start time.Time,
+) ([]*lnrpc.ForwardingEvent, error) {
+	if path == "" {
+		forwards, err := local.ListForwards(ctx, lnd, 0, uint64(start.Unix()), 0)
+		if err != nil {
+			return nil, errors.Wrap(err, "listing forwards")
+		}
+		return forwards, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading forwarding history file")
+	}
+
+	var history lnrpc.ForwardingHistoryResponse
+	if err := protojson.Unmarshal(data, &history); err != nil {
+		return nil, errors.Wrap(err, "decoding forwarding history")
+	}
+
+	return history.ForwardingEvents, nil
+}
+
+// getTagger returns the tagger of the channels if any tag sets a fee strategy, filling the peers of the
+// channels in the snapshots recorded without them.
+func getTagger(
+	ctx context.Context,
+	lnd lightning.Client,
+	agentConfig config.Agent,
+	snapshots []agent.BalanceSnapshot,
+) (*agent.Tagger, error) {
+	if !slices.ContainsFunc(agentConfig.Tags, func(tag config.Tag) bool { return tag.Fees != nil }) {
+		return nil, nil
+	}
+
+	channelGraph, err := lnd.DescribeGraph(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting channel graph")
+	}
+
+	channels, err := lnd.ListChannels(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing channels")
+	}
+
+	closedChannels, err := lnd.ClosedChannels(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing closed channels")
+	}
+
+	peers := make(map[string]string, len(channels)+len(closedChannels))
+	for _, channel := range channels {
+		peers[channel.ChannelPoint] = channel.RemotePubkey
+	}
+	for _, channel := range closedChannels {
+		peers[channel.ChannelPoint] = channel.RemotePubkey
+	}
+
+	for _, snapshot := range snapshots {
+		for i, channel := range snapshot.Channels {
+			if channel.RemotePublicKey == "" {
+				snapshot.Channels[i].RemotePublicKey = peers[channel.Point]
+			}
+		}
+	}
+
+	return agent.NewTagger(agentConfig, channelGraph), nil
+}
@@ -2,6 +2,11 @@ package channels
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"text/tabwriter"
 
 	"github.com/aftermath2/hydrus/agent"
 	"github.com/aftermath2/hydrus/agent/local"
@@ -15,9 +20,17 @@ import (
 
 // NewCloseCmd returns a new run command.
 func NewCloseCmd() *cobra.Command {
-	return &cobra.Command{
+	var (
+		points []string
+		yes    bool
+	)
+
+	command := &cobra.Command{
 		Use:   "close",
 		Short: "Evaluate local channels to close and create the closing transactions",
+		Long: "Evaluate local channels to close and create the closing transactions.\n\n" +
+			"With --point, close the channels chosen instead, after checking them with the same rules the agent " +
+			"applies to its candidates.",
 		RunE: cmd.Run(func(ctx context.Context, config *config.Config, lnd lightning.Client, logger logger.Logger) error {
 			localNode, err := local.GetNode(ctx, config.Agent, lnd)
 			if err != nil {
@@ -25,6 +38,11 @@ func NewCloseCmd() *cobra.Command {
 			}
 			logger.Debugf("Local node: %s", localNode)
 
+			agent := agent.New(config.Agent, lnd)
+			if len(points) > 0 {
+				return closeManual(ctx, config.Agent, lnd, logger, agent, localNode, points, yes)
+			}
+
 			if localNode.SatvB > config.Agent.ChannelManager.MaxSatvB {
 				logger.Infof(
 					"Skipping... The estimated transaction fee per virtual byte (%d) is higher than the maximum (%d)",
@@ -35,8 +53,59 @@ func NewCloseCmd() *cobra.Command {
 			}
 
 			logger.Info("Evaluating channels to close")
-			agent := agent.New(config.Agent, lnd)
 			return agent.CloseChannels(ctx, localNode)
 		}),
 	}
+
+	flags := command.Flags()
+	flags.StringArrayVar(&points, "point", nil, "Close the channel with the channel point specified, can be repeated")
+	flags.BoolVarP(&yes, "yes", "y", false, "Skip the confirmation")
+
+	return command
+}
+
+func closeManual(
+	ctx context.Context,
+	config config.Agent,
+	lnd lightning.Client,
+	logger logger.Logger,
+	planner agent.Agent,
+	localNode local.Node,
+	points []string,
+	yes bool,
+) error {
+	req, err := planner.PlanClose(ctx, localNode, points)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHANNEL POINT\tFORCE CLOSE")
+	for _, point := range slices.Sorted(maps.Keys(req.Channels)) {
+		fmt.Fprintf(tw, "%s\t%t\n", point, req.Channels[point])
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("Closing transactions fee: %d sat/vB\n", req.SatvB)
+
+	if config.DryRun {
+		logger.Info("Dry run mode is enabled, the channels won't be closed")
+		return nil
+	}
+
+	if !yes {
+		ok, err := confirm(os.Stdin, os.Stdout, "Close the channels?")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			logger.Info("Aborted")
+			return nil
+		}
+	}
+
+	manager, dispatcher := newChannelManager(config, lnd)
+	defer dispatcher.Wait()
+	return manager.Close(ctx, req)
 }
@@ -0,0 +1,63 @@
+package channels
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/aftermath2/hydrus/channel"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/lightning"
+	"github.com/aftermath2/hydrus/notifier"
+
+	"github.com/pkg/errors"
+)
+
+// parsePeers parses a list of <public_key>=<amount> values.
+func parsePeers(values []string) (map[string]uint64, error) {
+	peers := make(map[string]uint64, len(values))
+	for _, value := range values {
+		publicKey, amountStr, ok := strings.Cut(value, "=")
+		if !ok || publicKey == "" {
+			return nil, errors.Errorf("invalid peer %q, the format is <public_key>=<amount>", value)
+		}
+
+		amount, err := strconv.ParseUint(amountStr, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid amount for peer %q", publicKey)
+		}
+
+		if _, ok := peers[publicKey]; ok {
+			return nil, errors.Errorf("peer %q specified more than once", publicKey)
+		}
+		peers[publicKey] = amount
+	}
+
+	return peers, nil
+}
+
+// confirm asks the question and returns whether the answer is affirmative.
+func confirm(r io.Reader, w io.Writer, question string) (bool, error) {
+	fmt.Fprintf(w, "%s [y/N]: ", question)
+
+	answer, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, errors.Wrap(err, "reading answer")
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// newChannelManager returns a channel manager and its notifications dispatcher, which must be waited for
+// before exiting so the notifications are delivered.
+func newChannelManager(config config.Agent, lnd lightning.Client) (channel.Manager, *notifier.Dispatcher) {
+	dispatcher := notifier.NewDispatcher(config.Notifiers)
+	return channel.NewManager(config.ChannelManager, config.DataDir, lnd, dispatcher), dispatcher
+}
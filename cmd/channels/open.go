@@ -2,6 +2,11 @@ package channels
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"text/tabwriter"
 
 	"github.com/aftermath2/hydrus/agent"
 	"github.com/aftermath2/hydrus/agent/local"
@@ -10,21 +15,46 @@ import (
 	"github.com/aftermath2/hydrus/lightning"
 	"github.com/aftermath2/hydrus/logger"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
 // NewOpenCmd returns a new run command.
 func NewOpenCmd() *cobra.Command {
-	return &cobra.Command{
+	var (
+		peers      []string
+		candidates int
+		yes        bool
+	)
+
+	command := &cobra.Command{
 		Use:   "open",
 		Short: "Evaluate nodes to connect to and create the funding transaction",
+		Long: "Evaluate nodes to connect to and create the funding transaction.\n\n" +
+			"With --peer, open channels to the nodes chosen instead, after checking them with the same rules " +
+			"the agent applies to its candidates.",
 		RunE: cmd.Run(func(ctx context.Context, config *config.Config, lnd lightning.Client, logger logger.Logger) error {
+			if len(peers) == 0 && candidates > 0 {
+				return errors.New("candidates can only be added to the channels chosen with --peer")
+			}
+
+			manualPeers, err := parsePeers(peers)
+			if err != nil {
+				return err
+			}
+			manual := agent.ManualOpen{Peers: manualPeers, Candidates: candidates}
+
 			localNode, err := local.GetNode(ctx, config.Agent, lnd)
 			if err != nil {
 				return err
 			}
 			logger.Debugf("Local node: %s", localNode)
 
+			agent := agent.New(config.Agent, lnd)
+			if len(peers) > 0 {
+				return openManual(ctx, config.Agent, lnd, logger, agent, localNode, manual, yes)
+			}
+
 			if localNode.SatvB > config.Agent.ChannelManager.MaxSatvB {
 				logger.Infof(
 					"Skipping... The estimated transaction fee per virtual byte (%d) is higher than the maximum (%d)",
@@ -35,8 +65,69 @@ func NewOpenCmd() *cobra.Command {
 			}
 
 			logger.Info("Evaluating channels to open")
-			agent := agent.New(config.Agent, lnd)
 			return agent.OpenChannels(ctx, localNode)
 		}),
 	}
+
+	flags := command.Flags()
+	flags.StringArrayVar(&peers, "peer", nil,
+		"Open a channel to the node with the amount specified as <public_key>=<amount>, can be repeated")
+	flags.IntVar(&candidates, "candidates", 0,
+		"Maximum number of the agent's top candidates to add to the batch opening the channels chosen")
+	flags.BoolVarP(&yes, "yes", "y", false, "Skip the confirmation")
+
+	return command
+}
+
+func openManual(
+	ctx context.Context,
+	config config.Agent,
+	lnd lightning.Client,
+	logger logger.Logger,
+	planner agent.Agent,
+	localNode local.Node,
+	manual agent.ManualOpen,
+	yes bool,
+) error {
+	req, err := planner.PlanOpen(ctx, localNode, manual)
+	if err != nil {
+		return err
+	}
+
+	total := uint64(0)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PUBLIC KEY\tAMOUNT\tSOURCE")
+	for _, publicKey := range slices.Sorted(maps.Keys(req.Nodes)) {
+		source := "agent"
+		if _, ok := manual.Peers[publicKey]; ok {
+			source = "manual"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", publicKey, req.Nodes[publicKey], source)
+		total += req.Nodes[publicKey]
+	}
+	fmt.Fprintf(tw, "Total\t%d\t\n", total)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("Funding transaction fee: %d sat/vB\n", req.SatvB)
+
+	if config.DryRun {
+		logger.Info("Dry run mode is enabled, the channels won't be opened")
+		return nil
+	}
+
+	if !yes {
+		ok, err := confirm(os.Stdin, os.Stdout, "Open the channels?")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			logger.Info("Aborted")
+			return nil
+		}
+	}
+
+	manager, dispatcher := newChannelManager(config, lnd)
+	defer dispatcher.Wait()
+	return manager.Open(ctx, req)
 }
@@ -2,6 +2,10 @@ package channels
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/aftermath2/hydrus/agent"
 	"github.com/aftermath2/hydrus/agent/local"
@@ -15,7 +19,9 @@ import (
 
 // NewUpdateCmd returns a new run command.
 func NewUpdateCmd() *cobra.Command {
-	return &cobra.Command{
+	var explain bool
+
+	command := &cobra.Command{
 		Use:   "updatepolicies",
 		Short: "Evaluate local channels and update their routing policies",
 		RunE: cmd.Run(func(ctx context.Context, config *config.Config, lnd lightning.Client, logger logger.Logger) error {
@@ -25,9 +31,41 @@ func NewUpdateCmd() *cobra.Command {
 			}
 			logger.Debugf("Local node: %s", localNode)
 
-			logger.Info("Updating channels routing policies")
 			agent := agent.New(config.Agent, lnd)
+			if explain {
+				return explainPolicies(ctx, agent, localNode)
+			}
+
+			logger.Info("Updating channels routing policies")
 			return agent.UpdatePolicies(ctx, localNode)
 		}),
 	}
+
+	command.Flags().BoolVar(&explain, "explain", false,
+		"Show the routing policy rule matching each channel without updating them")
+
+	return command
+}
+
+func explainPolicies(ctx context.Context, planner agent.Agent, localNode local.Node) error {
+	matches, err := planner.ExplainPolicies(ctx, localNode)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHANNEL POINT\tPEER\tTAGS\tRULE")
+	for _, match := range matches {
+		rule := match.Rule
+		if rule == "" {
+			rule = fmt.Sprintf("none, %s fee strategy", match.FeeStrategy)
+		}
+		tags := strings.Join(match.Tags, ",")
+		if tags == "" {
+			tags = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", match.ChannelPoint, match.RemotePublicKey, tags, rule)
+	}
+
+	return tw.Flush()
 }
@@ -3,10 +3,12 @@ package cmd
 import (
 	"context"
 
+	"github.com/aftermath2/hydrus/api"
 	"github.com/aftermath2/hydrus/config"
 	"github.com/aftermath2/hydrus/lightning"
 	"github.com/aftermath2/hydrus/logger"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -33,3 +35,22 @@ func Run(f func(ctx context.Context, config *config.Config, lnd lightning.Client
 		return f(cmd.Context(), config, lnd, logger)
 	}
 }
+
+// RunAPI is like Run but provides a client of the running agent's API instead of a Lightning node client.
+func RunAPI(f func(ctx context.Context, config *config.Config, client *api.Client, logger logger.Logger) error) RunE {
+	return func(cmd *cobra.Command, _ []string) error {
+		configPath := cmd.InheritedFlags().Lookup("config")
+
+		config, err := config.Load(configPath.Value.String())
+		if err != nil {
+			return err
+		}
+
+		if !config.Agent.API.Enabled() {
+			return errors.New("the agent API is not enabled in the configuration")
+		}
+
+		logger := logger.New("CMD")
+		return f(cmd.Context(), config, api.NewClient(config.Agent.API), logger)
+	}
+}
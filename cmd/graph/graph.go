@@ -0,0 +1,19 @@
+package graph
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewCmd returns a new graph command.
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Network graph information",
+	}
+
+	cmd.AddCommand(
+		NewStatsCmd(),
+	)
+
+	return cmd
+}
@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/aftermath2/hydrus/cmd"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/graph"
+	"github.com/aftermath2/hydrus/lightning"
+	"github.com/aftermath2/hydrus/logger"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewStatsCmd returns a new graph stats command.
+func NewStatsCmd() *cobra.Command {
+	var output string
+
+	command := &cobra.Command{
+		Use:   "stats",
+		Short: "Show network graph statistics",
+		RunE: cmd.Run(func(ctx context.Context, config *config.Config, lnd lightning.Client, _ logger.Logger) error {
+			if output != "table" && output != "json" {
+				return errors.Errorf("invalid output format %q", output)
+			}
+
+			channelGraph, err := lnd.DescribeGraph(ctx)
+			if err != nil {
+				return errors.Wrap(err, "getting channel graph")
+			}
+
+			networkGraph, err := graph.FromChannelGraph(ctx, config.Agent.HeuristicWeights.Open, channelGraph)
+			if err != nil {
+				return errors.Wrap(err, "creating graph")
+			}
+
+			stats := graph.NewStats(channelGraph, networkGraph)
+			if output == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(stats)
+			}
+
+			return writeStats(os.Stdout, stats)
+		}),
+	}
+
+	command.Flags().StringVarP(&output, "output", "o", "table", "Output format: table or json")
+
+	return command
+}
+
+func writeStats(w io.Writer, stats graph.Stats) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Nodes\t%d\n", stats.Nodes)
+	fmt.Fprintf(tw, "Edges\t%d\n", stats.Edges)
+	fmt.Fprintf(tw, "Edges skipped for missing policies\t%d\n", stats.SkippedEdges)
+	fmt.Fprintf(tw, "Policies discarded\t%d\n", stats.DiscardedPolicies)
+	fmt.Fprintf(tw, "Average node capacity\t%d\n", stats.AvgNodeCapacity)
+	fmt.Fprintf(tw, "Average node channels\t%d\n", stats.AvgNumChannels)
+	fmt.Fprintf(tw, "Filtered nodes\t%d\n", stats.FilteredNodes)
+	fmt.Fprintf(tw, "Filtered edges\t%d\n", stats.FilteredEdges)
+	fmt.Fprintf(tw, "Largest connected component\t%d\n", stats.LargestComponent)
+	fmt.Fprintf(tw, "Diameter (estimate)\t%d\n", stats.Diameter)
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "DISTRIBUTION\tMIN\tP10\tP25\tMEDIAN\tP75\tP90\tMAX\tMEAN")
+	distributions := []struct {
+		name         string
+		distribution graph.Distribution
+	}{
+		{"Channel capacity", stats.Capacity},
+		{"Fee rate (ppm)", stats.FeeRate},
+		{"Degree centrality", stats.Centrality.Degree},
+		{"Betweenness centrality", stats.Centrality.Betweenness},
+		{"Eigenvector centrality", stats.Centrality.Eigenvector},
+		{"Closeness centrality", stats.Centrality.Closeness},
+	}
+	for _, d := range distributions {
+		fmt.Fprintf(tw, "%s\t%.6g\t%.6g\t%.6g\t%.6g\t%.6g\t%.6g\t%.6g\t%.6g\n",
+			d.name,
+			d.distribution.Min,
+			d.distribution.P10,
+			d.distribution.P25,
+			d.distribution.Median,
+			d.distribution.P75,
+			d.distribution.P90,
+			d.distribution.Max,
+			d.distribution.Mean,
+		)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,260 @@
+package report
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/cmd"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/lightning"
+	"github.com/aftermath2/hydrus/logger"
+	"github.com/aftermath2/hydrus/report"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	dateLayout    = time.DateOnly
+	defaultPeriod = 30 * 24 * time.Hour
+)
+
+// NewCmd returns a new report command.
+func NewCmd() *cobra.Command {
+	var (
+		from   string
+		to     string
+		output string
+	)
+
+	command := &cobra.Command{
+		Use:   "report",
+		Short: "Show the routing fees earned and the on-chain and rebalancing costs of each channel in a period",
+		RunE: cmd.Run(func(ctx context.Context, _ *config.Config, lnd lightning.Client, _ logger.Logger) error {
+			if output != "table" && output != "csv" && output != "json" {
+				return errors.Errorf("invalid output format %q", output)
+			}
+
+			start, end, err := parsePeriod(from, to, time.Now())
+			if err != nil {
+				return err
+			}
+
+			in, err := getInput(ctx, lnd, start, end)
+			if err != nil {
+				return err
+			}
+
+			r := report.Build(in)
+			switch output {
+			case "json":
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(r)
+			case "csv":
+				return writeCSV(os.Stdout, r)
+			default:
+				return writeSummary(os.Stdout, r)
+			}
+		}),
+	}
+
+	flags := command.Flags()
+	flags.StringVar(&from, "from", "", "Start date of the period (YYYY-MM-DD), 30 days before the end by default")
+	flags.StringVar(&to, "to", "", "End date of the period (YYYY-MM-DD), excluded, now by default")
+	flags.StringVarP(&output, "output", "o", "table", "Output format: table, csv or json")
+
+	return command
+}
+
+// parsePeriod returns the start and end of the period from the dates specified.
+func parsePeriod(from, to string, now time.Time) (time.Time, time.Time, error) {
+	end := now
+	if to != "" {
+		t, err := time.ParseInLocation(dateLayout, to, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.Wrap(err, "parsing end date")
+		}
+		end = t
+	}
+
+	start := end.Add(-defaultPeriod)
+	if from != "" {
+		t, err := time.ParseInLocation(dateLayout, from, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.Wrap(err, "parsing start date")
+		}
+		start = t
+	}
+
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, errors.New("the start of the period must be before its end")
+	}
+
+	return start, end, nil
+}
+
+func getInput(ctx context.Context, lnd lightning.Client, start, end time.Time) (report.Input, error) {
+	info, err := lnd.GetInfo(ctx)
+	if err != nil {
+		return report.Input{}, errors.Wrap(err, "getting node info")
+	}
+
+	channels, err := lnd.ListChannels(ctx)
+	if err != nil {
+		return report.Input{}, errors.Wrap(err, "listing channels")
+	}
+
+	closedChannels, err := lnd.ClosedChannels(ctx)
+	if err != nil {
+		return report.Input{}, errors.Wrap(err, "listing closed channels")
+	}
+
+	transactions, err := lnd.GetTransactions(ctx)
+	if err != nil {
+		return report.Input{}, errors.Wrap(err, "listing transactions")
+	}
+
+	forwards, err := local.ListForwards(ctx, lnd, 0, uint64(start.Unix()), 0)
+	if err != nil {
+		return report.Input{}, errors.Wrap(err, "listing forwards")
+	}
+
+	payments, err := lnd.ListPayments(ctx, uint64(start.Unix()), uint64(end.Unix()))
+	if err != nil {
+		return report.Input{}, errors.Wrap(err, "listing payments")
+	}
+
+	return report.Input{
+		Start:              start,
+		End:                end,
+		Now:                time.Now(),
+		CurrentBlockHeight: info.BlockHeight,
+		PublicKey:          info.IdentityPubkey,
+		Channels:           channels,
+		ClosedChannels:     closedChannels,
+		Transactions:       transactions,
+		Forwards:           forwards,
+		Payments:           payments,
+	}, nil
+}
+
+func writeSummary(w io.Writer, r report.Report) error {
+	total := r.Total
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Period\t%s - %s (%.1f days)\n",
+		r.Start.Format(time.DateTime),
+		r.End.Format(time.DateTime),
+		total.Days,
+	)
+	fmt.Fprintf(tw, "Channels\t%d, %d sats of capacity\n", len(r.Channels), total.Capacity)
+	fmt.Fprintf(tw, "Routing fees\t%s sats\n", formatSats(int64(total.FeesOutMsat)))
+	fmt.Fprintf(tw, "Open costs\t%s sats\n", formatSats(int64(total.OpenCostMsat)))
+	fmt.Fprintf(tw, "Close costs\t%s sats\n", formatSats(int64(total.CloseCostMsat)))
+	fmt.Fprintf(tw, "Rebalancing costs\t%s sats\n", formatSats(int64(total.RebalanceCostMsat)))
+	fmt.Fprintf(tw, "Net return\t%s sats\n", formatSats(total.NetReturnMsat))
+	fmt.Fprintf(tw, "Annualized yield\t%.4f%%\n", total.AnnualizedYield*100)
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "CHANNEL\tPEER\tSTATUS\tCAPACITY\tDAYS\tFEES IN\tFEES OUT\tOPEN COST\tCLOSE COST\t"+
+		"REBALANCE COST\tNET RETURN\tYIELD")
+	for _, c := range r.Channels {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%.1f\t%s\t%s\t%s\t%s\t%s\t%s\t%.4f%%\n",
+			c.Point,
+			c.RemotePublicKey,
+			status(c),
+			c.Capacity,
+			c.Days,
+			formatSats(int64(c.FeesInMsat)),
+			formatSats(int64(c.FeesOutMsat)),
+			formatSats(int64(c.OpenCostMsat)),
+			formatSats(int64(c.CloseCostMsat)),
+			formatSats(int64(c.RebalanceCostMsat)),
+			formatSats(c.NetReturnMsat),
+			c.AnnualizedYield*100,
+		)
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "Amounts in sats. The net return counts the fees of the forwards leaving through each "+
+		"channel.")
+
+	return tw.Flush()
+}
+
+// writeCSV writes a row per channel followed by the totals, with amounts in millisatoshis.
+func writeCSV(w io.Writer, r report.Report) error {
+	csvWriter := csv.NewWriter(w)
+	header := []string{
+		"channel_point",
+		"channel_id",
+		"remote_public_key",
+		"status",
+		"start",
+		"end",
+		"capacity",
+		"days",
+		"fees_in_msat",
+		"fees_out_msat",
+		"open_cost_msat",
+		"close_cost_msat",
+		"rebalance_cost_msat",
+		"net_return_msat",
+		"annualized_yield",
+	}
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+
+	row := func(point, id, remotePublicKey, status string, s report.Summary) []string {
+		return []string{
+			point,
+			id,
+			remotePublicKey,
+			status,
+			r.Start.Format(time.RFC3339),
+			r.End.Format(time.RFC3339),
+			strconv.FormatUint(s.Capacity, 10),
+			strconv.FormatFloat(s.Days, 'f', 4, 64),
+			strconv.FormatUint(s.FeesInMsat, 10),
+			strconv.FormatUint(s.FeesOutMsat, 10),
+			strconv.FormatUint(s.OpenCostMsat, 10),
+			strconv.FormatUint(s.CloseCostMsat, 10),
+			strconv.FormatUint(s.RebalanceCostMsat, 10),
+			strconv.FormatInt(s.NetReturnMsat, 10),
+			strconv.FormatFloat(s.AnnualizedYield, 'f', 6, 64),
+		}
+	}
+
+	for _, c := range r.Channels {
+		id := strconv.FormatUint(c.ID, 10)
+		if err := csvWriter.Write(row(c.Point, id, c.RemotePublicKey, status(c), c.Summary)); err != nil {
+			return err
+		}
+	}
+	if err := csvWriter.Write(row("total", "", "", "", r.Total)); err != nil {
+		return err
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func status(c report.ChannelReport) string {
+	if c.Closed {
+		return "closed"
+	}
+	return "open"
+}
+
+// formatSats formats an amount of millisatoshis in satoshis.
+func formatSats(msat int64) string {
+	return strconv.FormatFloat(float64(msat)/1000, 'f', 3, 64)
+}
@@ -2,8 +2,13 @@ package root
 
 import (
 	"github.com/aftermath2/hydrus/cmd/agent"
+	"github.com/aftermath2/hydrus/cmd/backtest"
 	"github.com/aftermath2/hydrus/cmd/channels"
+	"github.com/aftermath2/hydrus/cmd/graph"
+	"github.com/aftermath2/hydrus/cmd/report"
 	"github.com/aftermath2/hydrus/cmd/scores"
+	"github.com/aftermath2/hydrus/cmd/simulate"
+	"github.com/aftermath2/hydrus/cmd/status"
 
 	"github.com/spf13/cobra"
 )
@@ -26,8 +31,13 @@ func NewCmd() (*cobra.Command, error) {
 
 	cmd.AddCommand(
 		agent.NewCmd(),
+		backtest.NewCmd(),
 		channels.NewCmd(),
+		graph.NewCmd(),
+		report.NewCmd(),
 		scores.NewCmd(),
+		simulate.NewCmd(),
+		status.NewCmd(),
 	)
 
 	return cmd, nil
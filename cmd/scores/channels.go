@@ -3,9 +3,12 @@ package scores
 import (
 	"context"
 	"encoding/json"
+	"os"
 	"sort"
+	"strconv"
 
 	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/api"
 	"github.com/aftermath2/hydrus/cmd"
 	"github.com/aftermath2/hydrus/config"
 	"github.com/aftermath2/hydrus/lightning"
@@ -16,53 +19,135 @@ import (
 )
 
 type candidateChannel struct {
-	ID           uint64  `json:"id"`
-	ChannelPoint string  `json:"channel_point"`
-	Score        float64 `json:"score"`
+	ID              uint64              `json:"id"`
+	ChannelPoint    string              `json:"channel_point"`
+	RemotePublicKey string              `json:"remote_public_key"`
+	Active          bool                `json:"active"`
+	Capacity        uint64              `json:"capacity"`
+	LocalBalance    uint64              `json:"local_balance"`
+	Score           float64             `json:"score"`
+	Profitability   local.Profitability `json:"profitability"`
+	Components      components          `json:"components"`
+}
+
+func (c candidateChannel) header() []string {
+	header := []string{
+		"id",
+		"channel_point",
+		"remote_public_key",
+		"active",
+		"capacity",
+		"local_balance",
+		"score",
+		"fees_per_day",
+		"capital_cost_per_day",
+		"close_reopen_cost",
+		"expected_return",
+	}
+	return append(header, c.Components.columns()...)
+}
+
+func (c candidateChannel) values() []string {
+	values := []string{
+		strconv.FormatUint(c.ID, 10),
+		c.ChannelPoint,
+		c.RemotePublicKey,
+		strconv.FormatBool(c.Active),
+		strconv.FormatUint(c.Capacity, 10),
+		strconv.FormatUint(c.LocalBalance, 10),
+		formatFloat(c.Score),
+		formatFloat(c.Profitability.FeesPerDay),
+		formatFloat(c.Profitability.CapitalCostPerDay),
+		strconv.FormatUint(c.Profitability.CloseReopenCost, 10),
+		formatFloat(c.Profitability.ExpectedReturn),
+	}
+	return append(values, c.Components.values()...)
+}
+
+func (c candidateChannel) score() float64 {
+	return c.Score
+}
+
+func (c candidateChannel) components() components {
+	return c.Components
 }
 
 // NewChannelsCmd returns a new scores channels command.
 func NewChannelsCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "channels",
-		Short: "Show local channels scores",
-		RunE: cmd.Run(func(ctx context.Context, config *config.Config, lnd lightning.Client, logger logger.Logger) error {
-			localNode, err := local.GetNode(ctx, config.Agent, lnd)
-			if err != nil {
-				return errors.Wrap(err, "getting local node")
-			}
+	var (
+		fromAgent bool
+		opts      outputOptions
+	)
 
-			if len(localNode.Channels.List) == 0 {
-				logger.Info("The node has no channels")
-				return nil
-			}
+	run := cmd.Run(func(ctx context.Context, config *config.Config, lnd lightning.Client, logger logger.Logger) error {
+		localNode, err := local.GetNode(ctx, config.Agent, lnd)
+		if err != nil {
+			return errors.Wrap(err, "getting local node")
+		}
 
-			heu, err := json.Marshal(localNode.Channels.Heuristics)
-			if err != nil {
-				return errors.Wrap(err, "parsing channels heuristics")
-			}
-			logger.Infof("Local node channels heuristics: %s", heu)
-
-			candidates := make([]candidateChannel, 0, len(localNode.Channels.List))
-			for _, channel := range localNode.Channels.List {
-				candidates = append(candidates, candidateChannel{
-					ID:           channel.ID,
-					ChannelPoint: channel.Point,
-					Score:        localNode.Channels.Heuristics.GetScore(channel),
-				})
-			}
+		if len(localNode.Channels.List) == 0 {
+			logger.Info("The node has no channels")
+			return nil
+		}
 
-			sort.Slice(candidates, func(i, j int) bool {
-				return candidates[i].Score < candidates[j].Score
+		heu, err := json.Marshal(localNode.Channels.Heuristics)
+		if err != nil {
+			return errors.Wrap(err, "parsing channels heuristics")
+		}
+		logger.Debugf("Local node channels heuristics: %s", heu)
+
+		candidates := make([]candidateChannel, 0, len(localNode.Channels.List))
+		for _, channel := range localNode.Channels.List {
+			candidates = append(candidates, candidateChannel{
+				ID:              channel.ID,
+				ChannelPoint:    channel.Point,
+				RemotePublicKey: channel.RemotePublicKey,
+				Active:          channel.Active,
+				Capacity:        channel.Capacity,
+				LocalBalance:    channel.LocalBalance,
+				Score:           localNode.Channels.Heuristics.GetScore(channel),
+				Profitability:   channel.GetProfitability(config.Agent.Closing.Profitability, localNode.SatvB),
+				Components:      localNode.Channels.Heuristics.Explain(channel),
 			})
+		}
 
-			encCandidates, err := json.Marshal(candidates)
-			if err != nil {
-				return errors.Wrap(err, "encoding candidates list")
-			}
+		sortCandidateChannels(candidates, config.Agent.Closing.Mode)
 
-			logger.Infof("Scores: %s", encCandidates)
-			return nil
-		}),
+		candidates, err = selectResults(candidates, opts)
+		if err != nil {
+			return err
+		}
+
+		return writeResults(os.Stdout, opts.format, candidates)
+	})
+
+	command := &cobra.Command{
+		Use:   "channels",
+		Short: "Show local channels scores",
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := opts.validate(); err != nil {
+				return err
+			}
+			if fromAgent {
+				pick := func(r api.Rankings) api.Ranking { return r.Channels }
+				return cmd.RunAPI(printAgentRanking(opts, pick))(c, args)
+			}
+			return run(c, args)
+		},
 	}
+
+	command.Flags().BoolVar(&fromAgent, "agent", false, "Get the scores evaluated by the running agent")
+	opts.addFlags(command)
+
+	return command
+}
+
+// sortCandidateChannels sorts the channels from the worst to the best according to the closing mode.
+func sortCandidateChannels(candidates []candidateChannel, closingMode string) {
+	sort.Slice(candidates, func(i, j int) bool {
+		if closingMode == config.ClosingModeProfitability {
+			return candidates[i].Profitability.ExpectedReturn < candidates[j].Profitability.ExpectedReturn
+		}
+		return candidates[i].Score < candidates[j].Score
+	})
 }
@@ -0,0 +1,149 @@
+package scores
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/aftermath2/hydrus/agent"
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/cmd"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/graph"
+	"github.com/aftermath2/hydrus/heuristic"
+	"github.com/aftermath2/hydrus/lightning"
+	"github.com/aftermath2/hydrus/logger"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewExplainCmd returns a new scores explain command.
+func NewExplainCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "explain <public_key|channel_point>",
+		Short: "Show how the score of a node or a local channel is composed",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			target := args[0]
+			run := cmd.Run(func(ctx context.Context, config *config.Config, lnd lightning.Client, logger logger.Logger) error {
+				localNode, err := local.GetNode(ctx, config.Agent, lnd)
+				if err != nil {
+					return errors.Wrap(err, "getting local node")
+				}
+
+				if strings.Contains(target, ":") {
+					return explainChannel(logger, localNode, target)
+				}
+
+				return explainNode(ctx, logger, config.Agent, lnd, localNode, target)
+			})
+			return run(c, args)
+		},
+	}
+}
+
+func explainNode(
+	ctx context.Context,
+	logger logger.Logger,
+	config config.Agent,
+	lnd lightning.Client,
+	localNode local.Node,
+	publicKey string,
+) error {
+	channelGraph, err := lnd.DescribeGraph(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting channel graph")
+	}
+
+	networkGraph, err := graph.FromChannelGraph(ctx, config.HeuristicWeights.Open, channelGraph)
+	if err != nil {
+		return errors.Wrap(err, "creating graph")
+	}
+
+	node, ok := networkGraph.GetNode(publicKey)
+	if ok {
+		logger.Infof("Node %s (%s): score %.3f, rank %d of %d",
+			node.Alias,
+			node.PublicKey,
+			networkGraph.Heuristics.GetScore(node),
+			networkGraph.Rank(node),
+			len(networkGraph.Nodes),
+		)
+		printComponents(logger, networkGraph.Heuristics.Explain(node))
+	} else {
+		logger.Infof("Node %s is not part of the graph used for scoring:", publicKey)
+		for _, reason := range graph.ExclusionReasons(channelGraph, publicKey) {
+			logger.Infof("  - %s", reason)
+		}
+
+		// Rebuild the node from the channel graph so the tags see its alias, capacity and channels
+		if node, ok = agent.FindNode(channelGraph, networkGraph, publicKey); !ok {
+			node.PublicKey = publicKey
+		}
+	}
+
+	reasons := agent.DiscardReasons(localNode, node, agent.NewTagger(config, channelGraph))
+	if len(reasons) == 0 {
+		logger.Info("The node is not discarded as a candidate")
+		return nil
+	}
+
+	logger.Info("The node is discarded as a candidate:")
+	for _, reason := range reasons {
+		logger.Infof("  - %s", reason)
+	}
+	return nil
+}
+
+func explainChannel(logger logger.Logger, localNode local.Node, channelPoint string) error {
+	channels := localNode.Channels
+	i := slices.IndexFunc(channels.List, func(channel local.Channel) bool {
+		return channel.Point == channelPoint
+	})
+	if i == -1 {
+		return errors.Errorf("channel %q not found", channelPoint)
+	}
+
+	scores := make([]float64, 0, len(channels.List))
+	for _, channel := range channels.List {
+		scores = append(scores, channels.Heuristics.GetScore(channel))
+	}
+
+	channel := channels.List[i]
+	score := channels.Heuristics.GetScore(channel)
+	logger.Infof("Channel %s (%d): score %.3f, rank %d of %d",
+		channel.Point,
+		channel.ID,
+		score,
+		getRank(scores, score),
+		len(scores),
+	)
+	printComponents(logger, channels.Heuristics.Explain(channel))
+	return nil
+}
+
+func printComponents(logger logger.Logger, components []heuristic.Component) {
+	for _, c := range components {
+		logger.Infof("  %-30s value %-14.6g lowest %-14.6g highest %-14.6g weight %.2f contribution %.4f",
+			c.Name,
+			c.Value,
+			c.Lowest,
+			c.Highest,
+			c.Weight,
+			c.Score,
+		)
+	}
+}
+
+// getRank returns the position of the score among all the scores, starting from 1 for the highest one.
+func getRank(scores []float64, score float64) int {
+	rank := 1
+	for _, s := range scores {
+		if s > score {
+			rank++
+		}
+	}
+
+	return rank
+}
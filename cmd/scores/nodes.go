@@ -3,8 +3,11 @@ package scores
 import (
 	"context"
 	"encoding/json"
+	"os"
 	"sort"
+	"strconv"
 
+	"github.com/aftermath2/hydrus/api"
 	"github.com/aftermath2/hydrus/cmd"
 	"github.com/aftermath2/hydrus/config"
 	"github.com/aftermath2/hydrus/graph"
@@ -16,46 +19,121 @@ import (
 )
 
 type candidateNode struct {
-	Alias string  `json:"alias"`
-	Score float64 `json:"score"`
+	PublicKey   string     `json:"public_key"`
+	Alias       string     `json:"alias"`
+	Capacity    uint64     `json:"capacity"`
+	NumChannels int        `json:"num_channels"`
+	Centrality  centrality `json:"centrality"`
+	Score       float64    `json:"score"`
+	Components  components `json:"components"`
+}
+
+type centrality struct {
+	Degree      float64 `json:"degree"`
+	Betweenness float64 `json:"betweenness"`
+	Eigenvector uint64  `json:"eigenvector"`
+	Closeness   float64 `json:"closeness"`
+}
+
+func (c candidateNode) header() []string {
+	header := []string{
+		"public_key",
+		"alias",
+		"capacity",
+		"num_channels",
+		"degree",
+		"betweenness",
+		"eigenvector",
+		"closeness",
+		"score",
+	}
+	return append(header, c.Components.columns()...)
+}
+
+func (c candidateNode) values() []string {
+	values := []string{
+		c.PublicKey,
+		c.Alias,
+		strconv.FormatUint(c.Capacity, 10),
+		strconv.Itoa(c.NumChannels),
+		formatFloat(c.Centrality.Degree),
+		formatFloat(c.Centrality.Betweenness),
+		strconv.FormatUint(c.Centrality.Eigenvector, 10),
+		formatFloat(c.Centrality.Closeness),
+		formatFloat(c.Score),
+	}
+	return append(values, c.Components.values()...)
+}
+
+func (c candidateNode) score() float64 {
+	return c.Score
+}
+
+func (c candidateNode) components() components {
+	return c.Components
 }
 
 // NewNodesCmd returns a new scores nodes command.
 func NewNodesCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "nodes",
-		Short: "Show network graph nodes scores",
-		RunE: cmd.Run(func(ctx context.Context, config *config.Config, lnd lightning.Client, logger logger.Logger) error {
-			networkGraph, err := graph.New(ctx, config.Agent.HeuristicWeights.Open, lnd)
-			if err != nil {
-				return errors.Wrap(err, "creating graph")
-			}
+	var (
+		fromAgent bool
+		opts      outputOptions
+	)
 
-			heu, err := json.Marshal(networkGraph.Heuristics)
-			if err != nil {
-				return errors.Wrap(err, "parsing graph heuristics")
-			}
-			logger.Infof("Network heuristics: %s", heu)
-
-			candidates := make([]candidateNode, 0, len(networkGraph.Nodes))
-			for _, node := range networkGraph.Nodes {
-				candidates = append(candidates, candidateNode{
-					Alias: node.Alias,
-					Score: networkGraph.Heuristics.GetScore(node),
-				})
-			}
+	run := cmd.Run(func(ctx context.Context, config *config.Config, lnd lightning.Client, logger logger.Logger) error {
+		networkGraph, err := graph.New(ctx, config.Agent.HeuristicWeights.Open, lnd)
+		if err != nil {
+			return errors.Wrap(err, "creating graph")
+		}
+
+		heu, err := json.Marshal(networkGraph.Heuristics)
+		if err != nil {
+			return errors.Wrap(err, "parsing graph heuristics")
+		}
+		logger.Debugf("Network heuristics: %s", heu)
 
-			sort.Slice(candidates, func(i, j int) bool {
-				return candidates[i].Score > candidates[j].Score
+		candidates := make([]candidateNode, 0, len(networkGraph.Nodes))
+		for _, node := range networkGraph.Nodes {
+			candidates = append(candidates, candidateNode{
+				PublicKey:   node.PublicKey,
+				Alias:       node.Alias,
+				Capacity:    node.Capacity,
+				NumChannels: len(node.Channels),
+				Centrality:  centrality(node.Centrality),
+				Score:       networkGraph.Heuristics.GetScore(node),
+				Components:  networkGraph.Heuristics.Explain(node),
 			})
+		}
 
-			encCandidates, err := json.Marshal(candidates)
-			if err != nil {
-				return errors.Wrap(err, "encoding candidates list")
-			}
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].Score > candidates[j].Score
+		})
+
+		candidates, err = selectResults(candidates, opts)
+		if err != nil {
+			return err
+		}
+
+		return writeResults(os.Stdout, opts.format, candidates)
+	})
 
-			logger.Infof("Scores: %s", encCandidates)
-			return nil
-		}),
+	command := &cobra.Command{
+		Use:   "nodes",
+		Short: "Show network graph nodes scores",
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := opts.validate(); err != nil {
+				return err
+			}
+			if fromAgent {
+				pick := func(r api.Rankings) api.Ranking { return r.Nodes }
+				return cmd.RunAPI(printAgentRanking(opts, pick))(c, args)
+			}
+			return run(c, args)
+		},
 	}
+
+	command.Flags().BoolVar(&fromAgent, "agent", false, "Get the scores evaluated by the running agent")
+	opts.addFlags(command)
+
+	return command
 }
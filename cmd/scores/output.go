@@ -0,0 +1,205 @@
+package scores
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aftermath2/hydrus/heuristic"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Output formats.
+const (
+	outputTable  = "table"
+	outputJSON   = "json"
+	outputCSV    = "csv"
+	outputNDJSON = "ndjson"
+)
+
+// sortByScore sorts the results by their total score.
+const sortByScore = "score"
+
+// scoreResult is a scored node or channel.
+type scoreResult interface {
+	header() []string
+	values() []string
+	score() float64
+	components() components
+}
+
+// components contains the contribution of each heuristic to a score, in the order they are evaluated.
+type components []heuristic.Component
+
+// MarshalJSON encodes the components as an object indexed by the heuristic name.
+func (c components) MarshalJSON() ([]byte, error) {
+	scores := make(map[string]float64, len(c))
+	for _, component := range c {
+		scores[component.Name] = component.Score
+	}
+
+	return json.Marshal(scores)
+}
+
+func (c components) get(name string) (float64, bool) {
+	i := slices.IndexFunc(c, func(component heuristic.Component) bool {
+		return component.Name == name
+	})
+	if i == -1 {
+		return 0, false
+	}
+
+	return c[i].Score, true
+}
+
+func (c components) names() []string {
+	names := make([]string, 0, len(c))
+	for _, component := range c {
+		names = append(names, component.Name)
+	}
+
+	return names
+}
+
+// columns returns the names of the table columns, prefixed to tell them apart from the raw values.
+func (c components) columns() []string {
+	columns := make([]string, 0, len(c))
+	for _, component := range c {
+		columns = append(columns, "score."+component.Name)
+	}
+
+	return columns
+}
+
+func (c components) values() []string {
+	values := make([]string, 0, len(c))
+	for _, component := range c {
+		values = append(values, formatFloat(component.Score))
+	}
+
+	return values
+}
+
+// outputOptions contains the flags shared by the scores commands to select and format the results.
+type outputOptions struct {
+	format   string
+	sortBy   string
+	minScore float64
+	limit    int
+}
+
+func (o *outputOptions) addFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringVarP(&o.format, "output", "o", outputTable, "Output format: table, json, csv or ndjson")
+	flags.StringVar(&o.sortBy, "sort-by", "",
+		"Sort the results from highest to lowest by the score or the contribution of a heuristic")
+	flags.Float64Var(&o.minScore, "min-score", 0, "Skip the results with a lower score")
+	flags.IntVar(&o.limit, "limit", 0, "Maximum number of results, 0 means no limit")
+}
+
+func (o outputOptions) validate() error {
+	switch o.format {
+	case outputTable, outputJSON, outputCSV, outputNDJSON:
+	default:
+		return errors.Errorf("invalid output format %q", o.format)
+	}
+
+	if o.limit < 0 {
+		return errors.New("limit must not be negative")
+	}
+
+	return nil
+}
+
+// selectResults sorts the results if requested and returns the ones passing the filters.
+func selectResults[T scoreResult](results []T, opts outputOptions) ([]T, error) {
+	if opts.sortBy != "" && len(results) > 0 {
+		if _, ok := results[0].components().get(opts.sortBy); !ok && opts.sortBy != sortByScore {
+			return nil, errors.Errorf("invalid sort by value %q, it must be %q or one of: %s",
+				opts.sortBy,
+				sortByScore,
+				strings.Join(results[0].components().names(), ", "),
+			)
+		}
+
+		value := func(result T) float64 {
+			if opts.sortBy == sortByScore {
+				return result.score()
+			}
+			score, _ := result.components().get(opts.sortBy)
+			return score
+		}
+		slices.SortStableFunc(results, func(a, b T) int {
+			return cmp.Compare(value(b), value(a))
+		})
+	}
+
+	selected := make([]T, 0, len(results))
+	for _, result := range results {
+		if result.score() < opts.minScore {
+			continue
+		}
+		if opts.limit > 0 && len(selected) == opts.limit {
+			break
+		}
+		selected = append(selected, result)
+	}
+
+	return selected, nil
+}
+
+// writeResults writes the results in the format specified.
+func writeResults[T scoreResult](w io.Writer, format string, results []T) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+
+	case outputNDJSON:
+		enc := json.NewEncoder(w)
+		for _, result := range results {
+			if err := enc.Encode(result); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case outputCSV:
+		csvWriter := csv.NewWriter(w)
+		if len(results) > 0 {
+			if err := csvWriter.Write(results[0].header()); err != nil {
+				return err
+			}
+		}
+		for _, result := range results {
+			if err := csvWriter.Write(result.values()); err != nil {
+				return err
+			}
+		}
+		csvWriter.Flush()
+		return csvWriter.Error()
+
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		if len(results) > 0 {
+			fmt.Fprintln(tw, strings.ToUpper(strings.Join(results[0].header(), "\t")))
+		}
+		for _, result := range results {
+			fmt.Fprintln(tw, strings.Join(result.values(), "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
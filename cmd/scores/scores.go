@@ -1,6 +1,17 @@
 package scores
 
 import (
+	"context"
+	"encoding/json"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/aftermath2/hydrus/api"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/logger"
+
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -14,8 +25,96 @@ func NewCmd() *cobra.Command {
 
 	cmd.AddCommand(
 		NewChannelsCmd(),
+		NewExplainCmd(),
 		NewNodesCmd(),
 	)
 
 	return cmd
 }
+
+// agentCandidate is a candidate evaluated by the running agent, which only contains the fields it keeps.
+type agentCandidate struct {
+	fields map[string]json.RawMessage
+	// Fields of all the candidates, so that every row has the same columns
+	keys []string
+}
+
+// MarshalJSON encodes the candidate as it was received from the agent.
+func (a agentCandidate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.fields)
+}
+
+func (a agentCandidate) header() []string {
+	return a.keys
+}
+
+func (a agentCandidate) values() []string {
+	values := make([]string, 0, len(a.keys))
+	for _, key := range a.keys {
+		var str string
+		if err := json.Unmarshal(a.fields[key], &str); err == nil {
+			values = append(values, str)
+			continue
+		}
+		values = append(values, string(a.fields[key]))
+	}
+
+	return values
+}
+
+func (a agentCandidate) score() float64 {
+	var score float64
+	_ = json.Unmarshal(a.fields["score"], &score)
+	return score
+}
+
+func (a agentCandidate) components() components {
+	return nil
+}
+
+// printAgentRanking returns a function printing one of the rankings evaluated by the running agent.
+func printAgentRanking(
+	opts outputOptions,
+	pick func(rankings api.Rankings) api.Ranking,
+) func(ctx context.Context, config *config.Config, client *api.Client, logger logger.Logger) error {
+	return func(ctx context.Context, _ *config.Config, client *api.Client, logger logger.Logger) error {
+		rankings, err := client.Rankings(ctx)
+		if err != nil {
+			return errors.Wrap(err, "getting agent rankings")
+		}
+
+		ranking := pick(rankings)
+		if ranking.UpdatedAt.IsZero() {
+			logger.Info("The agent has not evaluated any candidates yet")
+			return nil
+		}
+
+		var fields []map[string]json.RawMessage
+		if err := json.Unmarshal(ranking.Candidates, &fields); err != nil {
+			return errors.Wrap(err, "decoding agent candidates")
+		}
+
+		keys := make([]string, 0)
+		for _, f := range fields {
+			for key := range f {
+				if !slices.Contains(keys, key) {
+					keys = append(keys, key)
+				}
+			}
+		}
+		slices.Sort(keys)
+
+		candidates := make([]agentCandidate, 0, len(fields))
+		for _, f := range fields {
+			candidates = append(candidates, agentCandidate{fields: f, keys: keys})
+		}
+
+		candidates, err = selectResults(candidates, opts)
+		if err != nil {
+			return err
+		}
+
+		logger.Infof("Evaluated at %s", ranking.UpdatedAt.Format(time.RFC3339))
+		return writeResults(os.Stdout, opts.format, candidates)
+	}
+}
@@ -0,0 +1,212 @@
+package simulate
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aftermath2/hydrus/agent"
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/cmd"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/graph"
+	"github.com/aftermath2/hydrus/lightning"
+	"github.com/aftermath2/hydrus/logger"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// position contains the measures of our node in the graph.
+type position struct {
+	degree      int
+	closeness   float64
+	betweenness float64
+	eigenvector uint64
+	// Distances to the reference nodes
+	distances []int
+}
+
+func newPosition(simulation *graph.Simulation, references []string) position {
+	p := simulation.Position()
+	return position{
+		degree:      simulation.Degree(),
+		closeness:   p.Closeness,
+		betweenness: p.Betweenness,
+		eigenvector: simulation.Eigenvector(),
+		distances:   simulation.Distances(references),
+	}
+}
+
+// NewOpenCmd returns a new simulate open command.
+func NewOpenCmd() *cobra.Command {
+	var (
+		amount  uint64
+		top     int
+		samples int
+	)
+
+	command := &cobra.Command{
+		Use:   "open <public_key> [<public_key>...]",
+		Short: "Show how opening channels to the nodes would change our position in the network graph",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			run := cmd.Run(func(ctx context.Context, config *config.Config, lnd lightning.Client, logger logger.Logger) error {
+				localNode, err := local.GetNode(ctx, config.Agent, lnd)
+				if err != nil {
+					return errors.Wrap(err, "getting local node")
+				}
+
+				channelGraph, err := lnd.DescribeGraph(ctx)
+				if err != nil {
+					return errors.Wrap(err, "getting channel graph")
+				}
+
+				networkGraph, err := graph.FromChannelGraph(ctx, config.Agent.HeuristicWeights.Open, channelGraph)
+				if err != nil {
+					return errors.Wrap(err, "creating graph")
+				}
+
+				for _, warning := range checkAmount(config.Agent, localNode, amount, len(args)) {
+					logger.Warning(warning)
+				}
+
+				if samples == 0 {
+					samples = config.Agent.Selection.MarginalGain.Samples
+				}
+				peers := slices.Collect(maps.Keys(localNode.ChannelPeers))
+				simulation := networkGraph.NewSimulation(localNode.PublicKey, peers, samples)
+				references := topBetweenness(networkGraph, top)
+
+				before := newPosition(simulation, references)
+				for _, publicKey := range args {
+					if err := simulation.AddChannel(publicKey); err != nil {
+						return err
+					}
+				}
+				after := newPosition(simulation, references)
+
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				writePositions(w, before, after, references)
+				for _, publicKey := range args {
+					writeCandidate(w, config.Agent, localNode, channelGraph, networkGraph, publicKey)
+				}
+				return w.Flush()
+			})
+			return run(c, args)
+		},
+	}
+
+	flags := command.Flags()
+	flags.Uint64Var(&amount, "amount", 0, "Size of each channel in satoshis")
+	flags.IntVar(&top, "top", 10, "Number of nodes with the highest betweenness to measure the distance to")
+	flags.IntVar(&samples, "samples", 0,
+		"Nodes sampled to estimate the betweenness, agent.selection.marginal_gain.samples by default")
+	_ = command.MarkFlagRequired("amount")
+
+	return command
+}
+
+// checkAmount returns the reasons why the agent would not open channels of the amount specified.
+func checkAmount(config config.Agent, localNode local.Node, amount uint64, numChannels int) []string {
+	warnings := make([]string, 0)
+	if amount < config.MinChannelSize {
+		warnings = append(warnings,
+			fmt.Sprintf("The amount is below the minimum channel size (%d)", config.MinChannelSize))
+	}
+
+	if amount > config.MaxChannelSize {
+		warnings = append(warnings,
+			fmt.Sprintf("The amount is above the maximum channel size (%d)", config.MaxChannelSize))
+	}
+
+	if total := amount * uint64(numChannels); total > localNode.AllocatedBalance {
+		warnings = append(warnings, fmt.Sprintf("The total amount (%d) is higher than the allocated balance (%d)",
+			total, localNode.AllocatedBalance))
+	}
+
+	return warnings
+}
+
+// topBetweenness returns the public keys of the n nodes with the highest betweenness centrality.
+func topBetweenness(networkGraph graph.Graph, n int) []string {
+	nodes := slices.Clone(networkGraph.Nodes)
+	slices.SortFunc(nodes, func(a, b graph.Node) int {
+		return cmp.Compare(b.Centrality.Betweenness, a.Centrality.Betweenness)
+	})
+
+	publicKeys := make([]string, 0, n)
+	for _, node := range nodes[:min(n, len(nodes))] {
+		publicKeys = append(publicKeys, node.PublicKey)
+	}
+
+	return publicKeys
+}
+
+func writePositions(w io.Writer, before, after position, references []string) {
+	fmt.Fprintln(w, "MEASURE\tCURRENT\tSIMULATED")
+	fmt.Fprintf(w, "Degree\t%d\t%d\n", before.degree, after.degree)
+	fmt.Fprintf(w, "Closeness\t%.6f\t%.6f\n", before.closeness, after.closeness)
+	fmt.Fprintf(w, "Betweenness (estimate)\t%.2f\t%.2f\n", before.betweenness, after.betweenness)
+	fmt.Fprintf(w, "Eigenvector\t%d\t%d\n", before.eigenvector, after.eigenvector)
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "DISTANCE TO TOP BETWEENNESS NODE\tCURRENT\tSIMULATED")
+	for i, publicKey := range references {
+		fmt.Fprintf(w, "%s\t%s\t%s\n",
+			publicKey,
+			formatDistance(before.distances[i]),
+			formatDistance(after.distances[i]),
+		)
+	}
+	fmt.Fprintln(w)
+}
+
+func writeCandidate(
+	w io.Writer,
+	config config.Agent,
+	localNode local.Node,
+	channelGraph *lnrpc.ChannelGraph,
+	networkGraph graph.Graph,
+	publicKey string,
+) {
+	node, ok := networkGraph.GetNode(publicKey)
+	if ok {
+		fmt.Fprintf(w, "Candidate %s (%s)\tscore %.3f\trank %d of %d\n",
+			node.Alias,
+			publicKey,
+			networkGraph.Heuristics.GetScore(node),
+			networkGraph.Rank(node),
+			len(networkGraph.Nodes),
+		)
+	} else {
+		fmt.Fprintf(w, "Candidate %s\tnot scored: %s\n",
+			publicKey,
+			strings.Join(graph.ExclusionReasons(channelGraph, publicKey), ", "),
+		)
+
+		// Rebuild the node from the channel graph so the tags see its alias, capacity and channels
+		if node, ok = agent.FindNode(channelGraph, networkGraph, publicKey); !ok {
+			node.PublicKey = publicKey
+		}
+	}
+
+	for _, reason := range agent.DiscardReasons(localNode, node, agent.NewTagger(config, channelGraph)) {
+		fmt.Fprintf(w, "\tdiscarded: %s\n", reason)
+	}
+}
+
+func formatDistance(distance int) string {
+	if distance < 0 {
+		return "unreachable"
+	}
+	return strconv.Itoa(distance)
+}
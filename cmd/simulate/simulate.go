@@ -0,0 +1,19 @@
+package simulate
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewCmd returns a new simulate command.
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Simulate operations without executing them",
+	}
+
+	cmd.AddCommand(
+		NewOpenCmd(),
+	)
+
+	return cmd
+}
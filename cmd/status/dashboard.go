@@ -0,0 +1,208 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aftermath2/hydrus/agent"
+	"github.com/aftermath2/hydrus/agent/local"
+	"github.com/aftermath2/hydrus/api"
+	"github.com/aftermath2/hydrus/channel"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/heuristic"
+	"github.com/aftermath2/hydrus/lightning"
+
+	"github.com/pkg/errors"
+)
+
+// dashboard contains the information displayed by the status command.
+type dashboard struct {
+	updatedAt time.Time
+	node      local.Node
+	channels  []channelRow
+	// Agent status, nil if the API is disabled or the agent couldn't be reached
+	agent    *api.Status
+	agentErr string
+	// Channels opened and closed and routing policy updates sent, sorted from newest to oldest
+	actions []action
+}
+
+// action is a channel opened or closed or a routing policy update sent by the agent.
+type action struct {
+	time time.Time
+	// Channel point or, for the channels opened, peer the action was taken on
+	subject     string
+	description string
+	// The channels opened are identified by their funding transaction and peer
+	channelPoint string
+	txID         string
+	publicKey    string
+}
+
+// matches returns whether the action was taken on the channel.
+func (a action) matches(channel local.Channel) bool {
+	if a.channelPoint != "" {
+		return a.channelPoint == channel.Point
+	}
+
+	return a.publicKey == channel.RemotePublicKey && strings.HasPrefix(channel.Point, a.txID+":")
+}
+
+// channelRow contains the information of a local channel.
+type channelRow struct {
+	channel     local.Channel
+	feeRatePPM  uint64
+	maxHTLCMsat uint64
+	// Reason why the routing policy of the channel couldn't be retrieved, empty if it was
+	policyErr string
+	score     float64
+	// Forwards per day within the longest window
+	forwards   float64
+	components []heuristic.Component
+}
+
+// localRatio returns the share of the channel capacity on our side.
+func (r channelRow) localRatio() float64 {
+	if r.channel.Capacity == 0 {
+		return 0
+	}
+
+	return float64(r.channel.LocalBalance) / float64(r.channel.Capacity)
+}
+
+// policy returns the fee rate and maximum HTLC size in sats to display, or "n/a" if the routing policy of the
+// channel couldn't be retrieved.
+func (r channelRow) policy() (feeRate, maxHTLC string) {
+	if r.policyErr != "" {
+		return "n/a", "n/a"
+	}
+
+	return strconv.FormatUint(r.feeRatePPM, 10), strconv.FormatUint(r.maxHTLCMsat/1000, 10)
+}
+
+// collect gathers the node, channels and agent information.
+func collect(ctx context.Context, config *config.Config, lnd lightning.Client) (dashboard, error) {
+	localNode, err := local.GetNode(ctx, config.Agent, lnd)
+	if err != nil {
+		return dashboard{}, errors.Wrap(err, "getting local node")
+	}
+
+	channels := localNode.Channels
+	rows := make([]channelRow, 0, len(channels.List))
+	for _, channel := range channels.List {
+		row := channelRow{
+			channel:    channel,
+			score:      channels.Heuristics.GetScore(channel),
+			forwards:   channel.LongestRates().NumForwards,
+			components: channels.Heuristics.Explain(channel),
+		}
+
+		// A channel whose policy can't be retrieved is still listed
+		chanInfo, err := lnd.GetChanInfo(ctx, channel.ID)
+		if err != nil {
+			row.policyErr = err.Error()
+			rows = append(rows, row)
+			continue
+		}
+
+		policy := chanInfo.Node2Policy
+		if chanInfo.Node1Pub == localNode.PublicKey {
+			policy = chanInfo.Node1Policy
+		}
+		if policy != nil {
+			row.feeRatePPM = uint64(policy.FeeRateMilliMsat)
+			row.maxHTLCMsat = policy.MaxHtlcMsat
+		}
+
+		rows = append(rows, row)
+	}
+
+	actions, err := readActions(config.Agent.DataDir)
+	if err != nil {
+		return dashboard{}, err
+	}
+
+	d := dashboard{
+		updatedAt: time.Now(),
+		node:      localNode,
+		channels:  rows,
+		actions:   actions,
+	}
+
+	// The dashboard is still useful without the agent running, its status is optional
+	if !config.Agent.API.Enabled() {
+		d.agentErr = "the agent API is not enabled in the configuration"
+		return d, nil
+	}
+
+	status, err := api.NewClient(config.Agent.API).Status(ctx)
+	if err != nil {
+		d.agentErr = err.Error()
+		return d, nil
+	}
+
+	d.agent = &status
+	return d, nil
+}
+
+// readActions returns the channels opened and closed by the channel manager and the routing policy updates
+// sent by the agent, sorted from newest to oldest.
+func readActions(dataDir string) ([]action, error) {
+	channelActions, err := channel.ReadActions(dataDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading channel actions")
+	}
+
+	updates, err := agent.ReadPolicyUpdates(dataDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading policy updates")
+	}
+
+	actions := make([]action, 0, len(channelActions)+len(updates))
+	for _, a := range channelActions {
+		switch a.Type {
+		case channel.ActionOpen:
+			actions = append(actions, action{
+				time:        a.Time,
+				subject:     shorten(a.PublicKey),
+				description: fmt.Sprintf("opened a %d sats channel in transaction %s", a.Amount, a.TxID),
+				txID:        a.TxID,
+				publicKey:   a.PublicKey,
+			})
+		case channel.ActionClose:
+			closeType := "closed"
+			if a.Force {
+				closeType = "force closed"
+			}
+			actions = append(actions, action{
+				time:         a.Time,
+				subject:      a.ChannelPoint,
+				description:  fmt.Sprintf("%s in transaction %s", closeType, a.TxID),
+				channelPoint: a.ChannelPoint,
+			})
+		}
+	}
+
+	for _, update := range updates {
+		description := fmt.Sprintf("fee rate %d -> %d ppm", update.PreviousFeeRatePPM, update.FeeRatePPM)
+		if update.PreviousFeeRatePPM == update.FeeRatePPM {
+			description = fmt.Sprintf("routing policy updated, fee rate %d ppm", update.FeeRatePPM)
+		}
+		actions = append(actions, action{
+			time:         update.Time,
+			subject:      update.ChannelPoint,
+			description:  description,
+			channelPoint: update.ChannelPoint,
+		})
+	}
+
+	slices.SortStableFunc(actions, func(a, b action) int {
+		return b.time.Compare(a.time)
+	})
+
+	return actions, nil
+}
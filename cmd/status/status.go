@@ -0,0 +1,50 @@
+package status
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/aftermath2/hydrus/cmd"
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/lightning"
+	"github.com/aftermath2/hydrus/logger"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewCmd returns a new status command.
+func NewCmd() *cobra.Command {
+	var (
+		tui      bool
+		interval time.Duration
+	)
+
+	command := &cobra.Command{
+		Use:   "status",
+		Short: "Show the node's balances and channels along with the agent status",
+		RunE: cmd.Run(func(ctx context.Context, config *config.Config, lnd lightning.Client, _ logger.Logger) error {
+			if interval < time.Second {
+				return errors.New("refresh interval must be at least one second")
+			}
+
+			if tui {
+				return runTUI(ctx, config, lnd, interval)
+			}
+
+			d, err := collect(ctx, config, lnd)
+			if err != nil {
+				return err
+			}
+
+			return newView(false).render(os.Stdout, d, 0, 0)
+		}),
+	}
+
+	flags := command.Flags()
+	flags.BoolVar(&tui, "tui", false, "Show a dashboard that refreshes periodically and responds to the keyboard")
+	flags.DurationVar(&interval, "refresh", 30*time.Second, "Interval at which the dashboard is refreshed")
+
+	return command
+}
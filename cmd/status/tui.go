@@ -0,0 +1,196 @@
+package status
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/lightning"
+
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+)
+
+// ANSI escape sequences used to draw the dashboard.
+const (
+	enterAltScreen = "\x1b[?1049h\x1b[?25l"
+	exitAltScreen  = "\x1b[?25h\x1b[?1049l"
+	clearScreen    = "\x1b[H\x1b[2J"
+)
+
+// Interval at which the screen is redrawn to update the countdowns and adapt to the terminal size.
+const redrawInterval = time.Second
+
+// key is a key pressed by the user.
+type key string
+
+const (
+	keyUp        key = "up"
+	keyDown      key = "down"
+	keyEnter     key = "enter"
+	keyBack      key = "back"
+	keyRefresh   key = "r"
+	keyQuit      key = "q"
+	keyInterrupt key = "ctrl+c"
+)
+
+// refreshResult is the outcome of collecting the dashboard information in the background.
+type refreshResult struct {
+	err       error
+	dashboard dashboard
+}
+
+// runTUI displays the dashboard refreshing it on the interval until the user quits or the context is
+// cancelled.
+func runTUI(ctx context.Context, config *config.Config, lnd lightning.Client, interval time.Duration) error {
+	inFd, outFd := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(inFd) || !term.IsTerminal(outFd) {
+		return errors.New("the dashboard requires an interactive terminal")
+	}
+
+	oldState, err := term.MakeRaw(inFd)
+	if err != nil {
+		return errors.Wrap(err, "setting terminal raw mode")
+	}
+	defer term.Restore(inFd, oldState)
+
+	os.Stdout.WriteString(enterAltScreen)
+	defer os.Stdout.WriteString(exitAltScreen)
+
+	keys := make(chan key)
+	go readKeys(os.Stdin, keys)
+
+	results := make(chan refreshResult, 1)
+	refresh := func() {
+		go func() {
+			d, err := collect(ctx, config, lnd)
+			results <- refreshResult{dashboard: d, err: err}
+		}()
+	}
+
+	refreshTicker := time.NewTicker(interval)
+	defer refreshTicker.Stop()
+	redrawTicker := time.NewTicker(redrawInterval)
+	defer redrawTicker.Stop()
+
+	var d dashboard
+	v := newView(true)
+	v.refreshing = true
+	refresh()
+
+	for {
+		if err := draw(outFd, v, d); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case result := <-results:
+			v.refreshing = false
+			v.err = result.err
+			if result.err == nil {
+				d = result.dashboard
+			}
+
+		case k, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			if k == keyRefresh {
+				if !v.refreshing {
+					v.refreshing = true
+					refresh()
+				}
+				continue
+			}
+			if v.handle(k, v.sort(d.channels)) {
+				return nil
+			}
+
+		case <-refreshTicker.C:
+			if !v.refreshing {
+				v.refreshing = true
+				refresh()
+			}
+
+		case <-redrawTicker.C:
+		}
+	}
+}
+
+// draw renders the dashboard on the whole terminal screen.
+func draw(fd int, v *view, d dashboard) error {
+	width, height, err := term.GetSize(fd)
+	if err != nil {
+		return errors.Wrap(err, "getting terminal size")
+	}
+
+	var buf bytes.Buffer
+	if err := v.render(&buf, d, width, height); err != nil {
+		return err
+	}
+
+	// The terminal doesn't translate line feeds in raw mode
+	screen := clearScreen + strings.ReplaceAll(buf.String(), "\n", "\r\n")
+	_, err = os.Stdout.WriteString(screen)
+	return err
+}
+
+// readKeys sends the keys pressed to the channel until the reader is closed.
+func readKeys(r io.Reader, keys chan<- key) {
+	defer close(keys)
+
+	buf := make([]byte, 16)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for _, k := range parseKeys(buf[:n]) {
+			keys <- k
+		}
+	}
+}
+
+// parseKeys translates the bytes read from the terminal into keys, unknown escape sequences are ignored.
+func parseKeys(input []byte) []key {
+	var keys []key
+	for i := 0; i < len(input); i++ {
+		switch b := input[i]; b {
+		case 0x1b:
+			// Arrow keys are sent as ESC [ A or ESC O A depending on the terminal mode
+			if i+2 < len(input) && (input[i+1] == '[' || input[i+1] == 'O') {
+				switch input[i+2] {
+				case 'A':
+					keys = append(keys, keyUp)
+				case 'B':
+					keys = append(keys, keyDown)
+				}
+				i += 2
+				continue
+			}
+			keys = append(keys, keyBack)
+		case 0x03:
+			keys = append(keys, keyInterrupt)
+		case '\r', '\n':
+			keys = append(keys, keyEnter)
+		case 0x7f, 0x08:
+			keys = append(keys, keyBack)
+		case 'k':
+			keys = append(keys, keyUp)
+		case 'j':
+			keys = append(keys, keyDown)
+		default:
+			keys = append(keys, key(string(rune(b))))
+		}
+	}
+
+	return keys
+}
@@ -0,0 +1,350 @@
+package status
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// Width of the local balance bars
+	barWidth = 10
+	// Maximum number of actions listed
+	maxActions = 5
+	// Lines used by the main view besides the channel rows
+	mainViewLines = 13
+)
+
+// sortColumn is a column of the channels table the rows can be sorted by.
+type sortColumn struct {
+	value func(row channelRow) float64
+	name  string
+	key   byte
+}
+
+var sortColumns = []sortColumn{
+	{key: '1', name: "CAPACITY", value: func(r channelRow) float64 { return float64(r.channel.Capacity) }},
+	{key: '2', name: "LOCAL", value: channelRow.localRatio},
+	{key: '3', name: "FEE PPM", value: func(r channelRow) float64 { return float64(r.feeRatePPM) }},
+	{key: '4', name: "MAX HTLC", value: func(r channelRow) float64 { return float64(r.maxHTLCMsat) }},
+	{key: '5', name: "SCORE", value: func(r channelRow) float64 { return r.score }},
+	{key: '6', name: "FWD/DAY", value: func(r channelRow) float64 { return r.forwards }},
+}
+
+// view holds the state of the dashboard presentation.
+type view struct {
+	// Channel point of the selected channel
+	selected string
+	// Error of the last refresh, the previous information is kept on screen
+	err        error
+	sortBy     int
+	ascending  bool
+	detail     bool
+	refreshing bool
+	// Whether the dashboard reacts to the keyboard, the selection and shortcuts are only shown if it does
+	interactive bool
+}
+
+func newView(interactive bool) *view {
+	// Show the channels with the lowest score first, they are the closing candidates
+	return &view{
+		sortBy:      slices.IndexFunc(sortColumns, func(c sortColumn) bool { return c.name == "SCORE" }),
+		ascending:   true,
+		interactive: interactive,
+	}
+}
+
+// handle applies the key pressed and returns true if the dashboard must be closed.
+func (v *view) handle(k key, rows []channelRow) bool {
+	switch k {
+	case keyQuit, keyInterrupt:
+		return true
+	case keyBack:
+		v.detail = false
+	case keyEnter:
+		v.detail = len(rows) > 0
+	case keyUp, keyDown:
+		if v.detail || len(rows) == 0 {
+			return false
+		}
+		i := max(v.selectedIndex(rows), 0)
+		if k == keyUp {
+			i = max(i-1, 0)
+		} else {
+			i = min(i+1, len(rows)-1)
+		}
+		v.selected = rows[i].channel.Point
+	default:
+		i := slices.IndexFunc(sortColumns, func(c sortColumn) bool { return key(c.key) == k })
+		if i == -1 || v.detail {
+			return false
+		}
+		// Selecting the same column again reverses the order
+		if i == v.sortBy {
+			v.ascending = !v.ascending
+		} else {
+			v.sortBy = i
+			v.ascending = false
+		}
+	}
+
+	return false
+}
+
+// sort returns the channel rows in the order selected.
+func (v *view) sort(rows []channelRow) []channelRow {
+	column := sortColumns[v.sortBy]
+	sorted := slices.Clone(rows)
+	slices.SortStableFunc(sorted, func(a, b channelRow) int {
+		if v.ascending {
+			return cmp.Compare(column.value(a), column.value(b))
+		}
+		return cmp.Compare(column.value(b), column.value(a))
+	})
+
+	return sorted
+}
+
+func (v *view) selectedIndex(rows []channelRow) int {
+	return slices.IndexFunc(rows, func(r channelRow) bool {
+		return r.channel.Point == v.selected
+	})
+}
+
+// render writes the dashboard limited to the terminal size, a non-positive size means unlimited.
+func (v *view) render(w io.Writer, d dashboard, width, height int) error {
+	var sb strings.Builder
+	rows := v.sort(d.channels)
+
+	switch {
+	case d.updatedAt.IsZero():
+		sb.WriteString("Loading...\n")
+	case v.detail && len(rows) > 0:
+		i := max(v.selectedIndex(rows), 0)
+		v.writeDetail(&sb, d, rows[i])
+	default:
+		v.writeMain(&sb, d, rows, height)
+	}
+
+	if v.err != nil {
+		fmt.Fprintf(&sb, "\nRefresh failed: %v\n", v.err)
+	}
+
+	for line := range strings.Lines(sb.String()) {
+		if _, err := io.WriteString(w, truncate(strings.TrimSuffix(line, "\n"), width)+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *view) writeMain(sb *strings.Builder, d dashboard, rows []channelRow, height int) {
+	node := d.node
+	updated := d.updatedAt.Format(time.DateTime)
+	if v.refreshing {
+		updated += " (refreshing)"
+	}
+
+	fmt.Fprintf(sb, "Hydrus status, updated at %s\n\n", updated)
+	tw := tabwriter.NewWriter(sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Wallet\t%d sats confirmed, %d sats allocated\n", node.WalletBalance, node.AllocatedBalance)
+	fmt.Fprintf(tw, "Channels\t%d, up to %d to open and %d to close\n",
+		node.NumChannels,
+		node.MaxOpenChannels,
+		node.MaxCloseChannels,
+	)
+	fmt.Fprintf(tw, "On-chain fees\t%d sat/vB at block %d\n", node.SatvB, node.CurrentBlockHeight)
+	fmt.Fprintf(tw, "Agent\t%s\n", agentState(d))
+	tw.Flush()
+	sb.WriteString("\n")
+
+	start, end := 0, len(rows)
+	if height > 0 {
+		actionLines := max(min(len(d.actions), maxActions), 1)
+		start, end = visibleRange(len(rows), max(v.selectedIndex(rows), 0), height-mainViewLines-actionLines)
+	}
+
+	tw = tabwriter.NewWriter(sb, 0, 0, 2, ' ', 0)
+	header := []string{"", "ID", "PEER"}
+	for i, column := range sortColumns {
+		name := column.name
+		if v.interactive {
+			name += "[" + string(column.key) + "]"
+		}
+		if i == v.sortBy {
+			if v.ascending {
+				name += " ↑"
+			} else {
+				name += " ↓"
+			}
+		}
+		header = append(header, name)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for i, row := range rows[start:end] {
+		cursor := ""
+		if v.interactive && start+i == max(v.selectedIndex(rows), 0) {
+			cursor = ">"
+		}
+		feeRate, maxHTLC := row.policy()
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%d\t%s\t%s\t%s\t%.3f\t%.2f\n",
+			cursor,
+			row.channel.ID,
+			shorten(row.channel.RemotePublicKey),
+			row.channel.Capacity,
+			balanceBar(row.localRatio()),
+			feeRate,
+			maxHTLC,
+			row.score,
+			row.forwards,
+		)
+	}
+	tw.Flush()
+	if start > 0 || end < len(rows) {
+		fmt.Fprintf(sb, "Channels %d-%d of %d\n", start+1, end, len(rows))
+	}
+
+	sb.WriteString("\nLast actions\n")
+	if len(d.actions) == 0 {
+		sb.WriteString("  No actions recorded\n")
+	}
+	for _, action := range d.actions[:min(len(d.actions), maxActions)] {
+		fmt.Fprintf(sb, "  %s  %s  %s\n",
+			action.time.Local().Format(time.DateTime),
+			action.subject,
+			action.description,
+		)
+	}
+
+	if v.interactive {
+		sb.WriteString("\n1-6 sort (again to reverse)  ↑/↓ select  enter details  r refresh  q quit\n")
+	}
+}
+
+func (v *view) writeDetail(sb *strings.Builder, d dashboard, row channelRow) {
+	channel := row.channel
+	rank := 1
+	for _, r := range d.channels {
+		if r.score < row.score {
+			rank++
+		}
+	}
+
+	fmt.Fprintf(sb, "Channel %s (%d)\n\n", channel.Point, channel.ID)
+	tw := tabwriter.NewWriter(sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Peer\t%s\n", channel.RemotePublicKey)
+	fmt.Fprintf(tw, "Active\t%t\n", channel.Active)
+	fmt.Fprintf(tw, "Age\t%s\n", channel.Age(d.node.CurrentBlockHeight).Round(time.Hour))
+	fmt.Fprintf(tw, "Capacity\t%d sats\n", channel.Capacity)
+	fmt.Fprintf(tw, "Local balance\t%d sats %s\n", channel.LocalBalance, balanceBar(row.localRatio()))
+	if row.policyErr != "" {
+		fmt.Fprintf(tw, "Routing policy\tunavailable: %s\n", row.policyErr)
+	} else {
+		fmt.Fprintf(tw, "Fee rate\t%d ppm\n", row.feeRatePPM)
+		fmt.Fprintf(tw, "HTLC range\t%d - %d msats\n", channel.MinHTLCMsat, row.maxHTLCMsat)
+	}
+	// The channels with the lowest scores are the first to be closed
+	fmt.Fprintf(tw, "Score\t%.3f, closing rank %d of %d\n", row.score, rank, len(d.channels))
+	tw.Flush()
+
+	sb.WriteString("\n")
+	tw = tabwriter.NewWriter(sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "HEURISTIC\tVALUE\tLOWEST\tHIGHEST\tWEIGHT\tCONTRIBUTION")
+	for _, c := range row.components {
+		fmt.Fprintf(tw, "%s\t%.6g\t%.6g\t%.6g\t%.2f\t%.4f\n", c.Name, c.Value, c.Lowest, c.Highest, c.Weight, c.Score)
+	}
+	tw.Flush()
+
+	sb.WriteString("\nActions\n")
+	actions := 0
+	for _, action := range d.actions {
+		if !action.matches(channel) || actions == maxActions {
+			continue
+		}
+		fmt.Fprintf(sb, "  %s  %s\n", action.time.Local().Format(time.DateTime), action.description)
+		actions++
+	}
+	if actions == 0 {
+		sb.WriteString("  No actions recorded\n")
+	}
+
+	if v.interactive {
+		sb.WriteString("\nesc back  r refresh  q quit\n")
+	}
+}
+
+// agentState describes the agent status and the time until the next executions of its tasks.
+func agentState(d dashboard) string {
+	if d.agent == nil {
+		return "unavailable: " + d.agentErr
+	}
+
+	var state []string
+	switch {
+	case d.agent.Paused:
+		state = append(state, "paused")
+	case d.agent.DryRun:
+		state = append(state, "running in dry run mode")
+	default:
+		state = append(state, "running")
+	}
+
+	for _, task := range d.agent.Tasks {
+		s := task.Name + ": "
+		switch {
+		case task.Running:
+			s += "running"
+		case task.NextRun.IsZero():
+			s += "not scheduled"
+		default:
+			s += "next in " + time.Until(task.NextRun).Round(time.Second).String()
+		}
+		if task.LastRun != nil && task.LastRun.Error != "" {
+			s += " (last run failed)"
+		}
+		state = append(state, s)
+	}
+
+	return strings.Join(state, ", ")
+}
+
+// balanceBar returns a bar filled in proportion to the ratio along with its percentage.
+func balanceBar(ratio float64) string {
+	filled := min(max(int(ratio*barWidth+0.5), 0), barWidth)
+	return fmt.Sprintf("%s%s %3.0f%%", strings.Repeat("█", filled), strings.Repeat("░", barWidth-filled), ratio*100)
+}
+
+// visibleRange returns the rows that fit in the space available, keeping the selected one visible.
+func visibleRange(n, selected, space int) (int, int) {
+	space = max(space, 3)
+	if n <= space {
+		return 0, n
+	}
+
+	start := min(max(selected-space/2, 0), n-space)
+	return start, start + space
+}
+
+func shorten(publicKey string) string {
+	if len(publicKey) <= 16 {
+		return publicKey
+	}
+
+	return publicKey[:8] + ".." + publicKey[len(publicKey)-6:]
+}
+
+// truncate cuts the line to the width, a non-positive width means unlimited.
+func truncate(line string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(line) <= width {
+		return line
+	}
+
+	return string([]rune(line)[:width])
+}
@@ -2,9 +2,11 @@ package config
 
 import (
 	"cmp"
+	"net"
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strings"
 	"time"
 
@@ -16,6 +18,84 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// Funding strategies used to decide the amount of each channel opened.
+const (
+	// FundingStrategyEqual splits the allocated balance equally between all channels.
+	FundingStrategyEqual = "equal"
+	// FundingStrategyScore splits the allocated balance proportionally to the nodes' scores.
+	FundingStrategyScore = "score"
+	// FundingStrategyPeerMedian matches the median capacity of the peer's channels.
+	FundingStrategyPeerMedian = "peer_median"
+)
+
+// Selection modes used to pick the nodes to open channels to.
+const (
+	// SelectionModeScore picks the nodes with the highest scores.
+	SelectionModeScore = "score"
+	// SelectionModeDiversity spreads the nodes across different communities of the network.
+	SelectionModeDiversity = "diversity"
+	// SelectionModeMarginalGain picks the nodes that improve our own centrality the most.
+	SelectionModeMarginalGain = "marginal_gain"
+)
+
+// Closing modes used to pick the channels to close.
+const (
+	// ClosingModeScore closes the channels whose scores are low relative to the rest of the channels.
+	ClosingModeScore = "score"
+	// ClosingModeProfitability closes the channels with a negative expected return.
+	ClosingModeProfitability = "profitability"
+)
+
+// Fee strategies used to update the channels routing policies.
+const (
+	// FeeStrategyDefault raises or lowers the fee rate depending on the local balance and the direction of
+	// the forwards.
+	FeeStrategyDefault = "default"
+	// FeeStrategyLiquidity sets a fee rate that grows as the local balance shrinks.
+	FeeStrategyLiquidity = "liquidity"
+	// FeeStrategyPID drives the local balance towards a target ratio with a PID controller.
+	FeeStrategyPID = "pid"
+	// FeeStrategyFlat sets a fixed fee rate.
+	FeeStrategyFlat = "flat"
+	// FeeStrategyMarket sets a fee rate relative to the ones charged by the other nodes to reach the same peer.
+	FeeStrategyMarket = "market"
+)
+
+// Notifier types used to deliver the agent's events.
+const (
+	// NotifierTypeWebhook sends the events as JSON in a POST request.
+	NotifierTypeWebhook = "webhook"
+	// NotifierTypeSMTP sends the events by email.
+	NotifierTypeSMTP = "smtp"
+	// NotifierTypeTelegram sends the events as messages from a Telegram bot.
+	NotifierTypeTelegram = "telegram"
+	// NotifierTypeNtfy publishes the events to a ntfy topic.
+	NotifierTypeNtfy = "ntfy"
+)
+
+// Events emitted by the agent.
+const (
+	// EventChannelsOpened is emitted when a batch of channels is opened.
+	EventChannelsOpened = "channels_opened"
+	// EventChannelClosed is emitted when a channel is closed.
+	EventChannelClosed = "channel_closed"
+	// EventRunSkipped is emitted when a task execution is skipped.
+	EventRunSkipped = "run_skipped"
+	// EventTaskError is emitted when a task execution fails.
+	EventTaskError = "task_error"
+)
+
+// Tags assigned from the blocklist and keeplist, their names can't be used by other tags.
+const (
+	// TagBlocklist is assigned to the peers in the blocklist, channels with them are never opened.
+	TagBlocklist = "blocklist"
+	// TagKeeplist is assigned to the channels and peers in the keeplist, their channels are never closed.
+	TagKeeplist = "keeplist"
+)
+
+// minTimeLockDelta is the lowest time lock delta accepted by LND.
+const minTimeLockDelta = 18
+
 var (
 	// DefaultOpenWeights contains the default values for the channel opening heuristic weights.
 	DefaultOpenWeights = OpenWeights{
@@ -62,11 +142,21 @@ type Config struct {
 // Agent configuration.
 type Agent struct {
 	DryRun            bool              `yaml:"dry_run"`
+	DataDir           string            `yaml:"data_dir"`
 	AllowForceCloses  bool              `yaml:"allow_force_closes"`
 	Blocklist         []string          `yaml:"blocklist"`
 	Keeplist          []string          `yaml:"keeplist"`
+	Tags              []Tag             `yaml:"tags"`
+	FundingStrategy   string            `yaml:"funding_strategy"`
 	ChannelManager    ChannelManager    `yaml:"channel_manager"`
 	HeuristicWeights  HeuristicsWeights `yaml:"heuristic_weights"`
+	Selection         Selection         `yaml:"selection"`
+	Closing           Closing           `yaml:"closing"`
+	Fees              Fees              `yaml:"fees"`
+	RoutingPolicies   RoutingPolicies   `yaml:"routing_policies"`
+	API               API               `yaml:"api"`
+	Metrics           Metrics           `yaml:"metrics"`
+	Notifiers         []Notifier        `yaml:"notifiers"`
 	Intervals         Intervals         `yaml:"intervals"`
 	AllocationPercent uint64            `yaml:"allocation_percent"`
 	MinBatchSize      uint64            `yaml:"min_batch_size"`
@@ -85,6 +175,392 @@ type ChannelManager struct {
 	FeeRatePPM  uint64 `yaml:"fee_rate_ppm"`
 }
 
+// Selection configuration.
+type Selection struct {
+	Mode         string       `yaml:"mode"`
+	Diversity    Diversity    `yaml:"diversity"`
+	MarginalGain MarginalGain `yaml:"marginal_gain"`
+}
+
+// Diversity selection configuration.
+type Diversity struct {
+	// Score fraction lost for each node already selected within the same community. The penalties are
+	// pointers to tell a zero penalty from an unset one
+	CommunityPenalty *float64 `yaml:"community_penalty"`
+	// Score fraction lost when all the node's peers are shared with the nodes already selected
+	SharedPeersPenalty *float64 `yaml:"shared_peers_penalty"`
+}
+
+// MarginalGain selection configuration.
+type MarginalGain struct {
+	// Number of top-scored candidates evaluated on each pick
+	TopK int `yaml:"top_k"`
+	// Number of nodes sampled to estimate the betweenness centrality
+	Samples int `yaml:"samples"`
+}
+
+// Closing configuration.
+type Closing struct {
+	Mode          string        `yaml:"mode"`
+	Profitability Profitability `yaml:"profitability"`
+	// Lookback windows over which the channels forwarding activity is measured
+	Windows []time.Duration `yaml:"windows"`
+	// Period of time after opening a channel during which it can't be closed. It's a pointer to tell a
+	// disabled grace period from an unset one
+	GracePeriod *time.Duration `yaml:"grace_period"`
+}
+
+// Profitability closing configuration.
+type Profitability struct {
+	// Annual return the capital locked in a channel could earn elsewhere, 0.01 means 1%. It's a pointer
+	// to tell a zero rate from an unset one
+	OpportunityRate *float64 `yaml:"opportunity_rate"`
+	// Period of time the expected return of a channel is projected over
+	Horizon time.Duration `yaml:"horizon"`
+}
+
+// Fees configuration.
+type Fees struct {
+	// Strategies used for specific channels, indexed by channel point. Missing values are taken from the
+	// global strategy
+	Channels    map[string]FeeStrategy `yaml:"channels"`
+	FeeStrategy `yaml:",inline"`
+}
+
+// FeeStrategy configuration.
+type FeeStrategy struct {
+	Name      string        `yaml:"strategy"`
+	Liquidity LiquidityFees `yaml:"liquidity"`
+	PID       PIDFees       `yaml:"pid"`
+	Flat      FlatFees      `yaml:"flat"`
+	Market    MarketFees    `yaml:"market"`
+	Inbound   InboundFees   `yaml:"inbound"`
+}
+
+// LiquidityFees strategy configuration.
+type LiquidityFees struct {
+	// Fee rate of a channel with all the balance on our side. Pointer to tell a zero fee rate from an unset
+	// value
+	MinFeeRatePPM *uint64 `yaml:"min_fee_rate_ppm"`
+	// Fee rate of a channel with all the balance on the remote side
+	MaxFeeRatePPM uint64 `yaml:"max_fee_rate_ppm"`
+	// Exponent of the curve, 1 is linear and higher values keep fees low until the channel is depleted
+	Exponent float64 `yaml:"exponent"`
+}
+
+// PIDFees strategy configuration.
+type PIDFees struct {
+	// Local balance ratio the controller aims at, 0.5 means a balanced channel
+	TargetRatio float64 `yaml:"target_ratio"`
+	// Fee rate applied when the channel is at the target ratio
+	FeeRatePPM    uint64  `yaml:"fee_rate_ppm"`
+	MinFeeRatePPM uint64  `yaml:"min_fee_rate_ppm"`
+	MaxFeeRatePPM uint64  `yaml:"max_fee_rate_ppm"`
+	Kp            float64 `yaml:"kp"`
+	Ki            float64 `yaml:"ki"`
+	Kd            float64 `yaml:"kd"`
+}
+
+// FlatFees strategy configuration.
+type FlatFees struct {
+	FeeRatePPM uint64 `yaml:"fee_rate_ppm"`
+}
+
+// MarketFees strategy configuration.
+type MarketFees struct {
+	// Percentile of the competitors fee rates used as reference, 0.5 is the median
+	Percentile float64 `yaml:"percentile"`
+	// Local balance ratio above which the reference fee rate is undercut
+	HighRatio float64 `yaml:"high_ratio"`
+	// Local balance ratio below which a premium over the reference fee rate is charged
+	LowRatio float64 `yaml:"low_ratio"`
+	// Fraction the reference fee rate is lowered by, 0.1 means 10%. Pointer to tell a zero from an unset value
+	Undercut *float64 `yaml:"undercut"`
+	// Fraction the reference fee rate is raised by. Pointer to tell a zero from an unset value
+	Premium *float64 `yaml:"premium"`
+	// Minimum capacity of the competitors channels taken into account when undercutting
+	MinCapacity   uint64 `yaml:"min_capacity"`
+	MinFeeRatePPM uint64 `yaml:"min_fee_rate_ppm"`
+	MaxFeeRatePPM uint64 `yaml:"max_fee_rate_ppm"`
+}
+
+// InboundFees configuration. Inbound fees are applied on top of the fee strategy, discounting the
+// forwards coming through channels with a high local balance and charging those through depleted ones.
+type InboundFees struct {
+	// Pointer so that a channel or tag strategy can disable them when they are enabled globally
+	Enabled *bool `yaml:"enabled"`
+	// Local balance ratio above which discounts are applied
+	HighRatio float64 `yaml:"high_ratio"`
+	// Local balance ratio below which surcharges are applied
+	LowRatio float64 `yaml:"low_ratio"`
+	// Most negative fee rate, applied to a channel with all the balance on our side. Pointer to tell a zero,
+	// which applies no fee rate discount, from an unset value
+	MinFeeRatePPM *int32 `yaml:"min_fee_rate_ppm"`
+	// Most negative base fee, applied to a channel with all the balance on our side
+	MinBaseFeeMsat int32 `yaml:"min_base_fee_msat"`
+	// Highest fee rate, applied to a depleted channel. LND rejects positive values unless it runs with
+	// accept-positive-inbound-fees
+	MaxFeeRatePPM int32 `yaml:"max_fee_rate_ppm"`
+}
+
+// RoutingPolicies configuration, the routing policy values that are not set by the fee strategies.
+type RoutingPolicies struct {
+	BaseFee       BaseFeePolicy       `yaml:"base_fee"`
+	TimeLockDelta TimeLockDeltaPolicy `yaml:"time_lock_delta"`
+	MinHTLC       MinHTLCPolicy       `yaml:"min_htlc"`
+	Damping       Damping             `yaml:"damping"`
+	Status        ChannelStatusPolicy `yaml:"status"`
+	// Rules evaluated in order, the first one matching a channel decides its routing policy. The channels
+	// no rule matches are updated by the fee strategy
+	Rules []PolicyRule `yaml:"rules"`
+	// Groups of peers public keys the rules can match, indexed by name
+	Groups map[string][]string `yaml:"groups"`
+}
+
+// BaseFeePolicy configuration.
+type BaseFeePolicy struct {
+	Enabled bool `yaml:"enabled"`
+	// Base fee set on all the channels
+	Msat uint64 `yaml:"msat"`
+}
+
+// TimeLockDeltaPolicy configuration.
+type TimeLockDeltaPolicy struct {
+	Enabled bool `yaml:"enabled"`
+	// Percentile of the time lock deltas announced by the peer and by the whole network, the highest of
+	// both is used
+	Percentile float64 `yaml:"percentile"`
+	Min        uint32  `yaml:"min"`
+	Max        uint32  `yaml:"max"`
+}
+
+// MinHTLCPolicy configuration.
+type MinHTLCPolicy struct {
+	Enabled bool `yaml:"enabled"`
+	// Outgoing forwards per day from which a channel is considered busy
+	BusyForwards uint64 `yaml:"busy_forwards"`
+	// Minimum HTLC set on busy channels, smaller payments are rejected to keep their HTLC slots free
+	DustMsat uint64 `yaml:"dust_msat"`
+	// Minimum HTLC set on the rest of the channels
+	Msat uint64 `yaml:"msat"`
+}
+
+// Damping configuration, it limits how often and how much the routing policies change. Zero values disable
+// each of the limits, the values are pointers to tell them from unset ones.
+type Damping struct {
+	Enabled bool `yaml:"enabled"`
+	// Minimum time between two updates of the same channel
+	MinInterval *time.Duration `yaml:"min_interval"`
+	// Maximum relative change of a channel fee rate within a day, 0.5 means 50%
+	MaxDailyChange *float64 `yaml:"max_daily_change"`
+	// Number of consecutive updates alternating direction from which fee rate changes that would continue
+	// alternating are suppressed
+	FlipFlopUpdates *int `yaml:"flip_flop_updates"`
+	// Maximum number of updates sent across all channels per hour
+	MaxUpdatesPerHour *int `yaml:"max_updates_per_hour"`
+}
+
+// ChannelStatusPolicy configuration, it disables our direction of the channels running out of local balance
+// or whose peer is unstable, and enables it back once they recover. The thresholds to enable the channels
+// are apart from the ones disabling them to avoid toggling the status on every run.
+type ChannelStatusPolicy struct {
+	Enabled bool `yaml:"enabled"`
+	// Local balance ratio below which the channel is disabled
+	DisableLocalRatio float64 `yaml:"disable_local_ratio"`
+	// Local balance ratio from which a disabled channel is enabled again
+	EnableLocalRatio float64 `yaml:"enable_local_ratio"`
+	// Peer flaps within the window from which the channel is disabled, 0 disables the check. Pointer to
+	// tell a zero from an unset value
+	DisableFlaps *int `yaml:"disable_flaps"`
+	// Peer flaps within the window up to which a disabled channel is enabled again. Pointer to tell a zero
+	// from an unset value
+	EnableFlaps *int `yaml:"enable_flaps"`
+	// Period of time the peer flaps are counted in
+	FlapWindow time.Duration `yaml:"flap_window"`
+}
+
+// PolicyRule configuration. A rule matches the channels meeting all of its conditions.
+type PolicyRule struct {
+	Name       string         `yaml:"name"`
+	Conditions RuleConditions `yaml:"match"`
+	Actions    RuleActions    `yaml:"policy"`
+}
+
+// RuleConditions configuration. Minimum values are inclusive and maximum values exclusive, zero values
+// disable each of the conditions.
+type RuleConditions struct {
+	Channels []string `yaml:"channels"`
+	Peers    []string `yaml:"peers"`
+	// Names of the groups, the peer must belong to any of them
+	Groups      []string `yaml:"groups"`
+	Active      *bool    `yaml:"active"`
+	MinCapacity uint64   `yaml:"min_capacity"`
+	MaxCapacity uint64   `yaml:"max_capacity"`
+	// Fraction of the capacity on our side
+	MinLocalRatio float64       `yaml:"min_local_ratio"`
+	MaxLocalRatio float64       `yaml:"max_local_ratio"`
+	MinAge        time.Duration `yaml:"min_age"`
+	MaxAge        time.Duration `yaml:"max_age"`
+	MaxFlapCount  int32         `yaml:"max_flap_count"`
+	// Forwards per day within the routing policies interval
+	MinForwardsInPerDay  float64 `yaml:"min_forwards_in_per_day"`
+	MaxForwardsInPerDay  float64 `yaml:"max_forwards_in_per_day"`
+	MinForwardsOutPerDay float64 `yaml:"min_forwards_out_per_day"`
+	MaxForwardsOutPerDay float64 `yaml:"max_forwards_out_per_day"`
+	// Total capacity and number of channels of the peer in the network graph
+	MinPeerCapacity uint64 `yaml:"min_peer_capacity"`
+	MaxPeerCapacity uint64 `yaml:"max_peer_capacity"`
+	MinPeerChannels int    `yaml:"min_peer_channels"`
+	MaxPeerChannels int    `yaml:"max_peer_channels"`
+}
+
+// PeerConditions returns whether the conditions require the peer's network graph properties.
+func (r RuleConditions) PeerConditions() bool {
+	return r.MinPeerCapacity > 0 || r.MaxPeerCapacity > 0 || r.MinPeerChannels > 0 || r.MaxPeerChannels > 0
+}
+
+// RuleActions configuration, the routing policy values set on the channels matched. The values not set are
+// decided by the fee strategy and the rest of the routing policies configuration.
+type RuleActions struct {
+	// Leave the current routing policy unchanged
+	Keep        bool    `yaml:"keep"`
+	FeeRatePPM  *uint64 `yaml:"fee_rate_ppm"`
+	BaseFeeMsat *uint64 `yaml:"base_fee_msat"`
+	MinHTLCMsat *uint64 `yaml:"min_htlc_msat"`
+	// Maximum HTLC as a fraction of the local spendable balance, 0.5 means 50%
+	MaxHTLCRatio       float64 `yaml:"max_htlc_ratio"`
+	TimeLockDelta      *uint32 `yaml:"time_lock_delta"`
+	InboundBaseFeeMsat *int32  `yaml:"inbound_base_fee_msat"`
+	InboundFeeRatePPM  *int32  `yaml:"inbound_fee_rate_ppm"`
+}
+
+// empty returns whether the actions set no routing policy value.
+func (r RuleActions) empty() bool {
+	return r.FeeRatePPM == nil && r.BaseFeeMsat == nil && r.MinHTLCMsat == nil && r.MaxHTLCRatio == 0 &&
+		r.TimeLockDelta == nil && r.InboundBaseFeeMsat == nil && r.InboundFeeRatePPM == nil
+}
+
+// Tag configuration. A tag is assigned to the channels and peers matching it and changes how the agent
+// treats them.
+type Tag struct {
+	Name  string   `yaml:"name"`
+	Match TagMatch `yaml:"match"`
+	// Never close the channels tagged
+	NeverClose bool `yaml:"never_close"`
+	// Never open channels with the peers tagged
+	NeverOpen bool `yaml:"never_open"`
+	// Overrides allow_force_closes for the channels tagged
+	AllowForceCloses *bool `yaml:"allow_force_closes"`
+	// Maximum size of a channel opened with the peers tagged, as a fraction of the node capacity including
+	// the balance allocated to new channels. 0.1 means 10%
+	MaxCapacityShare float64 `yaml:"max_capacity_share"`
+	// Fee strategy used for the channels tagged, missing values are taken from the global strategy
+	Fees *FeeStrategy `yaml:"fees"`
+}
+
+// TagMatch configuration. The channels and peers matching any of the peers, channels or alias selectors
+// and meeting all the graph conditions are tagged, zero values disable each of them.
+type TagMatch struct {
+	Peers    []string `yaml:"peers"`
+	Channels []string `yaml:"channels"`
+	// Regular expression matched against the peer alias
+	Alias string `yaml:"alias"`
+	// Total capacity and number of channels of the peer in the network graph. Minimum values are inclusive
+	// and maximum values exclusive
+	MinPeerCapacity uint64 `yaml:"min_peer_capacity"`
+	MaxPeerCapacity uint64 `yaml:"max_peer_capacity"`
+	MinPeerChannels int    `yaml:"min_peer_channels"`
+	MaxPeerChannels int    `yaml:"max_peer_channels"`
+}
+
+// Selectors returns whether the match identifies peers or channels by their public key, point or alias.
+func (t TagMatch) Selectors() bool {
+	return len(t.Peers) > 0 || len(t.Channels) > 0 || t.Alias != ""
+}
+
+// PeerConditions returns whether the match requires the peer's network graph properties.
+func (t TagMatch) PeerConditions() bool {
+	return t.MinPeerCapacity > 0 || t.MaxPeerCapacity > 0 || t.MinPeerChannels > 0 || t.MaxPeerChannels > 0
+}
+
+// API configuration.
+type API struct {
+	// TCP address to listen on, its host must be a loopback address
+	Address string `yaml:"address"`
+	// Path to the unix socket to listen on
+	Socket string `yaml:"socket"`
+	// Token clients must send in the Authorization header
+	Token string `yaml:"token"`
+}
+
+// Enabled returns whether the API server should be started.
+func (a API) Enabled() bool {
+	return a.Address != "" || a.Socket != ""
+}
+
+// Metrics configuration.
+type Metrics struct {
+	// TCP address the Prometheus metrics are exposed on
+	Address string `yaml:"address"`
+}
+
+// Enabled returns whether the metrics server should be started.
+func (m Metrics) Enabled() bool {
+	return m.Address != ""
+}
+
+// Notifier configuration.
+type Notifier struct {
+	Type string `yaml:"type"`
+	// Events delivered by the notifier, all of them if empty
+	Events    []string  `yaml:"events"`
+	RateLimit RateLimit `yaml:"rate_limit"`
+	Webhook   Webhook   `yaml:"webhook"`
+	SMTP      SMTP      `yaml:"smtp"`
+	Telegram  Telegram  `yaml:"telegram"`
+	Ntfy      Ntfy      `yaml:"ntfy"`
+}
+
+// RateLimit configuration. Events exceeding the limit are dropped, a zero value disables it.
+type RateLimit struct {
+	// Maximum number of events delivered within the period
+	MaxEvents int           `yaml:"max_events"`
+	Period    time.Duration `yaml:"period"`
+}
+
+// Webhook notifier configuration.
+type Webhook struct {
+	URL string `yaml:"url"`
+}
+
+// SMTP notifier configuration.
+type SMTP struct {
+	// Mail server address in the host:port format
+	Address  string   `yaml:"address"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+	// Connect using TLS from the start (implicit TLS, usually on port 465) instead of upgrading the
+	// connection with STARTTLS
+	TLS bool `yaml:"tls"`
+}
+
+// Telegram notifier configuration.
+type Telegram struct {
+	Token  string `yaml:"token"`
+	ChatID string `yaml:"chat_id"`
+}
+
+// Ntfy notifier configuration.
+type Ntfy struct {
+	// Server URL, https://ntfy.sh by default
+	URL   string `yaml:"url"`
+	Topic string `yaml:"topic"`
+	// Access token for protected topics
+	Token string `yaml:"token"`
+}
+
 // HeuristicsWeights configuration.
 type HeuristicsWeights struct {
 	Close CloseWeights `yaml:"close"`
@@ -155,8 +631,19 @@ type RPC struct {
 	Timeout      time.Duration `yaml:"timeout"`
 }
 
-// Load returns a configuration object loaded from a file.
+// Load returns a configuration object loaded from a file and applies its logging level.
 func Load(path string) (*Config, error) {
+	config, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config.SetLoggingLevel()
+	return config, nil
+}
+
+// Read returns a configuration object loaded from a file without applying any of it.
+func Read(path string) (*Config, error) {
 	path = cmp.Or(path, os.Getenv("HYDRUS_CONFIG"))
 	if path == "" {
 		dir, err := os.UserHomeDir()
@@ -179,13 +666,10 @@ func Load(path string) (*Config, error) {
 
 	config.setDefaults()
 
-	level, err := logger.LevelFromString(strings.ToLower(config.Logging.Level))
-	if err != nil {
+	if _, err := logger.LevelFromString(strings.ToLower(config.Logging.Level)); err != nil {
 		return nil, err
 	}
 
-	logger.SetLoggingLevel(level)
-
 	if err := config.Validate(); err != nil {
 		return nil, errors.Wrap(err, "invalid configuration")
 	}
@@ -193,6 +677,12 @@ func Load(path string) (*Config, error) {
 	return config, nil
 }
 
+// SetLoggingLevel applies the logging level of the configuration, which was validated when it was read.
+func (c *Config) SetLoggingLevel() {
+	level, _ := logger.LevelFromString(strings.ToLower(c.Logging.Level))
+	logger.SetLoggingLevel(level)
+}
+
 // Validate returns an error if the configuration is not valid.
 func (c *Config) Validate() error {
 	if c.Agent.AllocationPercent <= 0 || c.Agent.AllocationPercent > 100 {
@@ -213,6 +703,99 @@ func (c *Config) Validate() error {
 		return errors.New("minimum number of channels is higher than the maximum value")
 	}
 
+	switch c.Agent.FundingStrategy {
+	case FundingStrategyEqual, FundingStrategyScore, FundingStrategyPeerMedian:
+	default:
+		return errors.Errorf("invalid funding strategy %q", c.Agent.FundingStrategy)
+	}
+
+	switch c.Agent.Selection.Mode {
+	case SelectionModeScore, SelectionModeDiversity, SelectionModeMarginalGain:
+	default:
+		return errors.Errorf("invalid selection mode %q", c.Agent.Selection.Mode)
+	}
+
+	diversity := c.Agent.Selection.Diversity
+	for _, penalty := range []*float64{diversity.CommunityPenalty, diversity.SharedPeersPenalty} {
+		if penalty != nil && (*penalty < 0 || *penalty > 1) {
+			return errors.New("diversity penalties must be between zero and one")
+		}
+	}
+
+	if c.Agent.Selection.MarginalGain.TopK < 1 || c.Agent.Selection.MarginalGain.Samples < 1 {
+		return errors.New("marginal gain top k and samples must be greater than zero")
+	}
+
+	switch c.Agent.Closing.Mode {
+	case ClosingModeScore, ClosingModeProfitability:
+	default:
+		return errors.Errorf("invalid closing mode %q", c.Agent.Closing.Mode)
+	}
+
+	if rate := c.Agent.Closing.Profitability.OpportunityRate; rate != nil && (*rate < 0 || *rate > 1) {
+		return errors.New("profitability opportunity rate must be between zero and one")
+	}
+
+	if c.Agent.Closing.Profitability.Horizon < 24*time.Hour {
+		return errors.New("profitability horizon must be at least one day")
+	}
+
+	if len(c.Agent.Closing.Windows) == 0 {
+		return errors.New("at least one closing window is required")
+	}
+
+	for _, window := range c.Agent.Closing.Windows {
+		if window < 24*time.Hour {
+			return errors.New("closing windows must be at least one day long")
+		}
+	}
+
+	if gracePeriod := c.Agent.Closing.GracePeriod; gracePeriod != nil && *gracePeriod < 0 {
+		return errors.New("closing grace period must not be negative")
+	}
+
+	if err := c.Agent.Fees.validate(); err != nil {
+		return errors.Wrap(err, "invalid fees configuration")
+	}
+
+	for channelPoint, strategy := range c.Agent.Fees.Channels {
+		if err := strategy.validate(); err != nil {
+			return errors.Wrapf(err, "invalid %q channel fees configuration", channelPoint)
+		}
+	}
+
+	names := make(map[string]bool, len(c.Agent.Tags))
+	for i, tag := range c.Agent.Tags {
+		if err := tag.validate(); err != nil {
+			return errors.Wrapf(err, "invalid tag %d", i)
+		}
+
+		if names[tag.Name] {
+			return errors.Errorf("duplicate tag name %q", tag.Name)
+		}
+		names[tag.Name] = true
+	}
+
+	if err := c.Agent.RoutingPolicies.validate(); err != nil {
+		return errors.Wrap(err, "invalid routing policies configuration")
+	}
+
+	if err := c.Agent.API.validate(); err != nil {
+		return errors.Wrap(err, "invalid api configuration")
+	}
+
+	if c.Agent.Metrics.Enabled() {
+		if _, _, err := net.SplitHostPort(c.Agent.Metrics.Address); err != nil {
+			return errors.Wrap(err, "invalid metrics address")
+		}
+	}
+
+	for i, notifier := range c.Agent.Notifiers {
+		if err := notifier.validate(); err != nil {
+			return errors.Wrapf(err, "invalid notifier %d configuration", i)
+		}
+	}
+
 	if c.Agent.ChannelManager.MinConf == 0 {
 		return errors.New("invalid channel manager transcations minimum confirmations")
 	}
@@ -274,6 +857,356 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+func (a API) validate() error {
+	if !a.Enabled() {
+		return nil
+	}
+
+	if a.Address != "" && a.Socket != "" {
+		return errors.New("address and socket are mutually exclusive")
+	}
+
+	if a.Token == "" {
+		return errors.New("token is required")
+	}
+
+	if a.Address == "" {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(a.Address)
+	if err != nil {
+		return errors.Wrap(err, "invalid address")
+	}
+
+	if host == "localhost" {
+		return nil
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return errors.Errorf("address host %q is not a loopback address", host)
+	}
+
+	return nil
+}
+
+func (f FeeStrategy) validate() error {
+	switch f.Name {
+	case FeeStrategyDefault, FeeStrategyLiquidity, FeeStrategyPID, FeeStrategyFlat, FeeStrategyMarket:
+	default:
+		return errors.Errorf("invalid strategy %q", f.Name)
+	}
+
+	if minFeeRate := f.Liquidity.MinFeeRatePPM; minFeeRate != nil && *minFeeRate > f.Liquidity.MaxFeeRatePPM {
+		return errors.New("liquidity minimum fee rate is higher than the maximum value")
+	}
+
+	if f.Liquidity.Exponent <= 0 {
+		return errors.New("liquidity exponent must be greater than zero")
+	}
+
+	if f.PID.TargetRatio < 0 || f.PID.TargetRatio > 1 {
+		return errors.New("pid target ratio must be between zero and one")
+	}
+
+	if f.PID.MinFeeRatePPM > f.PID.MaxFeeRatePPM ||
+		f.PID.FeeRatePPM < f.PID.MinFeeRatePPM || f.PID.FeeRatePPM > f.PID.MaxFeeRatePPM {
+		return errors.New("pid fee rate must be between the minimum and maximum values")
+	}
+
+	if f.PID.Kp < 0 || f.PID.Ki < 0 || f.PID.Kd < 0 {
+		return errors.New("pid gains must not be negative")
+	}
+
+	market := f.Market
+	if market.Percentile < 0 || market.Percentile > 1 {
+		return errors.New("market percentile must be between zero and one")
+	}
+
+	if market.LowRatio < 0 || market.HighRatio > 1 || market.LowRatio >= market.HighRatio {
+		return errors.New("market ratios must be between zero and one and the low ratio lower than the high one")
+	}
+
+	if (market.Undercut != nil && (*market.Undercut < 0 || *market.Undercut >= 1)) ||
+		(market.Premium != nil && *market.Premium < 0) {
+		return errors.New("market undercut must be between zero and one and the premium must not be negative")
+	}
+
+	if market.MinFeeRatePPM > market.MaxFeeRatePPM {
+		return errors.New("market minimum fee rate is higher than the maximum value")
+	}
+
+	inbound := f.Inbound
+	if inbound.LowRatio < 0 || inbound.HighRatio > 1 || inbound.LowRatio >= inbound.HighRatio {
+		return errors.New("inbound ratios must be between zero and one and the low ratio lower than the high one")
+	}
+
+	if (inbound.MinFeeRatePPM != nil && *inbound.MinFeeRatePPM > 0) || inbound.MinBaseFeeMsat > 0 {
+		return errors.New("inbound minimum fees must not be positive")
+	}
+
+	if inbound.MaxFeeRatePPM < 0 {
+		return errors.New("inbound maximum fee rate must not be negative")
+	}
+
+	return nil
+}
+
+// withDefaults returns the strategy with its missing values taken from the defaults, field by field.
+func (f FeeStrategy) withDefaults(defaults FeeStrategy) FeeStrategy {
+	f.Name = cmp.Or(f.Name, defaults.Name)
+
+	liquidity := &f.Liquidity
+	liquidity.MinFeeRatePPM = cmp.Or(liquidity.MinFeeRatePPM, defaults.Liquidity.MinFeeRatePPM)
+	liquidity.MaxFeeRatePPM = cmp.Or(liquidity.MaxFeeRatePPM, defaults.Liquidity.MaxFeeRatePPM)
+	liquidity.Exponent = cmp.Or(liquidity.Exponent, defaults.Liquidity.Exponent)
+
+	pid := &f.PID
+	pid.TargetRatio = cmp.Or(pid.TargetRatio, defaults.PID.TargetRatio)
+	pid.FeeRatePPM = cmp.Or(pid.FeeRatePPM, defaults.PID.FeeRatePPM)
+	pid.MinFeeRatePPM = cmp.Or(pid.MinFeeRatePPM, defaults.PID.MinFeeRatePPM)
+	pid.MaxFeeRatePPM = cmp.Or(pid.MaxFeeRatePPM, defaults.PID.MaxFeeRatePPM)
+	// The gains are tuned together and zero is a valid gain, take them from the defaults only if none is set
+	if pid.Kp == 0 && pid.Ki == 0 && pid.Kd == 0 {
+		pid.Kp, pid.Ki, pid.Kd = defaults.PID.Kp, defaults.PID.Ki, defaults.PID.Kd
+	}
+
+	f.Flat.FeeRatePPM = cmp.Or(f.Flat.FeeRatePPM, defaults.Flat.FeeRatePPM)
+
+	market := &f.Market
+	market.Percentile = cmp.Or(market.Percentile, defaults.Market.Percentile)
+	market.HighRatio = cmp.Or(market.HighRatio, defaults.Market.HighRatio)
+	market.LowRatio = cmp.Or(market.LowRatio, defaults.Market.LowRatio)
+	market.Undercut = cmp.Or(market.Undercut, defaults.Market.Undercut)
+	market.Premium = cmp.Or(market.Premium, defaults.Market.Premium)
+	market.MinCapacity = cmp.Or(market.MinCapacity, defaults.Market.MinCapacity)
+	market.MinFeeRatePPM = cmp.Or(market.MinFeeRatePPM, defaults.Market.MinFeeRatePPM)
+	market.MaxFeeRatePPM = cmp.Or(market.MaxFeeRatePPM, defaults.Market.MaxFeeRatePPM)
+
+	inbound := &f.Inbound
+	inbound.Enabled = cmp.Or(inbound.Enabled, defaults.Inbound.Enabled)
+	inbound.HighRatio = cmp.Or(inbound.HighRatio, defaults.Inbound.HighRatio)
+	inbound.LowRatio = cmp.Or(inbound.LowRatio, defaults.Inbound.LowRatio)
+	inbound.MinFeeRatePPM = cmp.Or(inbound.MinFeeRatePPM, defaults.Inbound.MinFeeRatePPM)
+	inbound.MinBaseFeeMsat = cmp.Or(inbound.MinBaseFeeMsat, defaults.Inbound.MinBaseFeeMsat)
+	inbound.MaxFeeRatePPM = cmp.Or(inbound.MaxFeeRatePPM, defaults.Inbound.MaxFeeRatePPM)
+
+	return f
+}
+
+func (r RoutingPolicies) validate() error {
+	timeLockDelta := r.TimeLockDelta
+	if timeLockDelta.Enabled {
+		if timeLockDelta.Percentile <= 0 || timeLockDelta.Percentile > 1 {
+			return errors.New("time lock delta percentile must be greater than zero and lower or equal to one")
+		}
+
+		if timeLockDelta.Min < minTimeLockDelta || timeLockDelta.Min > timeLockDelta.Max {
+			return errors.Errorf("time lock delta minimum must be at least %d and lower than the maximum",
+				minTimeLockDelta,
+			)
+		}
+	}
+
+	minHTLC := r.MinHTLC
+	if minHTLC.Enabled {
+		if minHTLC.Msat == 0 || minHTLC.Msat > minHTLC.DustMsat {
+			return errors.New("min htlc must be greater than zero and lower than the dust value")
+		}
+	}
+
+	damping := r.Damping
+	if (damping.MinInterval != nil && *damping.MinInterval < 0) ||
+		(damping.MaxDailyChange != nil && *damping.MaxDailyChange < 0) ||
+		(damping.FlipFlopUpdates != nil && *damping.FlipFlopUpdates < 0) ||
+		(damping.MaxUpdatesPerHour != nil && *damping.MaxUpdatesPerHour < 0) {
+		return errors.New("damping values must not be negative")
+	}
+
+	if err := r.Status.validate(); err != nil {
+		return err
+	}
+
+	names := make(map[string]bool, len(r.Rules))
+	for i, rule := range r.Rules {
+		if err := rule.validate(r.Groups); err != nil {
+			return errors.Wrapf(err, "invalid rule %d", i)
+		}
+
+		if names[rule.Name] {
+			return errors.Errorf("duplicate rule name %q", rule.Name)
+		}
+		names[rule.Name] = true
+	}
+
+	return nil
+}
+
+// Flaps returns the number of peer flaps from which a channel is disabled and up to which it's enabled
+// again, 0 if they are not set.
+func (c ChannelStatusPolicy) Flaps() (disable, enable int) {
+	if c.DisableFlaps != nil {
+		disable = *c.DisableFlaps
+	}
+	if c.EnableFlaps != nil {
+		enable = *c.EnableFlaps
+	}
+	return disable, enable
+}
+
+func (c ChannelStatusPolicy) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.DisableLocalRatio < 0 || c.EnableLocalRatio > 1 || c.DisableLocalRatio >= c.EnableLocalRatio {
+		return errors.New("status local ratios must be between zero and one and the disable ratio lower " +
+			"than the enable one")
+	}
+
+	disableFlaps, enableFlaps := c.Flaps()
+	if disableFlaps < 0 || enableFlaps < 0 {
+		return errors.New("status flaps must not be negative")
+	}
+
+	if disableFlaps > 0 {
+		if enableFlaps >= disableFlaps {
+			return errors.New("status enable flaps must be lower than the disable flaps")
+		}
+
+		if c.FlapWindow <= 0 {
+			return errors.New("status flap window must be greater than zero")
+		}
+	}
+
+	return nil
+}
+
+func (p PolicyRule) validate(groups map[string][]string) error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+
+	conditions := p.Conditions
+	for _, group := range conditions.Groups {
+		if _, ok := groups[group]; !ok {
+			return errors.Errorf("unknown group %q", group)
+		}
+	}
+
+	if conditions.MinLocalRatio < 0 || conditions.MaxLocalRatio < 0 ||
+		conditions.MinLocalRatio > 1 || conditions.MaxLocalRatio > 1 {
+		return errors.New("local ratios must be between zero and one")
+	}
+
+	if conditions.MinAge < 0 || conditions.MaxAge < 0 || conditions.MaxFlapCount < 0 ||
+		conditions.MinForwardsInPerDay < 0 || conditions.MaxForwardsInPerDay < 0 ||
+		conditions.MinForwardsOutPerDay < 0 || conditions.MaxForwardsOutPerDay < 0 ||
+		conditions.MinPeerChannels < 0 || conditions.MaxPeerChannels < 0 {
+		return errors.New("conditions values must not be negative")
+	}
+
+	actions := p.Actions
+	if actions.Keep {
+		if !actions.empty() {
+			return errors.New("keep can't be combined with other policy values")
+		}
+		return nil
+	}
+
+	if actions.empty() {
+		return errors.New("at least one policy value is required")
+	}
+
+	if actions.MaxHTLCRatio < 0 || actions.MaxHTLCRatio > 1 {
+		return errors.New("max htlc ratio must be between zero and one")
+	}
+
+	if actions.TimeLockDelta != nil && *actions.TimeLockDelta < minTimeLockDelta {
+		return errors.Errorf("time lock delta must be at least %d", minTimeLockDelta)
+	}
+
+	return nil
+}
+
+func (t Tag) validate() error {
+	switch t.Name {
+	case "":
+		return errors.New("name is required")
+	case TagBlocklist, TagKeeplist:
+		return errors.Errorf("name %q is reserved", t.Name)
+	}
+
+	match := t.Match
+	if !match.Selectors() && !match.PeerConditions() {
+		return errors.New("at least one match condition is required")
+	}
+
+	if _, err := regexp.Compile(match.Alias); err != nil {
+		return errors.Wrap(err, "invalid alias regular expression")
+	}
+
+	if match.MinPeerChannels < 0 || match.MaxPeerChannels < 0 {
+		return errors.New("peer channels must not be negative")
+	}
+
+	if t.MaxCapacityShare < 0 || t.MaxCapacityShare > 1 {
+		return errors.New("max capacity share must be between zero and one")
+	}
+
+	if t.Fees != nil {
+		if err := t.Fees.validate(); err != nil {
+			return errors.Wrap(err, "invalid fees configuration")
+		}
+	}
+
+	return nil
+}
+
+func (n Notifier) validate() error {
+	for _, event := range n.Events {
+		switch event {
+		case EventChannelsOpened, EventChannelClosed, EventRunSkipped, EventTaskError:
+		default:
+			return errors.Errorf("invalid event %q", event)
+		}
+	}
+
+	if n.RateLimit.MaxEvents < 0 || n.RateLimit.Period < 0 {
+		return errors.New("rate limit values must not be negative")
+	}
+
+	if (n.RateLimit.MaxEvents == 0) != (n.RateLimit.Period == 0) {
+		return errors.New("rate limit requires both the maximum number of events and the period")
+	}
+
+	switch n.Type {
+	case NotifierTypeWebhook:
+		if n.Webhook.URL == "" {
+			return errors.New("webhook url is required")
+		}
+	case NotifierTypeSMTP:
+		if _, _, err := net.SplitHostPort(n.SMTP.Address); err != nil {
+			return errors.Wrap(err, "invalid smtp address")
+		}
+		if n.SMTP.From == "" || len(n.SMTP.To) == 0 {
+			return errors.New("smtp sender and recipients are required")
+		}
+	case NotifierTypeTelegram:
+		if n.Telegram.Token == "" || n.Telegram.ChatID == "" {
+			return errors.New("telegram token and chat id are required")
+		}
+	case NotifierTypeNtfy:
+		if n.Ntfy.Topic == "" {
+			return errors.New("ntfy topic is required")
+		}
+	default:
+		return errors.Errorf("invalid type %q", n.Type)
+	}
+
+	return nil
+}
+
 func (c *Config) setDefaults() {
 	if c.Agent.AllocationPercent == 0 {
 		c.Agent.AllocationPercent = 80
@@ -299,6 +1232,151 @@ func (c *Config) setDefaults() {
 		c.Agent.MaxChannelSize = 10_000_000
 	}
 
+	if c.Agent.FundingStrategy == "" {
+		c.Agent.FundingStrategy = FundingStrategyEqual
+	}
+
+	if c.Agent.Selection.Mode == "" {
+		c.Agent.Selection.Mode = SelectionModeScore
+	}
+
+	if c.Agent.Selection.Diversity.CommunityPenalty == nil {
+		communityPenalty := 0.5
+		c.Agent.Selection.Diversity.CommunityPenalty = &communityPenalty
+	}
+
+	if c.Agent.Selection.Diversity.SharedPeersPenalty == nil {
+		sharedPeersPenalty := 0.5
+		c.Agent.Selection.Diversity.SharedPeersPenalty = &sharedPeersPenalty
+	}
+
+	if c.Agent.Selection.MarginalGain.TopK == 0 {
+		c.Agent.Selection.MarginalGain.TopK = 10
+	}
+
+	if c.Agent.Selection.MarginalGain.Samples == 0 {
+		c.Agent.Selection.MarginalGain.Samples = 100
+	}
+
+	if c.Agent.Closing.Mode == "" {
+		c.Agent.Closing.Mode = ClosingModeScore
+	}
+
+	if c.Agent.Closing.Profitability.OpportunityRate == nil {
+		opportunityRate := 0.01
+		c.Agent.Closing.Profitability.OpportunityRate = &opportunityRate
+	}
+
+	if c.Agent.Closing.Profitability.Horizon == 0 {
+		c.Agent.Closing.Profitability.Horizon = 90 * 24 * time.Hour
+	}
+
+	if len(c.Agent.Closing.Windows) == 0 {
+		c.Agent.Closing.Windows = []time.Duration{7 * 24 * time.Hour, 30 * 24 * time.Hour, 90 * 24 * time.Hour}
+	}
+
+	if c.Agent.Closing.GracePeriod == nil {
+		gracePeriod := 30 * 24 * time.Hour
+		c.Agent.Closing.GracePeriod = &gracePeriod
+	}
+
+	liquidityMinFeeRate := uint64(50)
+	marketUndercut, marketPremium := 0.1, 0.25
+	inboundEnabled := false
+	inboundMinFeeRate := int32(-200)
+	c.Agent.Fees.FeeStrategy = c.Agent.Fees.withDefaults(FeeStrategy{
+		Name: FeeStrategyDefault,
+		Liquidity: LiquidityFees{
+			MinFeeRatePPM: &liquidityMinFeeRate,
+			MaxFeeRatePPM: 2_000,
+			Exponent:      1,
+		},
+		PID: PIDFees{
+			TargetRatio:   0.5,
+			FeeRatePPM:    500,
+			MaxFeeRatePPM: 5_000,
+			Kp:            1_000,
+			Ki:            200,
+		},
+		Market: MarketFees{
+			Percentile:    0.5,
+			HighRatio:     0.7,
+			LowRatio:      0.3,
+			Undercut:      &marketUndercut,
+			Premium:       &marketPremium,
+			MinCapacity:   1_000_000,
+			MaxFeeRatePPM: 5_000,
+		},
+		Inbound: InboundFees{
+			Enabled:       &inboundEnabled,
+			HighRatio:     0.8,
+			LowRatio:      0.2,
+			MinFeeRatePPM: &inboundMinFeeRate,
+		},
+	})
+
+	for channelPoint, strategy := range c.Agent.Fees.Channels {
+		c.Agent.Fees.Channels[channelPoint] = strategy.withDefaults(c.Agent.Fees.FeeStrategy)
+	}
+
+	for _, tag := range c.Agent.Tags {
+		if tag.Fees != nil {
+			*tag.Fees = tag.Fees.withDefaults(c.Agent.Fees.FeeStrategy)
+		}
+	}
+
+	// Zero isn't a valid value for any of these
+	timeLockDelta := &c.Agent.RoutingPolicies.TimeLockDelta
+	timeLockDelta.Percentile = cmp.Or(timeLockDelta.Percentile, 0.5)
+	timeLockDelta.Min = cmp.Or(timeLockDelta.Min, 40)
+	timeLockDelta.Max = cmp.Or(timeLockDelta.Max, 144)
+
+	minHTLC := &c.Agent.RoutingPolicies.MinHTLC
+	minHTLC.BusyForwards = cmp.Or(minHTLC.BusyForwards, 50)
+	minHTLC.DustMsat = cmp.Or(minHTLC.DustMsat, 1_000_000)
+	minHTLC.Msat = cmp.Or(minHTLC.Msat, 1_000)
+
+	damping := &c.Agent.RoutingPolicies.Damping
+	if damping.MinInterval == nil {
+		minInterval := 12 * time.Hour
+		damping.MinInterval = &minInterval
+	}
+
+	if damping.MaxDailyChange == nil {
+		maxDailyChange := 0.5
+		damping.MaxDailyChange = &maxDailyChange
+	}
+
+	if damping.FlipFlopUpdates == nil {
+		flipFlopUpdates := 3
+		damping.FlipFlopUpdates = &flipFlopUpdates
+	}
+
+	if damping.MaxUpdatesPerHour == nil {
+		maxUpdatesPerHour := 30
+		damping.MaxUpdatesPerHour = &maxUpdatesPerHour
+	}
+
+	status := &c.Agent.RoutingPolicies.Status
+	status.DisableLocalRatio = cmp.Or(status.DisableLocalRatio, 0.01)
+	status.EnableLocalRatio = cmp.Or(status.EnableLocalRatio, 0.05)
+	status.FlapWindow = cmp.Or(status.FlapWindow, 24*time.Hour)
+	if status.DisableFlaps == nil {
+		disableFlaps := 10
+		status.DisableFlaps = &disableFlaps
+	}
+
+	if status.EnableFlaps == nil {
+		enableFlaps := 2
+		status.EnableFlaps = &enableFlaps
+	}
+
+	if c.Agent.DataDir == "" {
+		if dir, err := os.UserHomeDir(); err == nil {
+			c.Agent.DataDir = filepath.Join(dir, ".hydrus")
+		}
+	}
+
 	if c.Agent.ChannelManager.MinConf == 0 {
 		c.Agent.ChannelManager.MinConf = 2
 	}
@@ -30,6 +30,14 @@ func TestLoadEnvVariable(t *testing.T) {
 	assert.Equal(t, int32(2), config.Agent.ChannelManager.MinConf)
 }
 
+func TestRead(t *testing.T) {
+	config, err := Read("./testdata/hydrus.yml")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "info", config.Logging.Level)
+	assert.Equal(t, int32(2), config.Agent.ChannelManager.MinConf)
+}
+
 func TestLoadError(t *testing.T) {
 	os.Setenv("HYDRUS_CONFIG", "invalid")
 
@@ -68,6 +76,376 @@ func TestValidate(t *testing.T) {
 			setup: func(c *Config) { c.Agent.MinChannels = 2; c.Agent.MaxChannels = 1 },
 			fail:  true,
 		},
+		{
+			name:  "Invalid funding strategy",
+			setup: func(c *Config) { c.Agent.FundingStrategy = "random" },
+			fail:  true,
+		},
+		{
+			name:  "Invalid selection mode",
+			setup: func(c *Config) { c.Agent.Selection.Mode = "random" },
+			fail:  true,
+		},
+		{
+			name: "Diversity penalty over one",
+			setup: func(c *Config) {
+				penalty := 1.5
+				c.Agent.Selection.Diversity.CommunityPenalty = &penalty
+			},
+			fail: true,
+		},
+		{
+			name:  "Negative marginal gain top k",
+			setup: func(c *Config) { c.Agent.Selection.MarginalGain.TopK = -1 },
+			fail:  true,
+		},
+		{
+			name:  "Invalid closing mode",
+			setup: func(c *Config) { c.Agent.Closing.Mode = "random" },
+			fail:  true,
+		},
+		{
+			name: "Opportunity rate over one",
+			setup: func(c *Config) {
+				opportunityRate := 2.0
+				c.Agent.Closing.Profitability.OpportunityRate = &opportunityRate
+			},
+			fail: true,
+		},
+		{
+			name:  "Profitability horizon too short",
+			setup: func(c *Config) { c.Agent.Closing.Profitability.Horizon = time.Hour },
+			fail:  true,
+		},
+		{
+			name:  "Closing window too short",
+			setup: func(c *Config) { c.Agent.Closing.Windows = []time.Duration{time.Hour} },
+			fail:  true,
+		},
+		{
+			name: "Negative grace period",
+			setup: func(c *Config) {
+				gracePeriod := -time.Hour
+				c.Agent.Closing.GracePeriod = &gracePeriod
+			},
+			fail: true,
+		},
+		{
+			name: "API on localhost",
+			setup: func(c *Config) {
+				validConfig(c)
+				c.Agent.API = API{Address: "127.0.0.1:7070", Token: "secret"}
+			},
+			fail: false,
+		},
+		{
+			name: "API on a public address",
+			setup: func(c *Config) {
+				c.Agent.API = API{Address: "0.0.0.0:7070", Token: "secret"}
+			},
+			fail: true,
+		},
+		{
+			name: "API without token",
+			setup: func(c *Config) {
+				c.Agent.API = API{Socket: "/tmp/hydrus.sock"}
+			},
+			fail: true,
+		},
+		{
+			name:  "Invalid fee strategy",
+			setup: func(c *Config) { c.Agent.Fees.Name = "random" },
+			fail:  true,
+		},
+		{
+			name: "Invalid liquidity fee rates",
+			setup: func(c *Config) {
+				minFeeRate := uint64(1_000)
+				c.Agent.Fees.Liquidity = LiquidityFees{MinFeeRatePPM: &minFeeRate, MaxFeeRatePPM: 100, Exponent: 1}
+			},
+			fail: true,
+		},
+		{
+			name: "PID fee rate out of bounds",
+			setup: func(c *Config) {
+				c.Agent.Fees.PID.FeeRatePPM = c.Agent.Fees.PID.MaxFeeRatePPM + 1
+			},
+			fail: true,
+		},
+		{
+			name: "Positive inbound discount",
+			setup: func(c *Config) {
+				minFeeRate := int32(10)
+				c.Agent.Fees.Inbound.MinFeeRatePPM = &minFeeRate
+			},
+			fail: true,
+		},
+		{
+			name: "Invalid inbound ratios",
+			setup: func(c *Config) {
+				c.Agent.Fees.Inbound.LowRatio = 0.9
+			},
+			fail: true,
+		},
+		{
+			name: "Invalid market ratios",
+			setup: func(c *Config) {
+				c.Agent.Fees.Market.HighRatio = 0.2
+			},
+			fail: true,
+		},
+		{
+			name: "Market undercut over one",
+			setup: func(c *Config) {
+				undercut := 1.0
+				c.Agent.Fees.Market.Undercut = &undercut
+			},
+			fail: true,
+		},
+		{
+			name: "Time lock delta below the minimum",
+			setup: func(c *Config) {
+				c.Agent.RoutingPolicies.TimeLockDelta.Enabled = true
+				c.Agent.RoutingPolicies.TimeLockDelta.Min = 10
+			},
+			fail: true,
+		},
+		{
+			name: "Invalid time lock delta percentile",
+			setup: func(c *Config) {
+				c.Agent.RoutingPolicies.TimeLockDelta.Enabled = true
+				c.Agent.RoutingPolicies.TimeLockDelta.Percentile = 1.5
+			},
+			fail: true,
+		},
+		{
+			name: "Min HTLC higher than the dust value",
+			setup: func(c *Config) {
+				c.Agent.RoutingPolicies.MinHTLC.Enabled = true
+				c.Agent.RoutingPolicies.MinHTLC.Msat = 2_000_000
+			},
+			fail: true,
+		},
+		{
+			name: "Negative damping value",
+			setup: func(c *Config) {
+				maxDailyChange := -1.0
+				c.Agent.RoutingPolicies.Damping.Enabled = true
+				c.Agent.RoutingPolicies.Damping.MaxDailyChange = &maxDailyChange
+			},
+			fail: true,
+		},
+		{
+			name: "Invalid channel fee strategy",
+			setup: func(c *Config) {
+				c.Agent.Fees.Channels = map[string]FeeStrategy{"txid:0": {Name: "random"}}
+			},
+			fail: true,
+		},
+		{
+			name: "Channel fee strategy",
+			setup: func(c *Config) {
+				validConfig(c)
+				c.Agent.Fees.Channels = map[string]FeeStrategy{"txid:0": c.Agent.Fees.FeeStrategy}
+			},
+			fail: false,
+		},
+		{
+			name: "Tags",
+			setup: func(c *Config) {
+				validConfig(c)
+				allowForceCloses := true
+				fees := c.Agent.Fees.FeeStrategy
+				fees.Name = FeeStrategyFlat
+				c.Agent.Tags = []Tag{
+					{Name: "exchanges", Match: TagMatch{Alias: "(?i)exchange"}, Fees: &fees, MaxCapacityShare: 0.1},
+					{Name: "small", Match: TagMatch{MaxPeerChannels: 10}, NeverOpen: true},
+					{Name: "friends", Match: TagMatch{Peers: []string{"pubkey"}}, AllowForceCloses: &allowForceCloses},
+				}
+			},
+			fail: false,
+		},
+		{
+			name: "Tag without match conditions",
+			setup: func(c *Config) {
+				validConfig(c)
+				c.Agent.Tags = []Tag{{Name: "all", NeverClose: true}}
+			},
+			fail: true,
+		},
+		{
+			name: "Tag with an invalid alias expression",
+			setup: func(c *Config) {
+				validConfig(c)
+				c.Agent.Tags = []Tag{{Name: "invalid", Match: TagMatch{Alias: "("}}}
+			},
+			fail: true,
+		},
+		{
+			name: "Tag with a reserved name",
+			setup: func(c *Config) {
+				validConfig(c)
+				c.Agent.Tags = []Tag{{Name: TagKeeplist, Match: TagMatch{Peers: []string{"pubkey"}}}}
+			},
+			fail: true,
+		},
+		{
+			name: "Duplicate tag names",
+			setup: func(c *Config) {
+				validConfig(c)
+				tag := Tag{Name: "friends", Match: TagMatch{Peers: []string{"pubkey"}}}
+				c.Agent.Tags = []Tag{tag, tag}
+			},
+			fail: true,
+		},
+		{
+			name: "Tag capacity share over one",
+			setup: func(c *Config) {
+				validConfig(c)
+				c.Agent.Tags = []Tag{{Name: "big", Match: TagMatch{Peers: []string{"pubkey"}}, MaxCapacityShare: 2}}
+			},
+			fail: true,
+		},
+		{
+			name: "Status enable ratio lower than the disable one",
+			setup: func(c *Config) {
+				validConfig(c)
+				c.Agent.RoutingPolicies.Status.Enabled = true
+				c.Agent.RoutingPolicies.Status.EnableLocalRatio = 0.005
+			},
+			fail: true,
+		},
+		{
+			name: "Status enable flaps higher than the disable ones",
+			setup: func(c *Config) {
+				validConfig(c)
+				c.Agent.RoutingPolicies.Status.Enabled = true
+				enableFlaps := 20
+				c.Agent.RoutingPolicies.Status.EnableFlaps = &enableFlaps
+			},
+			fail: true,
+		},
+		{
+			name: "Policy rules",
+			setup: func(c *Config) {
+				validConfig(c)
+				feeRate := uint64(1_500)
+				c.Agent.RoutingPolicies.Groups = map[string][]string{"sinks": {"pubkey"}}
+				c.Agent.RoutingPolicies.Rules = []PolicyRule{
+					{
+						Name:       "depleted sinks",
+						Conditions: RuleConditions{Groups: []string{"sinks"}, MaxLocalRatio: 0.2},
+						Actions:    RuleActions{FeeRatePPM: &feeRate, MaxHTLCRatio: 0.5},
+					},
+					{
+						Name:       "new channels",
+						Conditions: RuleConditions{MaxAge: 7 * 24 * time.Hour},
+						Actions:    RuleActions{Keep: true},
+					},
+				}
+			},
+			fail: false,
+		},
+		{
+			name: "Policy rule with an unknown group",
+			setup: func(c *Config) {
+				validConfig(c)
+				c.Agent.RoutingPolicies.Rules = []PolicyRule{
+					{Name: "sinks", Conditions: RuleConditions{Groups: []string{"sinks"}}, Actions: RuleActions{Keep: true}},
+				}
+			},
+			fail: true,
+		},
+		{
+			name: "Policy rule without actions",
+			setup: func(c *Config) {
+				validConfig(c)
+				c.Agent.RoutingPolicies.Rules = []PolicyRule{{Name: "empty"}}
+			},
+			fail: true,
+		},
+		{
+			name: "Policy rule keeping and setting values",
+			setup: func(c *Config) {
+				validConfig(c)
+				feeRate := uint64(1_500)
+				c.Agent.RoutingPolicies.Rules = []PolicyRule{
+					{Name: "keep", Actions: RuleActions{Keep: true, FeeRatePPM: &feeRate}},
+				}
+			},
+			fail: true,
+		},
+		{
+			name: "Duplicate policy rule names",
+			setup: func(c *Config) {
+				validConfig(c)
+				c.Agent.RoutingPolicies.Rules = []PolicyRule{
+					{Name: "keep", Actions: RuleActions{Keep: true}},
+					{Name: "keep", Actions: RuleActions{Keep: true}},
+				}
+			},
+			fail: true,
+		},
+		{
+			name:  "Invalid metrics address",
+			setup: func(c *Config) { c.Agent.Metrics.Address = "9090" },
+			fail:  true,
+		},
+		{
+			name: "Valid notifiers",
+			setup: func(c *Config) {
+				validConfig(c)
+				c.Agent.Notifiers = []Notifier{
+					{
+						Type:      NotifierTypeWebhook,
+						Events:    []string{EventChannelsOpened, EventTaskError},
+						RateLimit: RateLimit{MaxEvents: 10, Period: time.Hour},
+						Webhook:   Webhook{URL: "http://127.0.0.1:8080/hydrus"},
+					},
+					{
+						Type: NotifierTypeSMTP,
+						SMTP: SMTP{Address: "smtp.example.com:587", From: "hydrus@example.com", To: []string{"me@example.com"}},
+					},
+					{Type: NotifierTypeTelegram, Telegram: Telegram{Token: "token", ChatID: "1234"}},
+					{Type: NotifierTypeNtfy, Ntfy: Ntfy{Topic: "hydrus"}},
+				}
+			},
+			fail: false,
+		},
+		{
+			name: "Invalid notifier type",
+			setup: func(c *Config) {
+				c.Agent.Notifiers = []Notifier{{Type: "pigeon"}}
+			},
+			fail: true,
+		},
+		{
+			name: "Invalid notifier event",
+			setup: func(c *Config) {
+				c.Agent.Notifiers = []Notifier{
+					{Type: NotifierTypeNtfy, Events: []string{"channel_opened"}, Ntfy: Ntfy{Topic: "hydrus"}},
+				}
+			},
+			fail: true,
+		},
+		{
+			name: "Incomplete notifier rate limit",
+			setup: func(c *Config) {
+				c.Agent.Notifiers = []Notifier{
+					{Type: NotifierTypeNtfy, RateLimit: RateLimit{MaxEvents: 5}, Ntfy: Ntfy{Topic: "hydrus"}},
+				}
+			},
+			fail: true,
+		},
+		{
+			name: "SMTP notifier without recipients",
+			setup: func(c *Config) {
+				c.Agent.Notifiers = []Notifier{
+					{Type: NotifierTypeSMTP, SMTP: SMTP{Address: "smtp.example.com:587", From: "hydrus@example.com"}},
+				}
+			},
+			fail: true,
+		},
 		{
 			name:  "Invalid channel manager min confirmations",
 			setup: func(c *Config) { c.Agent.ChannelManager.MinConf = 0 },
@@ -154,6 +532,43 @@ func TestSetDefaults(t *testing.T) {
 	assert.Equal(t, int32(6), config.Agent.TargetConf)
 	assert.Equal(t, uint64(1_000_000), config.Agent.MinChannelSize)
 	assert.Equal(t, uint64(10_000_000), config.Agent.MaxChannelSize)
+	assert.Equal(t, FundingStrategyEqual, config.Agent.FundingStrategy)
+	assert.Equal(t, SelectionModeScore, config.Agent.Selection.Mode)
+	assert.Equal(t, 0.5, *config.Agent.Selection.Diversity.CommunityPenalty)
+	assert.Equal(t, 0.5, *config.Agent.Selection.Diversity.SharedPeersPenalty)
+	assert.Equal(t, 10, config.Agent.Selection.MarginalGain.TopK)
+	assert.Equal(t, 100, config.Agent.Selection.MarginalGain.Samples)
+	assert.Equal(t, ClosingModeScore, config.Agent.Closing.Mode)
+	assert.Equal(t, 0.01, *config.Agent.Closing.Profitability.OpportunityRate)
+	assert.Equal(t, time.Duration(time.Hour*24*90), config.Agent.Closing.Profitability.Horizon)
+	assert.Equal(t, []time.Duration{time.Hour * 24 * 7, time.Hour * 24 * 30, time.Hour * 24 * 90}, config.Agent.Closing.Windows)
+	assert.Equal(t, time.Duration(time.Hour*24*30), *config.Agent.Closing.GracePeriod)
+	assert.Equal(t, FeeStrategyDefault, config.Agent.Fees.Name)
+	assert.Equal(t, uint64(50), *config.Agent.Fees.Liquidity.MinFeeRatePPM)
+	assert.Equal(t, uint64(2_000), config.Agent.Fees.Liquidity.MaxFeeRatePPM)
+	assert.Equal(t, 1.0, config.Agent.Fees.Liquidity.Exponent)
+	assert.Equal(t, 0.5, config.Agent.Fees.PID.TargetRatio)
+	assert.Equal(t, uint64(500), config.Agent.Fees.PID.FeeRatePPM)
+	assert.Equal(t, 0.5, config.Agent.Fees.Market.Percentile)
+	assert.Equal(t, uint64(1_000_000), config.Agent.Fees.Market.MinCapacity)
+	assert.False(t, *config.Agent.Fees.Inbound.Enabled)
+	assert.Equal(t, 0.8, config.Agent.Fees.Inbound.HighRatio)
+	assert.Equal(t, 0.2, config.Agent.Fees.Inbound.LowRatio)
+	assert.Equal(t, int32(-200), *config.Agent.Fees.Inbound.MinFeeRatePPM)
+	assert.Equal(t, TimeLockDeltaPolicy{Percentile: 0.5, Min: 40, Max: 144}, config.Agent.RoutingPolicies.TimeLockDelta)
+	assert.Equal(t, MinHTLCPolicy{BusyForwards: 50, DustMsat: 1_000_000, Msat: 1_000}, config.Agent.RoutingPolicies.MinHTLC)
+	damping := config.Agent.RoutingPolicies.Damping
+	assert.Equal(t, 12*time.Hour, *damping.MinInterval)
+	assert.Equal(t, 0.5, *damping.MaxDailyChange)
+	assert.Equal(t, 3, *damping.FlipFlopUpdates)
+	assert.Equal(t, 30, *damping.MaxUpdatesPerHour)
+	status := config.Agent.RoutingPolicies.Status
+	assert.Equal(t, 0.01, status.DisableLocalRatio)
+	assert.Equal(t, 0.05, status.EnableLocalRatio)
+	assert.Equal(t, 10, *status.DisableFlaps)
+	assert.Equal(t, 2, *status.EnableFlaps)
+	assert.Equal(t, 24*time.Hour, status.FlapWindow)
+	assert.NotEmpty(t, config.Agent.DataDir)
 	assert.Equal(t, int32(2), config.Agent.ChannelManager.MinConf, 2)
 	assert.Equal(t, uint64(2_000), config.Agent.ChannelManager.FeeRatePPM)
 	assert.Equal(t, uint64(50), config.Agent.ChannelManager.MaxSatvB)
@@ -165,6 +580,187 @@ func TestSetDefaults(t *testing.T) {
 	assert.Equal(t, "info", config.Logging.Level)
 }
 
+func TestSetDefaultsChannelFees(t *testing.T) {
+	enabled := true
+	config := &Config{}
+	config.Agent.Fees = Fees{
+		FeeStrategy: FeeStrategy{Name: FeeStrategyPID},
+		Channels: map[string]FeeStrategy{
+			"txid:0": {Name: FeeStrategyFlat, Flat: FlatFees{FeeRatePPM: 100}},
+			"txid:1": {Liquidity: LiquidityFees{MaxFeeRatePPM: 1_000, Exponent: 2}},
+			"txid:2": {Inbound: InboundFees{Enabled: &enabled}},
+		},
+	}
+	config.setDefaults()
+
+	flat := config.Agent.Fees.Channels["txid:0"]
+	assert.Equal(t, FeeStrategyFlat, flat.Name)
+	assert.Equal(t, uint64(100), flat.Flat.FeeRatePPM)
+	assert.Equal(t, config.Agent.Fees.PID, flat.PID)
+
+	liquidity := config.Agent.Fees.Channels["txid:1"]
+	assert.Equal(t, FeeStrategyPID, liquidity.Name)
+	assert.Equal(t, uint64(50), *liquidity.Liquidity.MinFeeRatePPM)
+	assert.Equal(t, uint64(1_000), liquidity.Liquidity.MaxFeeRatePPM)
+	assert.Equal(t, 2.0, liquidity.Liquidity.Exponent)
+	assert.False(t, *liquidity.Inbound.Enabled)
+
+	inbound := config.Agent.Fees.Channels["txid:2"]
+	assert.True(t, *inbound.Inbound.Enabled)
+	assert.Equal(t, int32(-200), *inbound.Inbound.MinFeeRatePPM)
+	assert.False(t, *config.Agent.Fees.Inbound.Enabled)
+}
+
+func TestSetDefaultsChannelZeroFees(t *testing.T) {
+	zero := uint64(0)
+	noUndercut, noPremium := 0.0, 0.0
+	config := &Config{}
+	config.Agent.Fees.Channels = map[string]FeeStrategy{
+		"txid:0": {Liquidity: LiquidityFees{MinFeeRatePPM: &zero}},
+		"txid:1": {Market: MarketFees{Undercut: &noUndercut, Premium: &noPremium}},
+	}
+	config.setDefaults()
+
+	assert.Equal(t, uint64(50), *config.Agent.Fees.Liquidity.MinFeeRatePPM)
+	assert.Equal(t, uint64(0), *config.Agent.Fees.Channels["txid:0"].Liquidity.MinFeeRatePPM)
+	assert.Equal(t, 0.1, *config.Agent.Fees.Market.Undercut)
+	assert.Equal(t, 0.25, *config.Agent.Fees.Market.Premium)
+	market := config.Agent.Fees.Channels["txid:1"].Market
+	assert.Equal(t, 0.0, *market.Undercut)
+	assert.Equal(t, 0.0, *market.Premium)
+	assert.NoError(t, config.Agent.Fees.validate())
+}
+
+func TestSetDefaultsChannelInboundFees(t *testing.T) {
+	enabled, disabled := true, false
+	noDiscount := int32(0)
+	config := &Config{}
+	config.Agent.Fees = Fees{
+		FeeStrategy: FeeStrategy{Inbound: InboundFees{Enabled: &enabled}},
+		Channels: map[string]FeeStrategy{
+			"txid:0": {Inbound: InboundFees{Enabled: &disabled}},
+			"txid:1": {Inbound: InboundFees{MinFeeRatePPM: &noDiscount}},
+		},
+	}
+	config.setDefaults()
+
+	assert.True(t, *config.Agent.Fees.Inbound.Enabled)
+	assert.False(t, *config.Agent.Fees.Channels["txid:0"].Inbound.Enabled)
+
+	noDiscountInbound := config.Agent.Fees.Channels["txid:1"].Inbound
+	assert.True(t, *noDiscountInbound.Enabled)
+	assert.Equal(t, int32(0), *noDiscountInbound.MinFeeRatePPM)
+	assert.NoError(t, config.Agent.Fees.validate())
+}
+
+func TestSetDefaultsPartialFees(t *testing.T) {
+	config := &Config{}
+	config.Agent.Fees.Liquidity = LiquidityFees{MaxFeeRatePPM: 3_000}
+	config.Agent.Fees.PID = PIDFees{FeeRatePPM: 1_000, Kp: 500}
+	config.Agent.Fees.Market = MarketFees{Percentile: 0.25}
+	enabled := true
+	config.Agent.Fees.Inbound = InboundFees{Enabled: &enabled, HighRatio: 0.9}
+	config.setDefaults()
+
+	assert.Equal(t, uint64(50), *config.Agent.Fees.Liquidity.MinFeeRatePPM)
+	assert.Equal(t, uint64(3_000), config.Agent.Fees.Liquidity.MaxFeeRatePPM)
+	assert.Equal(t, 1.0, config.Agent.Fees.Liquidity.Exponent)
+	assert.Equal(t, PIDFees{TargetRatio: 0.5, FeeRatePPM: 1_000, MaxFeeRatePPM: 5_000, Kp: 500}, config.Agent.Fees.PID)
+	assert.Equal(t, 0.25, config.Agent.Fees.Market.Percentile)
+	assert.Equal(t, 0.1, *config.Agent.Fees.Market.Undercut)
+	assert.True(t, *config.Agent.Fees.Inbound.Enabled)
+	assert.Equal(t, 0.9, config.Agent.Fees.Inbound.HighRatio)
+	assert.Equal(t, 0.2, config.Agent.Fees.Inbound.LowRatio)
+	assert.Equal(t, int32(-200), *config.Agent.Fees.Inbound.MinFeeRatePPM)
+	assert.NoError(t, config.Agent.Fees.validate())
+}
+
+func TestSetDefaultsDiversity(t *testing.T) {
+	config := &Config{}
+	penalty := 0.0
+	config.Agent.Selection.Diversity.CommunityPenalty = &penalty
+	config.setDefaults()
+
+	assert.Equal(t, 0.0, *config.Agent.Selection.Diversity.CommunityPenalty)
+	assert.Equal(t, 0.5, *config.Agent.Selection.Diversity.SharedPeersPenalty)
+}
+
+func TestSetDefaultsClosing(t *testing.T) {
+	config := &Config{}
+	opportunityRate := 0.0
+	config.Agent.Closing.Profitability.OpportunityRate = &opportunityRate
+	gracePeriod := time.Duration(0)
+	config.Agent.Closing.GracePeriod = &gracePeriod
+	config.setDefaults()
+
+	assert.Equal(t, 0.0, *config.Agent.Closing.Profitability.OpportunityRate)
+	assert.Equal(t, time.Duration(0), *config.Agent.Closing.GracePeriod)
+}
+
+func TestSetDefaultsDamping(t *testing.T) {
+	minInterval := 6 * time.Hour
+	noFlipFlops := 0
+	config := &Config{}
+	config.Agent.RoutingPolicies.Damping = Damping{
+		Enabled:         true,
+		MinInterval:     &minInterval,
+		FlipFlopUpdates: &noFlipFlops,
+	}
+	config.setDefaults()
+
+	damping := config.Agent.RoutingPolicies.Damping
+	assert.Equal(t, 6*time.Hour, *damping.MinInterval)
+	assert.Equal(t, 0.5, *damping.MaxDailyChange)
+	assert.Equal(t, 0, *damping.FlipFlopUpdates)
+	assert.Equal(t, 30, *damping.MaxUpdatesPerHour)
+}
+
+func TestSetDefaultsStatus(t *testing.T) {
+	noFlaps := 0
+	config := &Config{}
+	config.Agent.RoutingPolicies.Status = ChannelStatusPolicy{
+		Enabled:     true,
+		FlapWindow:  12 * time.Hour,
+		EnableFlaps: &noFlaps,
+	}
+	config.setDefaults()
+
+	status := config.Agent.RoutingPolicies.Status
+	assert.Equal(t, 0.01, status.DisableLocalRatio)
+	assert.Equal(t, 0.05, status.EnableLocalRatio)
+	assert.Equal(t, 10, *status.DisableFlaps)
+	assert.Equal(t, 0, *status.EnableFlaps)
+	assert.Equal(t, 12*time.Hour, status.FlapWindow)
+	assert.NoError(t, status.validate())
+}
+
+func TestSetDefaultsRoutingPolicies(t *testing.T) {
+	config := &Config{}
+	config.Agent.RoutingPolicies.TimeLockDelta = TimeLockDeltaPolicy{Enabled: true, Max: 100}
+	config.Agent.RoutingPolicies.MinHTLC = MinHTLCPolicy{Enabled: true, BusyForwards: 10}
+	config.setDefaults()
+
+	assert.Equal(t, TimeLockDeltaPolicy{Enabled: true, Percentile: 0.5, Min: 40, Max: 100}, config.Agent.RoutingPolicies.TimeLockDelta)
+	assert.Equal(t, MinHTLCPolicy{Enabled: true, BusyForwards: 10, DustMsat: 1_000_000, Msat: 1_000}, config.Agent.RoutingPolicies.MinHTLC)
+	assert.NoError(t, config.Agent.RoutingPolicies.validate())
+}
+
+func TestSetDefaultsTagFees(t *testing.T) {
+	config := &Config{}
+	config.Agent.Fees.Name = FeeStrategyPID
+	config.Agent.Tags = []Tag{
+		{Name: "flat", Fees: &FeeStrategy{Name: FeeStrategyFlat, Flat: FlatFees{FeeRatePPM: 100}}},
+		{Name: "none"},
+	}
+	config.setDefaults()
+
+	flat := config.Agent.Tags[0].Fees
+	assert.Equal(t, FeeStrategyFlat, flat.Name)
+	assert.Equal(t, uint64(100), flat.Flat.FeeRatePPM)
+	assert.Equal(t, config.Agent.Fees.PID, flat.PID)
+	assert.Nil(t, config.Agent.Tags[1].Fees)
+}
+
 func TestIterWeights(t *testing.T) {
 	closeWeights := CloseWeights{
 		Capacity:       1,
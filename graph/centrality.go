@@ -60,15 +60,15 @@ func getCentrality(ctx context.Context, nodeIndices map[string]int, adjList [][]
 	return sumDistances, bCentrality
 }
 
-func getEigenvectorCentrality(nodeIndices map[string]int, adjList [][]int) []uint64 {
+func getEigenvectorCentrality(adjList [][]int) []uint64 {
 	// 4 iterations should be enough to get the values
 	iterations := 4
 	matrix := make([][]uint64, iterations)
 
 	for i := range iterations {
-		matrix[i] = make([]uint64, len(nodeIndices))
+		matrix[i] = make([]uint64, len(adjList))
 
-		for _, nodeIndex := range nodeIndices {
+		for nodeIndex := range adjList {
 			if i == 0 {
 				// The first value is the number of peers the node has
 				matrix[i][nodeIndex] = uint64(len(adjList[nodeIndex]))
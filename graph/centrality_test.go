@@ -119,7 +119,7 @@ func TestGetCentrality(t *testing.T) {
 
 func TestGetEigenvectorCentrality(t *testing.T) {
 	expectedResult := []uint64{26, 15, 26, 37, 32, 32, 15, 15}
-	result := getEigenvectorCentrality(nodeIndices, adjList)
+	result := getEigenvectorCentrality(adjList)
 
 	assert.Equal(t, expectedResult, result)
 }
@@ -0,0 +1,166 @@
+package graph
+
+import (
+	"slices"
+)
+
+// Maximum number of times the communities are aggregated and re-evaluated.
+const maxCommunityLevels = 10
+
+// Communities returns the community each node belongs to, indexed by public key.
+//
+// They are computed on every call instead of when the graph is built since only the diversity selection uses
+// them.
+func (g Graph) Communities() map[string]int {
+	communities := getCommunities(g.adjList)
+	nodeCommunities := make(map[string]int, len(g.Nodes))
+	for _, node := range g.Nodes {
+		nodeCommunities[node.PublicKey] = communities[g.nodeIndices[node.PublicKey]]
+	}
+
+	return nodeCommunities
+}
+
+// getCommunities partitions the graph into communities of densely connected nodes using the Louvain method and
+// returns the community of each node. Nodes without channels end up in a community of their own.
+//
+// For a detailed explanation please read:
+// https://en.wikipedia.org/wiki/Louvain_method
+func getCommunities(adjList [][]int) []int {
+	// The adjacency list may be asymmetric as filtered out nodes have no channels, make it undirected
+	weights := make([]map[int]float64, len(adjList))
+	for i := range weights {
+		weights[i] = make(map[int]float64)
+	}
+	for v, peers := range adjList {
+		for _, w := range peers {
+			if v == w {
+				continue
+			}
+			weights[v][w] += 0.5
+			weights[w][v] += 0.5
+		}
+	}
+
+	communities := make([]int, len(adjList))
+	for i := range communities {
+		communities[i] = i
+	}
+
+	for range maxCommunityLevels {
+		partition, moved := moveNodes(weights)
+		if !moved {
+			break
+		}
+
+		for i, community := range communities {
+			communities[i] = partition[community]
+		}
+		weights = aggregateCommunities(weights, partition)
+	}
+
+	return communities
+}
+
+// moveNodes moves each node to the neighbor community that increases modularity the most until no
+// improvement is possible. It returns the community of each node, numbered from zero, and whether any node
+// was moved.
+func moveNodes(weights []map[int]float64) ([]int, bool) {
+	nodesLen := len(weights)
+	community := make([]int, nodesLen)
+	degrees := make([]float64, nodesLen)
+	// totals[c] is the sum of the degrees of the nodes in the community c
+	totals := make([]float64, nodesLen)
+	totalWeight := 0.0
+
+	for v := range nodesLen {
+		community[v] = v
+		for _, weight := range weights[v] {
+			degrees[v] += weight
+		}
+		totals[v] = degrees[v]
+		totalWeight += degrees[v]
+	}
+
+	if totalWeight == 0 {
+		return community, false
+	}
+
+	moved := false
+	for improved := true; improved; {
+		improved = false
+
+		for v := range nodesLen {
+			if degrees[v] == 0 {
+				continue
+			}
+
+			// Sum the weights of the links from v to each of the neighbor communities
+			links := make(map[int]float64, len(weights[v]))
+			for w, weight := range weights[v] {
+				if w != v {
+					links[community[w]] += weight
+				}
+			}
+
+			current := community[v]
+			totals[current] -= degrees[v]
+
+			best := current
+			bestGain := links[current] - totals[current]*degrees[v]/totalWeight
+
+			neighborCommunities := make([]int, 0, len(links))
+			for c := range links {
+				neighborCommunities = append(neighborCommunities, c)
+			}
+			// Sort them to break ties deterministically
+			slices.Sort(neighborCommunities)
+
+			for _, c := range neighborCommunities {
+				gain := links[c] - totals[c]*degrees[v]/totalWeight
+				if gain > bestGain+1e-9 {
+					best = c
+					bestGain = gain
+				}
+			}
+
+			totals[best] += degrees[v]
+			if best != current {
+				community[v] = best
+				improved = true
+				moved = true
+			}
+		}
+	}
+
+	// Renumber communities so they are contiguous
+	labels := make(map[int]int, nodesLen)
+	for v, c := range community {
+		label, ok := labels[c]
+		if !ok {
+			label = len(labels)
+			labels[c] = label
+		}
+		community[v] = label
+	}
+
+	return community, moved
+}
+
+// aggregateCommunities builds a new graph where each community is a node. Links between nodes of the same
+// community become self-loops.
+func aggregateCommunities(weights []map[int]float64, partition []int) []map[int]float64 {
+	communitiesLen := slices.Max(partition) + 1
+	aggregated := make([]map[int]float64, communitiesLen)
+	for i := range aggregated {
+		aggregated[i] = make(map[int]float64)
+	}
+
+	for v, links := range weights {
+		for w, weight := range links {
+			aggregated[partition[v]][partition[w]] += weight
+		}
+	}
+
+	return aggregated
+}
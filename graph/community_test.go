@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCommunities(t *testing.T) {
+	tests := []struct {
+		desc     string
+		adjList  [][]int
+		expected []int
+	}{
+		{
+			desc:     "Empty graph",
+			adjList:  [][]int{},
+			expected: []int{},
+		},
+		{
+			desc: "Two triangles joined by a bridge",
+			adjList: [][]int{
+				{1, 2},
+				{0, 2},
+				{0, 1, 3},
+				{2, 4, 5},
+				{3, 5},
+				{3, 4},
+			},
+			expected: []int{0, 0, 0, 1, 1, 1},
+		},
+		{
+			desc: "Isolated node",
+			adjList: [][]int{
+				{1},
+				{0},
+				{},
+			},
+			expected: []int{0, 0, 1},
+		},
+		{
+			desc:     "Shared graph",
+			adjList:  adjList,
+			expected: []int{0, 1, 0, 1, 2, 3, 2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			communities := getCommunities(tt.adjList)
+			assert.Equal(t, tt.expected, communities)
+		})
+	}
+}
+
+func TestCommunities(t *testing.T) {
+	expected := map[string]int{
+		alice:  0,
+		bob:    1,
+		carol:  0,
+		dave:   1,
+		erin:   2,
+		frank:  3,
+		george: 2,
+		harold: 3,
+	}
+	assert.Equal(t, expected, newSimulationGraph().Communities())
+}
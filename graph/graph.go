@@ -3,10 +3,14 @@ package graph
 import (
 	"context"
 	"encoding/binary"
+	"fmt"
 	"math/big"
+	"slices"
+	"time"
 
 	"github.com/aftermath2/hydrus/config"
 	"github.com/aftermath2/hydrus/lightning"
+	"github.com/aftermath2/hydrus/metrics"
 
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/pkg/errors"
@@ -16,6 +20,9 @@ import (
 type Graph struct {
 	Heuristics Heuristics `json:"heuristics,omitzero"`
 	Nodes      []Node     `json:"nodes,omitempty"`
+
+	adjList     [][]int
+	nodeIndices map[string]int
 }
 
 // Node represents a lightning network node.
@@ -61,31 +68,22 @@ func New(ctx context.Context, openWeights config.OpenWeights, lnd lightning.Clie
 		return Graph{}, errors.Wrap(err, "getting channel graph")
 	}
 
-	totalCapacity := uint64(0)
-	nodesLen := len(graph.Nodes)
-	channels := make(map[string][]Channel, nodesLen*2)
-	skippedEdges := 0
-
-	for _, edge := range graph.Edges {
-		totalCapacity += uint64(edge.Capacity)
-
-		// New channels may be processed by our node before they are propagated entirely.
-		// Skip channels whose complete information isn't yet available to us.
-		if edge.Node1Policy == nil && edge.Node2Policy == nil {
-			skippedEdges++
-			continue
-		}
+	return FromChannelGraph(ctx, openWeights, graph)
+}
 
-		blockHeight := GetChannelBlockHeight(edge.ChannelId)
+// FromChannelGraph returns a new network graph built from a channel graph already obtained from LND.
+func FromChannelGraph(
+	ctx context.Context,
+	openWeights config.OpenWeights,
+	graph *lnrpc.ChannelGraph,
+) (Graph, error) {
+	start := time.Now()
+	defer func() {
+		metrics.GraphDuration.Observe(metrics.Since(start))
+	}()
 
-		if !discardChannel(edge.Node1Policy) {
-			channels[edge.Node1Pub] = append(channels[edge.Node1Pub], getNode1Channel(edge, blockHeight))
-		}
-
-		if !discardChannel(edge.Node2Policy) {
-			channels[edge.Node2Pub] = append(channels[edge.Node2Pub], getNode2Channel(edge, blockHeight))
-		}
-	}
+	nodesLen := len(graph.Nodes)
+	channels, totalCapacity, skippedEdges := getChannels(graph)
 
 	// Fail if we skipped more than half of the network graph edges
 	if skippedEdges > len(graph.Edges)/2 {
@@ -93,9 +91,7 @@ func New(ctx context.Context, openWeights config.OpenWeights, lnd lightning.Clie
 			errors.Errorf("channel graph is too incomplete to proceed, skipped %d channels", skippedEdges)
 	}
 
-	avgNodeSize := totalCapacity / uint64(nodesLen)
-	totalNumChannels := len(graph.Edges)
-	avgNumChannels := totalNumChannels / nodesLen
+	filter := newNodeFilter(graph, totalCapacity)
 
 	nodes := make([]Node, 0, nodesLen)
 	nodeIndices := make(map[string]int, nodesLen)
@@ -109,7 +105,7 @@ func New(ctx context.Context, openWeights config.OpenWeights, lnd lightning.Clie
 		}
 
 		// Discard nodes we know won't be ranked at the top in advance to reduce the size of the adjacency list
-		if len(node.Addresses) == 0 || capacity < avgNodeSize || len(channels[node.PubKey]) < avgNumChannels {
+		if filter.discard(node, capacity, len(channels[node.PubKey])) {
 			continue
 		}
 
@@ -127,8 +123,10 @@ func New(ctx context.Context, openWeights config.OpenWeights, lnd lightning.Clie
 	// After having filtered the nodes, calculate the centrality of the remaining ones
 	// We do this to avoid doing big amounts of allocations and speeding up the calculations
 	adjList := newAdjacencyList(nodes, nodeIndices)
+	centralityStart := time.Now()
 	sumDistances, betweennessCentrality := getCentrality(ctx, nodeIndices, adjList)
-	eigenvectorCentrality := getEigenvectorCentrality(nodeIndices, adjList)
+	eigenvectorCentrality := getEigenvectorCentrality(adjList)
+	metrics.CentralityDuration.Observe(metrics.Since(centralityStart))
 
 	// Populate nodes' centralities values
 	heuristics := NewHeuristics(openWeights)
@@ -145,7 +143,7 @@ func New(ctx context.Context, openWeights config.OpenWeights, lnd lightning.Clie
 		}
 
 		nodes[i].Centrality = Centrality{
-			Degree:      float64(len(channels[nodes[i].PublicKey])) / float64(totalNumChannels),
+			Degree:      float64(len(channels[nodes[i].PublicKey])) / float64(len(graph.Edges)),
 			Betweenness: betweennessCentrality[index],
 			Closeness:   closeness,
 			Eigenvector: eigenvectorCentrality[index],
@@ -154,7 +152,151 @@ func New(ctx context.Context, openWeights config.OpenWeights, lnd lightning.Clie
 		heuristics.Update(nodes[i])
 	}
 
-	return Graph{Nodes: nodes, Heuristics: *heuristics}, nil
+	return Graph{
+		Nodes:       nodes,
+		Heuristics:  *heuristics,
+		adjList:     adjList,
+		nodeIndices: nodeIndices,
+	}, nil
+}
+
+// getChannels returns the channels of each node, skipping the ones whose policies are unknown or outliers,
+// along with the capacity of the whole network and the number of edges skipped for missing policies.
+func getChannels(graph *lnrpc.ChannelGraph) (map[string][]Channel, uint64, int) {
+	totalCapacity := uint64(0)
+	channels := make(map[string][]Channel, len(graph.Nodes)*2)
+	skippedEdges := 0
+
+	for _, edge := range graph.Edges {
+		totalCapacity += uint64(edge.Capacity)
+
+		// New channels may be processed by our node before they are propagated entirely.
+		// Skip channels whose complete information isn't yet available to us.
+		if edge.Node1Policy == nil && edge.Node2Policy == nil {
+			skippedEdges++
+			continue
+		}
+
+		blockHeight := GetChannelBlockHeight(edge.ChannelId)
+
+		if !discardChannel(edge.Node1Policy) {
+			channels[edge.Node1Pub] = append(channels[edge.Node1Pub], getNode1Channel(edge, blockHeight))
+		}
+
+		if !discardChannel(edge.Node2Policy) {
+			channels[edge.Node2Pub] = append(channels[edge.Node2Pub], getNode2Channel(edge, blockHeight))
+		}
+	}
+
+	return channels, totalCapacity, skippedEdges
+}
+
+// nodeFilter holds the network averages nodes must reach to be included in the graph.
+type nodeFilter struct {
+	avgNodeSize    uint64
+	avgNumChannels int
+}
+
+func newNodeFilter(graph *lnrpc.ChannelGraph, totalCapacity uint64) nodeFilter {
+	if len(graph.Nodes) == 0 {
+		return nodeFilter{}
+	}
+
+	return nodeFilter{
+		avgNodeSize:    totalCapacity / uint64(len(graph.Nodes)),
+		avgNumChannels: len(graph.Edges) / len(graph.Nodes),
+	}
+}
+
+// discard returns whether the node is excluded from the graph.
+func (f nodeFilter) discard(node *lnrpc.LightningNode, capacity uint64, numChannels int) bool {
+	return len(node.Addresses) == 0 || capacity < f.avgNodeSize || numChannels < f.avgNumChannels
+}
+
+// reasons returns why the node is excluded from the graph.
+func (f nodeFilter) reasons(node *lnrpc.LightningNode, capacity uint64, numChannels int) []string {
+	reasons := make([]string, 0)
+	if len(node.Addresses) == 0 {
+		reasons = append(reasons, "no addresses announced")
+	}
+
+	if capacity < f.avgNodeSize {
+		reasons = append(reasons,
+			fmt.Sprintf("capacity (%d) below the network average (%d)", capacity, f.avgNodeSize))
+	}
+
+	if numChannels < f.avgNumChannels {
+		reasons = append(reasons,
+			fmt.Sprintf("channels (%d) below the network average (%d)", numChannels, f.avgNumChannels))
+	}
+
+	return reasons
+}
+
+// ExclusionReasons returns why the node is left out of the graph built from the channel graph, it's empty if
+// the node is included.
+//
+// Channels that are disabled or whose fees are outliers are not counted.
+func ExclusionReasons(graph *lnrpc.ChannelGraph, publicKey string) []string {
+	i := slices.IndexFunc(graph.Nodes, func(node *lnrpc.LightningNode) bool {
+		return node.PubKey == publicKey
+	})
+	if i == -1 {
+		return []string{"not found in the network graph"}
+	}
+
+	channels, totalCapacity, _ := getChannels(graph)
+	capacity := uint64(0)
+	for _, channel := range channels[publicKey] {
+		capacity += channel.Capacity
+	}
+
+	return newNodeFilter(graph, totalCapacity).reasons(graph.Nodes[i], capacity, len(channels[publicKey]))
+}
+
+// GetNode returns the node with the public key, false if it's not part of the graph.
+func (g Graph) GetNode(publicKey string) (Node, bool) {
+	i := slices.IndexFunc(g.Nodes, func(node Node) bool {
+		return node.PublicKey == publicKey
+	})
+	if i == -1 {
+		return Node{}, false
+	}
+
+	return g.Nodes[i], true
+}
+
+// Rank returns the position of the node in the ranking by score, starting from 1 for the highest one.
+func (g Graph) Rank(node Node) int {
+	score := g.Heuristics.GetScore(node)
+	rank := 1
+	for _, n := range g.Nodes {
+		if g.Heuristics.GetScore(n) > score {
+			rank++
+		}
+	}
+
+	return rank
+}
+
+// MedianChannelCapacity returns the median capacity of the node's channels.
+func (n Node) MedianChannelCapacity() uint64 {
+	if len(n.Channels) == 0 {
+		return 0
+	}
+
+	capacities := make([]uint64, 0, len(n.Channels))
+	for _, channel := range n.Channels {
+		capacities = append(capacities, channel.Capacity)
+	}
+	slices.Sort(capacities)
+
+	middle := len(capacities) / 2
+	if len(capacities)%2 == 0 {
+		return (capacities[middle-1] + capacities[middle]) / 2
+	}
+
+	return capacities[middle]
 }
 
 // GetAddresses parses node addresses into a string slice.
@@ -144,7 +144,8 @@ func TestNew(t *testing.T) {
 	g, err := graph.New(t.Context(), config.DefaultOpenWeights, lndMock)
 	assert.NoError(t, err)
 
-	assert.Equal(t, expectedGraph, g)
+	assert.Equal(t, expectedGraph.Nodes, g.Nodes)
+	assert.Equal(t, expectedGraph.Heuristics, g.Heuristics)
 }
 
 func BenchmarkNew(b *testing.B) {
@@ -199,6 +200,62 @@ func TestNewDiscardNode(t *testing.T) {
 	assert.Empty(t, g.Nodes)
 }
 
+func TestExclusionReasons(t *testing.T) {
+	policy := &lnrpc.RoutingPolicy{FeeRateMilliMsat: 100}
+	channelGraph := &lnrpc.ChannelGraph{
+		Nodes: []*lnrpc.LightningNode{
+			{PubKey: "alice", Addresses: []*lnrpc.NodeAddress{{Addr: "127.0.0.1:9735"}}},
+			{PubKey: "bob"},
+			{PubKey: "carol", Addresses: []*lnrpc.NodeAddress{{Addr: "127.0.0.1:9736"}}},
+		},
+		Edges: []*lnrpc.ChannelEdge{
+			{
+				Node1Pub:    "alice",
+				Node2Pub:    "carol",
+				Capacity:    5_000_000,
+				Node1Policy: policy,
+				Node2Policy: policy,
+			},
+			{
+				Node1Pub:    "alice",
+				Node2Pub:    "bob",
+				Capacity:    1_000_000,
+				Node1Policy: policy,
+				Node2Policy: policy,
+			},
+		},
+	}
+
+	assert.Empty(t, graph.ExclusionReasons(channelGraph, "alice"))
+	assert.Equal(t, []string{
+		"no addresses announced",
+		"capacity (1000000) below the network average (2000000)",
+	}, graph.ExclusionReasons(channelGraph, "bob"))
+	assert.Equal(t, []string{"not found in the network graph"}, graph.ExclusionReasons(channelGraph, "dave"))
+}
+
+func TestGraphRank(t *testing.T) {
+	nodes := []graph.Node{
+		{PublicKey: "alice", Capacity: 1_000_000, Channels: []graph.Channel{{}}},
+		{PublicKey: "bob", Capacity: 5_000_000, Channels: []graph.Channel{{}}},
+		{PublicKey: "carol", Capacity: 3_000_000, Channels: []graph.Channel{{}}},
+	}
+	heuristics := graph.NewHeuristics(config.OpenWeights{Capacity: 1})
+	for _, node := range nodes {
+		heuristics.Update(node)
+	}
+	g := graph.Graph{Nodes: nodes, Heuristics: *heuristics}
+
+	node, ok := g.GetNode("carol")
+	assert.True(t, ok)
+	assert.Equal(t, nodes[2], node)
+	assert.Equal(t, 2, g.Rank(node))
+	assert.Equal(t, 1, g.Rank(nodes[1]))
+
+	_, ok = g.GetNode("dave")
+	assert.False(t, ok)
+}
+
 func TestGetNumFeatures(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -326,6 +383,50 @@ func TestGetChannelBlockHeight(t *testing.T) {
 	}
 }
 
+func TestMedianChannelCapacity(t *testing.T) {
+	tests := []struct {
+		name     string
+		node     graph.Node
+		expected uint64
+	}{
+		{
+			name:     "No channels",
+			node:     graph.Node{},
+			expected: 0,
+		},
+		{
+			name: "Odd number of channels",
+			node: graph.Node{
+				Channels: []graph.Channel{
+					{Capacity: 5_000_000},
+					{Capacity: 1_000_000},
+					{Capacity: 2_000_000},
+				},
+			},
+			expected: 2_000_000,
+		},
+		{
+			name: "Even number of channels",
+			node: graph.Node{
+				Channels: []graph.Channel{
+					{Capacity: 4_000_000},
+					{Capacity: 1_000_000},
+					{Capacity: 2_000_000},
+					{Capacity: 10_000_000},
+				},
+			},
+			expected: 3_000_000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := tt.node.MedianChannelCapacity()
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
 // Utils
 
 var nodes = []string{
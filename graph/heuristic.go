@@ -131,3 +131,64 @@ func isHybrid(addresses []string) bool {
 
 	return hasClearnet && hasTor
 }
+
+// Explain returns the contribution of each heuristic to the node's score. The channels heuristics contain the
+// mean of the node's channels values and scores.
+func (h *Heuristics) Explain(node Node) []heuristic.Component {
+	hybrid := 0
+	if isHybrid(node.Addresses) {
+		hybrid = 1
+	}
+
+	components := []heuristic.Component{
+		h.Capacity.Explain("capacity", node.Capacity),
+		h.Features.Explain("features", node.NumFeatures),
+		h.Hybrid.Explain("hybrid", hybrid),
+		h.Centrality.Degree.Explain("centrality.degree", node.Centrality.Degree),
+		h.Centrality.Betweenness.Explain("centrality.betweenness", node.Centrality.Betweenness),
+		h.Centrality.Eigenvector.Explain("centrality.eigenvector", node.Centrality.Eigenvector),
+		h.Centrality.Closeness.Explain("centrality.closeness", node.Centrality.Closeness),
+	}
+
+	return append(components,
+		explainChannels("channels.base_fee", h.Channels.BaseFee, node.Channels,
+			func(c Channel) uint64 { return c.BaseFee }),
+		explainChannels("channels.fee_rate", h.Channels.FeeRate, node.Channels,
+			func(c Channel) uint64 { return c.FeeRate }),
+		explainChannels("channels.inbound_base_fee", h.Channels.InboundBaseFee, node.Channels,
+			func(c Channel) int64 { return c.InboundBaseFee }),
+		explainChannels("channels.inbound_fee_rate", h.Channels.InboundFeeRate, node.Channels,
+			func(c Channel) int64 { return c.InboundFeeRate }),
+		explainChannels("channels.min_htlc", h.Channels.MinHTLC, node.Channels,
+			func(c Channel) uint64 { return c.MinHTLC }),
+		explainChannels("channels.max_htlc", h.Channels.MaxHTLC, node.Channels,
+			func(c Channel) uint64 { return c.MaxHTLC }),
+		explainChannels("channels.block_height", h.Channels.BlockHeight, node.Channels,
+			func(c Channel) uint64 { return c.BlockHeight }),
+	)
+}
+
+// explainChannels returns the mean value and score of a channels heuristic.
+func explainChannels[T int64 | uint64](
+	name string,
+	h *heuristic.Heuristic[T],
+	channels []Channel,
+	value func(channel Channel) T,
+) heuristic.Component {
+	component := h.Explain(name, 0)
+	component.Value, component.Score = 0, 0
+	if len(channels) == 0 {
+		return component
+	}
+
+	for _, channel := range channels {
+		c := h.Explain(name, value(channel))
+		component.Value += c.Value
+		component.Score += c.Score
+	}
+
+	n := float64(len(channels))
+	component.Value /= n
+	component.Score /= n
+	return component
+}
@@ -266,3 +266,49 @@ func TestIsHybrid(t *testing.T) {
 		})
 	}
 }
+
+func TestHeuristicsExplain(t *testing.T) {
+	heuristics := NewHeuristics(config.DefaultOpenWeights)
+	nodes := []Node{
+		{
+			Capacity:    1_000_000,
+			NumFeatures: 3,
+			Addresses:   []string{"127.0.0.1:9735", "abc.onion:9735"},
+			Channels: []Channel{
+				{FeeRate: 100, MaxHTLC: 500_000, BlockHeight: 800_000},
+				{FeeRate: 300, MaxHTLC: 100_000, BlockHeight: 850_000},
+			},
+			Centrality: Centrality{Degree: 0.4, Betweenness: 0.1, Eigenvector: 2, Closeness: 0.5},
+		},
+		{
+			Capacity:    4_000_000,
+			NumFeatures: 5,
+			Channels:    []Channel{{FeeRate: 50, MaxHTLC: 1_000_000, BlockHeight: 900_000}},
+			Centrality:  Centrality{Degree: 0.2, Betweenness: 0.6, Eigenvector: 8, Closeness: 0.8},
+		},
+	}
+	for _, node := range nodes {
+		heuristics.Update(node)
+	}
+
+	components := heuristics.Explain(nodes[0])
+	assert.Len(t, components, 14)
+
+	total := 0.0
+	for _, component := range components {
+		total += component.Score
+	}
+	assert.InDelta(t, heuristics.GetScore(nodes[0]), total, 0.001)
+
+	capacity := components[0]
+	assert.Equal(t, "capacity", capacity.Name)
+	assert.Equal(t, 1_000_000.0, capacity.Value)
+	assert.Equal(t, 1_000_000.0, capacity.Lowest)
+	assert.Equal(t, 4_000_000.0, capacity.Highest)
+	assert.Equal(t, config.DefaultOpenWeights.Capacity, capacity.Weight)
+
+	// Channels values are averaged
+	feeRate := components[8]
+	assert.Equal(t, "channels.fee_rate", feeRate.Name)
+	assert.Equal(t, 200.0, feeRate.Value)
+}
@@ -0,0 +1,219 @@
+package graph
+
+import (
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// Position contains the centrality of a node within the graph.
+type Position struct {
+	// Closeness is normalized by the number of nodes reachable, so that adding channels to disconnected
+	// nodes doesn't lower it.
+	Closeness float64 `json:"closeness"`
+	// Betweenness is estimated from a sample of source nodes.
+	Betweenness float64 `json:"betweenness"`
+}
+
+// Simulation evaluates how a node's position in the graph changes when channels are added to it.
+//
+// The graph itself is never modified, the adjacency lists of the nodes affected are copied on write.
+type Simulation struct {
+	adjList     [][]int
+	nodeIndices map[string]int
+	sources     []int
+	index       int
+}
+
+// NewSimulation returns a simulation of the graph from the point of view of the node with the public key and
+// channels with the peers specified.
+//
+// The betweenness centrality is estimated using the shortest paths from a number of sampled nodes.
+func (g Graph) NewSimulation(publicKey string, peers []string, samples int) *Simulation {
+	adjList := slices.Clone(g.adjList)
+	index, ok := g.nodeIndices[publicKey]
+	if !ok {
+		// The node is not part of the graph yet
+		index = len(adjList)
+		adjList = append(adjList, nil)
+	}
+
+	s := &Simulation{
+		adjList:     adjList,
+		nodeIndices: g.nodeIndices,
+		sources:     g.sampleSources(samples),
+		index:       index,
+	}
+
+	// Our node is usually filtered out of the graph, add its channels back
+	for _, peer := range peers {
+		j, ok := s.nodeIndices[peer]
+		if !ok || slices.Contains(s.adjList[index], j) {
+			continue
+		}
+		s.adjList[index] = append(slices.Clip(s.adjList[index]), j)
+		if !slices.Contains(s.adjList[j], index) {
+			s.adjList[j] = append(slices.Clip(s.adjList[j]), index)
+		}
+	}
+
+	return s
+}
+
+// AddChannel adds a hypothetical channel between the node and the peer.
+func (s *Simulation) AddChannel(peer string) error {
+	j, ok := s.nodeIndices[peer]
+	if !ok {
+		return errors.Errorf("node %q not found in the graph", peer)
+	}
+
+	s.adjList[s.index] = append(slices.Clip(s.adjList[s.index]), j)
+	s.adjList[j] = append(slices.Clip(s.adjList[j]), s.index)
+	return nil
+}
+
+// Evaluate returns the node's position if it opened channels to the peers, without keeping them.
+func (s *Simulation) Evaluate(peers ...string) (Position, error) {
+	indices := []int{s.index}
+	for _, peer := range peers {
+		j, ok := s.nodeIndices[peer]
+		if !ok {
+			return Position{}, errors.Errorf("node %q not found in the graph", peer)
+		}
+		indices = append(indices, j)
+	}
+
+	// Save the adjacency lists that will be modified to restore them afterwards
+	saved := make(map[int][]int, len(indices))
+	for _, i := range indices {
+		saved[i] = s.adjList[i]
+	}
+	defer func() {
+		for i, list := range saved {
+			s.adjList[i] = list
+		}
+	}()
+
+	for _, peer := range peers {
+		if err := s.AddChannel(peer); err != nil {
+			return Position{}, err
+		}
+	}
+
+	return s.Position(), nil
+}
+
+// Position returns the node's current position in the simulated graph.
+func (s *Simulation) Position() Position {
+	return Position{
+		Closeness:   s.closeness(),
+		Betweenness: s.betweenness(),
+	}
+}
+
+// Degree returns the number of channels of the node in the simulated graph.
+func (s *Simulation) Degree() int {
+	return len(s.adjList[s.index])
+}
+
+// Eigenvector returns the eigenvector centrality of the node in the simulated graph.
+func (s *Simulation) Eigenvector() uint64 {
+	return getEigenvectorCentrality(s.adjList)[s.index]
+}
+
+// Distances returns the length of the shortest paths from the node to the ones specified, -1 if they are
+// unreachable or not part of the graph.
+func (s *Simulation) Distances(publicKeys []string) []int {
+	distances := getDistances(s.adjList, s.index)
+
+	result := make([]int, 0, len(publicKeys))
+	for _, publicKey := range publicKeys {
+		i, ok := s.nodeIndices[publicKey]
+		if !ok {
+			result = append(result, -1)
+			continue
+		}
+		result = append(result, distances[i])
+	}
+
+	return result
+}
+
+func (s *Simulation) closeness() float64 {
+	distances := getDistances(s.adjList, s.index)
+
+	reachable := 0
+	sumDistances := 0
+	for _, distance := range distances {
+		if distance > 0 {
+			reachable++
+			sumDistances += distance
+		}
+	}
+
+	if sumDistances == 0 || len(s.adjList) < 2 {
+		return 0
+	}
+
+	// Wasserman and Faust closeness centrality
+	reachableRatio := float64(reachable) / float64(len(s.adjList)-1)
+	return reachableRatio * float64(reachable) / float64(sumDistances)
+}
+
+func (s *Simulation) betweenness() float64 {
+	if len(s.sources) == 0 {
+		return 0
+	}
+
+	sum := 0.0
+	for _, source := range s.sources {
+		if source == s.index {
+			continue
+		}
+		_, bc := getNodeCentrality(s.adjList, source, len(s.adjList))
+		sum += bc[s.index]
+	}
+
+	// Scale the estimation to the size of the graph
+	return sum * float64(len(s.adjList)) / float64(len(s.sources))
+}
+
+// sampleSources returns up to n nodes evenly spread across the graph to be used as the source of shortest
+// paths.
+func (g Graph) sampleSources(n int) []int {
+	if n <= 0 || len(g.Nodes) == 0 {
+		return nil
+	}
+
+	step := max(len(g.Nodes)/n, 1)
+	sources := make([]int, 0, n)
+	for i := 0; i < len(g.Nodes) && len(sources) < n; i += step {
+		sources = append(sources, g.nodeIndices[g.Nodes[i].PublicKey])
+	}
+
+	return sources
+}
+
+// getDistances returns the length of the shortest paths from the node s to all others, -1 if it's unreachable.
+func getDistances(adjList [][]int, s int) []int {
+	distances := make([]int, len(adjList))
+	for i := range distances {
+		distances[i] = -1
+	}
+	distances[s] = 0
+
+	queue := newQueue(len(adjList))
+	queue.push(s)
+
+	for !queue.empty() {
+		v := queue.pop()
+		for _, w := range adjList[v] {
+			if distances[w] < 0 {
+				distances[w] = distances[v] + 1
+				queue.push(w)
+			}
+		}
+	}
+
+	return distances
+}
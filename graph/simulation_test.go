@@ -0,0 +1,82 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulation(t *testing.T) {
+	g := newSimulationGraph()
+
+	simulation := g.NewSimulation("zed", nil, 8)
+	assert.Equal(t, Position{}, simulation.Position())
+
+	_, err := simulation.Evaluate("unknown")
+	assert.Error(t, err)
+
+	err = simulation.AddChannel(george)
+	assert.NoError(t, err)
+
+	base := simulation.Position()
+	assert.Greater(t, base.Closeness, 0.0)
+	assert.Equal(t, 0.0, base.Betweenness)
+
+	// A channel to a peripheral node on the other side of the graph makes us a bridge
+	position, err := simulation.Evaluate(harold)
+	assert.NoError(t, err)
+	assert.Greater(t, position.Closeness, base.Closeness)
+	assert.Greater(t, position.Betweenness, base.Betweenness)
+
+	// Evaluating must not modify the simulation
+	assert.Equal(t, base, simulation.Position())
+	assert.Len(t, g.adjList[nodeIndices[harold]], 1)
+}
+
+func TestNewSimulationPeers(t *testing.T) {
+	g := newSimulationGraph()
+
+	simulation := g.NewSimulation(alice, []string{carol, frank, bob}, 8)
+
+	// Existing channels are not duplicated while new ones are added in both directions
+	assert.Len(t, simulation.adjList[nodeIndices[alice]], 3)
+	assert.Contains(t, simulation.adjList[nodeIndices[bob]], nodeIndices[alice])
+	assert.Len(t, g.adjList[nodeIndices[alice]], 2)
+	assert.Len(t, g.adjList[nodeIndices[bob]], 1)
+}
+
+func TestSimulationMeasures(t *testing.T) {
+	g := newSimulationGraph()
+
+	simulation := g.NewSimulation("zed", []string{george}, 8)
+	assert.Equal(t, 1, simulation.Degree())
+	before := simulation.Eigenvector()
+	distances := simulation.Distances([]string{george, harold, "unknown"})
+	assert.Equal(t, 1, distances[0])
+	assert.Greater(t, distances[1], 1)
+	assert.Equal(t, -1, distances[2])
+
+	assert.NoError(t, simulation.AddChannel(harold))
+	assert.Equal(t, 2, simulation.Degree())
+	assert.Greater(t, simulation.Eigenvector(), before)
+	assert.Equal(t, []int{1, 1}, simulation.Distances([]string{george, harold}))
+}
+
+func TestGetDistances(t *testing.T) {
+	expected := []int{0, 3, 1, 2, 2, 1, 3, 2}
+	distances := getDistances(adjList, nodeIndices[alice])
+	assert.Equal(t, expected, distances)
+}
+
+func newSimulationGraph() Graph {
+	nodes := make([]Node, len(nodeIndices))
+	for publicKey, i := range nodeIndices {
+		nodes[i] = Node{PublicKey: publicKey}
+	}
+
+	return Graph{
+		Nodes:       nodes,
+		adjList:     adjList,
+		nodeIndices: nodeIndices,
+	}
+}
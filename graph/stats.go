@@ -0,0 +1,235 @@
+package graph
+
+import (
+	"math"
+	"slices"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+)
+
+// Number of breadth-first searches used to estimate the diameter of the graph.
+const diameterSweeps = 4
+
+// Stats contains statistics of the network graph, before and after filtering the nodes that are not scored.
+type Stats struct {
+	Nodes int `json:"nodes"`
+	Edges int `json:"edges"`
+	// Edges without policies on either side
+	SkippedEdges int `json:"skipped_edges"`
+	// Policies of the rest of the edges that are missing, disabled or whose fees are outliers
+	DiscardedPolicies int `json:"discarded_policies"`
+	// Network averages nodes must reach to be scored
+	AvgNodeCapacity uint64 `json:"avg_node_capacity"`
+	AvgNumChannels  int    `json:"avg_num_channels"`
+	FilteredNodes   int    `json:"filtered_nodes"`
+	FilteredEdges   int    `json:"filtered_edges"`
+	// Nodes in the largest connected component of the filtered graph
+	LargestComponent int `json:"largest_component"`
+	// Lower bound of the longest shortest path in the largest component of the filtered graph
+	Diameter   int               `json:"diameter"`
+	Capacity   Distribution      `json:"capacity"`
+	FeeRate    Distribution      `json:"fee_rate"`
+	Centrality CentralityDistros `json:"centrality"`
+}
+
+// CentralityDistros contains the distributions of the centrality measures of the filtered nodes.
+type CentralityDistros struct {
+	Degree      Distribution `json:"degree"`
+	Betweenness Distribution `json:"betweenness"`
+	Eigenvector Distribution `json:"eigenvector"`
+	Closeness   Distribution `json:"closeness"`
+}
+
+// Distribution summarizes a set of values.
+type Distribution struct {
+	Min    float64 `json:"min"`
+	P10    float64 `json:"p10"`
+	P25    float64 `json:"p25"`
+	Median float64 `json:"median"`
+	P75    float64 `json:"p75"`
+	P90    float64 `json:"p90"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+}
+
+// NewStats returns the statistics of the graph built from the channel graph.
+func NewStats(channelGraph *lnrpc.ChannelGraph, g Graph) Stats {
+	channels, totalCapacity, skippedEdges := getChannels(channelGraph)
+	filter := newNodeFilter(channelGraph, totalCapacity)
+
+	capacities := make([]float64, 0, len(channelGraph.Edges))
+	feeRates := make([]float64, 0, len(channelGraph.Edges)*2)
+	numPolicies := 0
+	for _, edge := range channelGraph.Edges {
+		capacities = append(capacities, float64(edge.Capacity))
+		if edge.Node1Policy != nil || edge.Node2Policy != nil {
+			numPolicies += 2
+		}
+	}
+
+	for _, nodeChannels := range channels {
+		for _, channel := range nodeChannels {
+			feeRates = append(feeRates, float64(channel.FeeRate))
+		}
+	}
+
+	filtered := g.filteredAdjList()
+	edges := 0
+	for _, peers := range filtered {
+		edges += len(peers)
+	}
+
+	component := largestComponent(filtered)
+
+	return Stats{
+		Nodes:             len(channelGraph.Nodes),
+		Edges:             len(channelGraph.Edges),
+		SkippedEdges:      skippedEdges,
+		DiscardedPolicies: numPolicies - len(feeRates),
+		AvgNodeCapacity:   filter.avgNodeSize,
+		AvgNumChannels:    filter.avgNumChannels,
+		FilteredNodes:     len(g.Nodes),
+		FilteredEdges:     edges / 2,
+		LargestComponent:  len(component),
+		Diameter:          estimateDiameter(filtered, component),
+		Capacity:          newDistribution(capacities),
+		FeeRate:           newDistribution(feeRates),
+		Centrality:        g.centralityDistros(),
+	}
+}
+
+// filteredAdjList returns the undirected adjacency list of the graph restricted to the nodes scored, the
+// channels with filtered out nodes are dropped.
+func (g Graph) filteredAdjList() [][]int {
+	adjList := make([][]int, len(g.adjList))
+	scored := make([]bool, len(g.adjList))
+	for _, node := range g.Nodes {
+		scored[g.nodeIndices[node.PublicKey]] = true
+	}
+
+	for i, peers := range g.adjList {
+		if !scored[i] {
+			continue
+		}
+		for _, j := range peers {
+			if scored[j] {
+				adjList[i] = append(adjList[i], j)
+				adjList[j] = append(adjList[j], i)
+			}
+		}
+	}
+
+	// Remove parallel channels and the ones added from both sides
+	for i := range adjList {
+		slices.Sort(adjList[i])
+		adjList[i] = slices.Compact(adjList[i])
+	}
+
+	return adjList
+}
+
+func (g Graph) centralityDistros() CentralityDistros {
+	degree := make([]float64, 0, len(g.Nodes))
+	betweenness := make([]float64, 0, len(g.Nodes))
+	eigenvector := make([]float64, 0, len(g.Nodes))
+	closeness := make([]float64, 0, len(g.Nodes))
+	for _, node := range g.Nodes {
+		degree = append(degree, node.Centrality.Degree)
+		betweenness = append(betweenness, node.Centrality.Betweenness)
+		eigenvector = append(eigenvector, float64(node.Centrality.Eigenvector))
+		closeness = append(closeness, node.Centrality.Closeness)
+	}
+
+	return CentralityDistros{
+		Degree:      newDistribution(degree),
+		Betweenness: newDistribution(betweenness),
+		Eigenvector: newDistribution(eigenvector),
+		Closeness:   newDistribution(closeness),
+	}
+}
+
+// largestComponent returns the nodes of the largest connected component.
+func largestComponent(adjList [][]int) []int {
+	visited := make([]bool, len(adjList))
+	var largest []int
+
+	for s := range adjList {
+		if visited[s] || len(adjList[s]) == 0 {
+			continue
+		}
+
+		component := []int{s}
+		visited[s] = true
+		for i := 0; i < len(component); i++ {
+			for _, w := range adjList[component[i]] {
+				if !visited[w] {
+					visited[w] = true
+					component = append(component, w)
+				}
+			}
+		}
+
+		if len(component) > len(largest) {
+			largest = component
+		}
+	}
+
+	return largest
+}
+
+// estimateDiameter returns a lower bound of the diameter of the connected component using repeated sweeps:
+// the farthest node found by each breadth-first search is the source of the next one.
+func estimateDiameter(adjList [][]int, component []int) int {
+	if len(component) == 0 {
+		return 0
+	}
+
+	diameter := 0
+	source := component[0]
+	for range diameterSweeps {
+		distances := getDistances(adjList, source)
+		farthest := source
+		for _, v := range component {
+			if distances[v] > distances[farthest] {
+				farthest = v
+			}
+		}
+
+		diameter = max(diameter, distances[farthest])
+		source = farthest
+	}
+
+	return diameter
+}
+
+// newDistribution returns the distribution of the values.
+func newDistribution(values []float64) Distribution {
+	if len(values) == 0 {
+		return Distribution{}
+	}
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	sum := 0.0
+	for _, value := range sorted {
+		sum += value
+	}
+
+	return Distribution{
+		Min:    sorted[0],
+		P10:    percentile(sorted, 0.1),
+		P25:    percentile(sorted, 0.25),
+		Median: percentile(sorted, 0.5),
+		P75:    percentile(sorted, 0.75),
+		P90:    percentile(sorted, 0.9),
+		Max:    sorted[len(sorted)-1],
+		Mean:   sum / float64(len(sorted)),
+	}
+}
+
+// percentile returns the value at the percentile of a sorted list using the nearest rank.
+func percentile(sorted []float64, p float64) float64 {
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[min(max(i, 0), len(sorted)-1)]
+}
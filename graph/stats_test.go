@@ -0,0 +1,73 @@
+package graph_test
+
+import (
+	"testing"
+
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/graph"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStats(t *testing.T) {
+	policy := &lnrpc.RoutingPolicy{FeeRateMilliMsat: 100}
+	edge := func(node1, node2 string) *lnrpc.ChannelEdge {
+		return &lnrpc.ChannelEdge{
+			Node1Pub:    node1,
+			Node2Pub:    node2,
+			Capacity:    1_000_000,
+			Node1Policy: policy,
+			Node2Policy: policy,
+		}
+	}
+	outlier := edge("bob", "dave")
+	outlier.Node2Policy = &lnrpc.RoutingPolicy{FeeRateMilliMsat: 10_000}
+	missing := edge("erin", "frank")
+	missing.Node1Policy, missing.Node2Policy = nil, nil
+
+	nodes := make([]*lnrpc.LightningNode, 0)
+	for _, publicKey := range []string{"alice", "bob", "carol", "dave", "erin", "frank"} {
+		nodes = append(nodes, &lnrpc.LightningNode{
+			PubKey:    publicKey,
+			Addresses: []*lnrpc.NodeAddress{{Addr: "127.0.0.1:9735"}},
+		})
+	}
+	channelGraph := &lnrpc.ChannelGraph{
+		Nodes: nodes,
+		Edges: []*lnrpc.ChannelEdge{
+			edge("alice", "bob"),
+			edge("bob", "carol"),
+			edge("carol", "dave"),
+			outlier,
+			missing,
+		},
+	}
+
+	g, err := graph.FromChannelGraph(t.Context(), config.DefaultOpenWeights, channelGraph)
+	assert.NoError(t, err)
+
+	stats := graph.NewStats(channelGraph, g)
+
+	assert.Equal(t, 6, stats.Nodes)
+	assert.Equal(t, 5, stats.Edges)
+	assert.Equal(t, 1, stats.SkippedEdges)
+	assert.Equal(t, 1, stats.DiscardedPolicies)
+	assert.Equal(t, uint64(833_333), stats.AvgNodeCapacity)
+	assert.Equal(t, 4, stats.FilteredNodes)
+	assert.Equal(t, 4, stats.FilteredEdges)
+	assert.Equal(t, 4, stats.LargestComponent)
+	assert.Equal(t, 2, stats.Diameter)
+	assert.Equal(t, graph.Distribution{
+		Min:    1_000_000,
+		P10:    1_000_000,
+		P25:    1_000_000,
+		Median: 1_000_000,
+		P75:    1_000_000,
+		P90:    1_000_000,
+		Max:    1_000_000,
+		Mean:   1_000_000,
+	}, stats.Capacity)
+	assert.Equal(t, 100.0, stats.FeeRate.Max)
+	assert.Equal(t, 0.0, stats.Centrality.Betweenness.Min)
+}
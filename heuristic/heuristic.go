@@ -82,3 +82,31 @@ func (h *Heuristic[T]) GetScore(value T) float64 {
 
 	return score * h.weight
 }
+
+// Component describes the contribution of a heuristic to a score.
+type Component struct {
+	Name    string  `json:"name"`
+	Value   float64 `json:"value"`
+	Lowest  float64 `json:"lowest"`
+	Highest float64 `json:"highest"`
+	Weight  float64 `json:"weight"`
+	Score   float64 `json:"score"`
+}
+
+// Explain returns the contribution of the value to the score along with the heuristic range and weight.
+func (h *Heuristic[T]) Explain(name string, value T) Component {
+	component := Component{
+		Name:   name,
+		Value:  float64(value),
+		Weight: h.weight,
+		Score:  h.GetScore(value),
+	}
+
+	// The range is unset until the heuristic is updated
+	if h.lowest <= h.highest {
+		component.Lowest = float64(h.lowest)
+		component.Highest = float64(h.highest)
+	}
+
+	return component
+}
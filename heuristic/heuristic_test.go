@@ -112,3 +112,24 @@ func TestHeuristic(t *testing.T) {
 		})
 	}
 }
+
+func TestExplain(t *testing.T) {
+	// Heuristics without weight are never updated
+	unweighted := heuristic.New[uint64](0, false)
+	unweighted.Update(10)
+	assert.Equal(t, heuristic.Component{Name: "capacity", Value: 10}, unweighted.Explain("capacity", 10))
+
+	h := heuristic.New[uint64](0.5, false)
+	h.Update(10)
+	h.Update(30)
+
+	expected := heuristic.Component{
+		Name:    "capacity",
+		Value:   20,
+		Lowest:  10,
+		Highest: 30,
+		Weight:  0.5,
+		Score:   0.25,
+	}
+	assert.Equal(t, expected, h.Explain("capacity", 20))
+}
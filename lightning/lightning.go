@@ -37,6 +37,8 @@ const (
 
 	// MaxForwardingEvents is the maximum number of forwarding events to get per RPC call.
 	MaxForwardingEvents = 50_000
+	// Maximum number of payments to get per RPC call
+	maxPayments = 10_000
 )
 
 // Stream implements a method that receives updates from a stream.
@@ -55,11 +57,14 @@ type Client interface {
 	EstimateRouteFee(ctx context.Context, publicKey string) (*routerrpc.RouteFeeResponse, error)
 	GetChanInfo(ctx context.Context, channelID uint64) (*lnrpc.ChannelEdge, error)
 	GetInfo(ctx context.Context) (*lnrpc.GetInfoResponse, error)
+	GetTransactions(ctx context.Context) ([]*lnrpc.Transaction, error)
 	ListChannels(ctx context.Context) ([]*lnrpc.Channel, error)
 	ListForwards(ctx context.Context, channelID uint64, startTime, endTime uint64, indexOffset uint32) (*lnrpc.ForwardingHistoryResponse, error)
+	ListPayments(ctx context.Context, startTime, endTime uint64) ([]*lnrpc.Payment, error)
 	ListPeers(ctx context.Context) ([]*lnrpc.Peer, error)
 	QueryRoute(ctx context.Context, publicKey string) (*lnrpc.QueryRoutesResponse, error)
-	UpdateChannelPolicy(ctx context.Context, channelPoint string, baseFeeMsat, feeRatePPM, maxHTLCMsat, timeLockDelta uint64) error
+	UpdateChannelPolicy(ctx context.Context, req *lnrpc.PolicyUpdateRequest) error
+	UpdateChanStatus(ctx context.Context, channelPoint string, action routerrpc.ChanStatusAction) error
 	WalletBalance(ctx context.Context, minConf int32) (*lnrpc.WalletBalanceResponse, error)
 }
 
@@ -95,11 +100,12 @@ func NewClient(config config.Lightning) (Client, error) {
 		return nil, errors.Wrap(err, "disabling LND's autopilot")
 	}
 
-	return &client{
+	client := &client{
 		ln:     lnrpc.NewLightningClient(conn),
 		router: routerrpc.NewRouterClient(conn),
 		wallet: walletrpc.NewWalletKitClient(conn),
-	}, nil
+	}
+	return newInstrumentedClient(client), nil
 }
 
 func loadGRPCOpts(config config.Lightning, logger logger.Logger) ([]grpc.DialOption, error) {
@@ -290,6 +296,17 @@ func (c *client) GetInfo(ctx context.Context) (*lnrpc.GetInfoResponse, error) {
 	return c.ln.GetInfo(ctx, &lnrpc.GetInfoRequest{})
 }
 
+// GetTransactions returns the on-chain transactions relevant to the wallet.
+func (c *client) GetTransactions(ctx context.Context) ([]*lnrpc.Transaction, error) {
+	// An end height of -1 includes unconfirmed transactions
+	resp, err := c.ln.GetTransactions(ctx, &lnrpc.GetTransactionsRequest{EndHeight: -1})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Transactions, nil
+}
+
 // ListChannels returns a description of all the open channels that this node is a participant in.
 func (c *client) ListChannels(ctx context.Context) ([]*lnrpc.Channel, error) {
 	resp, err := c.ln.ListChannels(ctx, &lnrpc.ListChannelsRequest{})
@@ -324,6 +341,38 @@ func (c *client) ListForwards(
 	})
 }
 
+// ListPayments returns the successful payments sent within the period specified, paginating over the results.
+func (c *client) ListPayments(ctx context.Context, startTime, endTime uint64) ([]*lnrpc.Payment, error) {
+	payments := make([]*lnrpc.Payment, 0)
+	offset := uint64(0)
+
+	for {
+		resp, err := c.ln.ListPayments(ctx, &lnrpc.ListPaymentsRequest{
+			IndexOffset:       offset,
+			MaxPayments:       maxPayments,
+			CreationDateStart: startTime,
+			CreationDateEnd:   endTime,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, payment := range resp.Payments {
+			if payment.Status == lnrpc.Payment_SUCCEEDED {
+				payments = append(payments, payment)
+			}
+		}
+
+		if len(resp.Payments) != maxPayments {
+			break
+		}
+
+		offset = resp.LastIndexOffset
+	}
+
+	return payments, nil
+}
+
 // ListPeers returns a verbose listing of all currently active peers.
 func (c *client) ListPeers(ctx context.Context) ([]*lnrpc.Peer, error) {
 	resp, err := c.ln.ListPeers(ctx, &lnrpc.ListPeersRequest{})
@@ -345,23 +394,7 @@ func (c *client) QueryRoute(ctx context.Context, publicKey string) (*lnrpc.Query
 }
 
 // UpdateChannelPolicy updates the fee schedule and channel policies for a particular channel.
-func (c *client) UpdateChannelPolicy(
-	ctx context.Context, channelPoint string, baseFeeMsat, feeRatePPM, maxHTLCMsat, timeLockDelta uint64,
-) error {
-	chanPoint, err := ParseChannelPoint(channelPoint)
-	if err != nil {
-		return err
-	}
-
-	req := &lnrpc.PolicyUpdateRequest{
-		Scope: &lnrpc.PolicyUpdateRequest_ChanPoint{
-			ChanPoint: chanPoint,
-		},
-		BaseFeeMsat:   int64(baseFeeMsat),
-		FeeRatePpm:    uint32(feeRatePPM),
-		MaxHtlcMsat:   maxHTLCMsat,
-		TimeLockDelta: uint32(timeLockDelta),
-	}
+func (c *client) UpdateChannelPolicy(ctx context.Context, req *lnrpc.PolicyUpdateRequest) error {
 	resp, err := c.ln.UpdateChannelPolicy(ctx, req)
 	if err != nil {
 		return errors.Wrap(err, "updating channel policy")
@@ -374,6 +407,29 @@ func (c *client) UpdateChannelPolicy(
 	return nil
 }
 
+// UpdateChanStatus enables or disables our direction of a channel, or gives the control of its status back
+// to LND.
+func (c *client) UpdateChanStatus(
+	ctx context.Context,
+	channelPoint string,
+	action routerrpc.ChanStatusAction,
+) error {
+	chanPoint, err := ParseChannelPoint(channelPoint)
+	if err != nil {
+		return errors.Wrapf(err, "parsing channel point %q", channelPoint)
+	}
+
+	req := &routerrpc.UpdateChanStatusRequest{
+		ChanPoint: chanPoint,
+		Action:    action,
+	}
+	if _, err := c.router.UpdateChanStatus(ctx, req); err != nil {
+		return errors.Wrap(err, "updating channel status")
+	}
+
+	return nil
+}
+
 // WalletBalance returns confirmed/unconfirmed and the total number of UTXOs under control of the
 // wallet.
 func (c *client) WalletBalance(ctx context.Context, minConf int32) (*lnrpc.WalletBalanceResponse, error) {
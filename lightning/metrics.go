@@ -0,0 +1,134 @@
+package lightning
+
+import (
+	"context"
+	"time"
+
+	"github.com/aftermath2/hydrus/metrics"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+)
+
+// instrumentedClient records the latency of each of the client methods.
+type instrumentedClient struct {
+	client Client
+}
+
+func newInstrumentedClient(client Client) Client {
+	return &instrumentedClient{client: client}
+}
+
+func observe(method string, start time.Time) {
+	metrics.LightningRequestDuration.WithLabelValues(method).Observe(metrics.Since(start))
+}
+
+func (c *instrumentedClient) BatchOpenChannel(ctx context.Context, req *lnrpc.BatchOpenChannelRequest) (string, error) {
+	defer observe("BatchOpenChannel", time.Now())
+	return c.client.BatchOpenChannel(ctx, req)
+}
+
+func (c *instrumentedClient) CloseChannel(
+	ctx context.Context,
+	req *lnrpc.CloseChannelRequest,
+) (Stream[*lnrpc.CloseStatusUpdate], error) {
+	defer observe("CloseChannel", time.Now())
+	return c.client.CloseChannel(ctx, req)
+}
+
+func (c *instrumentedClient) ClosedChannels(ctx context.Context) ([]*lnrpc.ChannelCloseSummary, error) {
+	defer observe("ClosedChannels", time.Now())
+	return c.client.ClosedChannels(ctx)
+}
+
+func (c *instrumentedClient) ConnectPeer(ctx context.Context, publicKey string, addresses []string) error {
+	defer observe("ConnectPeer", time.Now())
+	return c.client.ConnectPeer(ctx, publicKey, addresses)
+}
+
+func (c *instrumentedClient) DescribeGraph(ctx context.Context) (*lnrpc.ChannelGraph, error) {
+	defer observe("DescribeGraph", time.Now())
+	return c.client.DescribeGraph(ctx)
+}
+
+func (c *instrumentedClient) EstimateTxFee(ctx context.Context, targetConf int32) (uint64, error) {
+	defer observe("EstimateTxFee", time.Now())
+	return c.client.EstimateTxFee(ctx, targetConf)
+}
+
+func (c *instrumentedClient) EstimateRouteFee(
+	ctx context.Context,
+	publicKey string,
+) (*routerrpc.RouteFeeResponse, error) {
+	defer observe("EstimateRouteFee", time.Now())
+	return c.client.EstimateRouteFee(ctx, publicKey)
+}
+
+func (c *instrumentedClient) GetChanInfo(ctx context.Context, channelID uint64) (*lnrpc.ChannelEdge, error) {
+	defer observe("GetChanInfo", time.Now())
+	return c.client.GetChanInfo(ctx, channelID)
+}
+
+func (c *instrumentedClient) GetInfo(ctx context.Context) (*lnrpc.GetInfoResponse, error) {
+	defer observe("GetInfo", time.Now())
+	return c.client.GetInfo(ctx)
+}
+
+func (c *instrumentedClient) GetTransactions(ctx context.Context) ([]*lnrpc.Transaction, error) {
+	defer observe("GetTransactions", time.Now())
+	return c.client.GetTransactions(ctx)
+}
+
+func (c *instrumentedClient) ListChannels(ctx context.Context) ([]*lnrpc.Channel, error) {
+	defer observe("ListChannels", time.Now())
+	return c.client.ListChannels(ctx)
+}
+
+func (c *instrumentedClient) ListForwards(
+	ctx context.Context,
+	channelID uint64,
+	startTime,
+	endTime uint64,
+	indexOffset uint32,
+) (*lnrpc.ForwardingHistoryResponse, error) {
+	defer observe("ListForwards", time.Now())
+	return c.client.ListForwards(ctx, channelID, startTime, endTime, indexOffset)
+}
+
+func (c *instrumentedClient) ListPayments(
+	ctx context.Context,
+	startTime,
+	endTime uint64,
+) ([]*lnrpc.Payment, error) {
+	defer observe("ListPayments", time.Now())
+	return c.client.ListPayments(ctx, startTime, endTime)
+}
+
+func (c *instrumentedClient) ListPeers(ctx context.Context) ([]*lnrpc.Peer, error) {
+	defer observe("ListPeers", time.Now())
+	return c.client.ListPeers(ctx)
+}
+
+func (c *instrumentedClient) QueryRoute(ctx context.Context, publicKey string) (*lnrpc.QueryRoutesResponse, error) {
+	defer observe("QueryRoute", time.Now())
+	return c.client.QueryRoute(ctx, publicKey)
+}
+
+func (c *instrumentedClient) UpdateChannelPolicy(ctx context.Context, req *lnrpc.PolicyUpdateRequest) error {
+	defer observe("UpdateChannelPolicy", time.Now())
+	return c.client.UpdateChannelPolicy(ctx, req)
+}
+
+func (c *instrumentedClient) UpdateChanStatus(
+	ctx context.Context,
+	channelPoint string,
+	action routerrpc.ChanStatusAction,
+) error {
+	defer observe("UpdateChanStatus", time.Now())
+	return c.client.UpdateChanStatus(ctx, channelPoint, action)
+}
+
+func (c *instrumentedClient) WalletBalance(ctx context.Context, minConf int32) (*lnrpc.WalletBalanceResponse, error) {
+	defer observe("WalletBalance", time.Now())
+	return c.client.WalletBalance(ctx, minConf)
+}
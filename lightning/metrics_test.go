@@ -0,0 +1,41 @@
+package lightning
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aftermath2/hydrus/metrics"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrumentedClient(t *testing.T) {
+	ctx := context.Background()
+	expected := &lnrpc.GetInfoResponse{IdentityPubkey: "public_key"}
+
+	lnd := NewClientMock()
+	lnd.On("GetInfo", ctx).Return(expected, nil)
+
+	client := newInstrumentedClient(lnd)
+	info, err := client.GetInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, expected, info)
+	lnd.AssertExpectations(t)
+
+	server := httptest.NewServer(metrics.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(body), `hydrus_lightning_request_duration_seconds_count{method="GetInfo"} 1`)
+}
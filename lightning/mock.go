@@ -84,6 +84,12 @@ func (c *ClientMock) GetInfo(ctx context.Context) (*lnrpc.GetInfoResponse, error
 	return mockReturn[*lnrpc.GetInfoResponse](args)
 }
 
+// GetTransactions mock.
+func (c *ClientMock) GetTransactions(ctx context.Context) ([]*lnrpc.Transaction, error) {
+	args := c.Called(ctx)
+	return mockReturn[[]*lnrpc.Transaction](args)
+}
+
 // ListChannels mock.
 func (c *ClientMock) ListChannels(ctx context.Context) ([]*lnrpc.Channel, error) {
 	args := c.Called(ctx)
@@ -102,6 +108,12 @@ func (c *ClientMock) ListForwards(
 	return mockReturn[*lnrpc.ForwardingHistoryResponse](args)
 }
 
+// ListPayments mock.
+func (c *ClientMock) ListPayments(ctx context.Context, startTime, endTime uint64) ([]*lnrpc.Payment, error) {
+	args := c.Called(ctx, startTime, endTime)
+	return mockReturn[[]*lnrpc.Payment](args)
+}
+
 // ListPeers mock.
 func (c *ClientMock) ListPeers(ctx context.Context) ([]*lnrpc.Peer, error) {
 	args := c.Called(ctx)
@@ -121,10 +133,18 @@ func (c *ClientMock) WalletBalance(ctx context.Context, minConf int32) (*lnrpc.W
 }
 
 // UpdateChannelPolicy mock.
-func (c *ClientMock) UpdateChannelPolicy(
-	ctx context.Context, channelPoint string, baseFeeMsat, feeRatePPM, maxHTLCMsat, timeLockDelta uint64,
+func (c *ClientMock) UpdateChannelPolicy(ctx context.Context, req *lnrpc.PolicyUpdateRequest) error {
+	args := c.Called(ctx, req)
+	return args.Error(0)
+}
+
+// UpdateChanStatus mock.
+func (c *ClientMock) UpdateChanStatus(
+	ctx context.Context,
+	channelPoint string,
+	action routerrpc.ChanStatusAction,
 ) error {
-	args := c.Called(ctx, channelPoint, baseFeeMsat, feeRatePPM, maxHTLCMsat, timeLockDelta)
+	args := c.Called(ctx, channelPoint, action)
 	return args.Error(0)
 }
 
@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/aftermath2/hydrus/logger"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace       = "hydrus"
+	shutdownTimeout = 5 * time.Second
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	// WalletBalance is the node's confirmed on-chain balance.
+	WalletBalance = promauto.With(registry).NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "wallet_balance_sats",
+		Help:      "Confirmed on-chain balance of the node.",
+	})
+	// AllocatedBalance is the part of the wallet balance the agent can use to open channels.
+	AllocatedBalance = promauto.With(registry).NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "allocated_balance_sats",
+		Help:      "Wallet balance allocated to open channels.",
+	})
+	// Channels is the number of channels the node has.
+	Channels = promauto.With(registry).NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "channels",
+		Help:      "Number of active, inactive and pending channels.",
+	})
+	// ChannelScore is the score of each of the node's public channels.
+	ChannelScore = promauto.With(registry).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "channel_score",
+		Help:      "Score of the public channels based on the closing heuristics.",
+	}, []string{"channel_point", "remote_public_key"})
+
+	// ChannelsOpened is the number of channels opened.
+	ChannelsOpened = promauto.With(registry).NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "channels_opened_total",
+		Help:      "Number of channels opened.",
+	})
+	// ChannelsClosed is the number of channels closed.
+	ChannelsClosed = promauto.With(registry).NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "channels_closed_total",
+		Help:      "Number of channels closed.",
+	})
+	// PolicyUpdates is the number of routing policies updated.
+	PolicyUpdates = promauto.With(registry).NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "policy_updates_total",
+		Help:      "Number of channel routing policies updated.",
+	})
+	// TaskErrors is the number of task executions that failed.
+	TaskErrors = promauto.With(registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "task_errors_total",
+		Help:      "Number of task executions that returned an error.",
+	}, []string{"task"})
+	// TaskLastSuccess is the time of the last successful execution of each task.
+	TaskLastSuccess = promauto.With(registry).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "task_last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful execution of the task.",
+	}, []string{"task"})
+
+	// GraphDuration is the time it takes to build the network graph.
+	GraphDuration = promauto.With(registry).NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "graph_duration_seconds",
+		Help:      "Time spent building the network graph and its heuristics.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 8),
+	})
+	// CentralityDuration is the time it takes to calculate the centrality of the graph nodes.
+	CentralityDuration = promauto.With(registry).NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "centrality_duration_seconds",
+		Help:      "Time spent calculating the centrality of the network graph nodes.",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 8),
+	})
+	// LightningRequestDuration is the latency of the requests to the lightning node.
+	LightningRequestDuration = promauto.With(registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "lightning_request_duration_seconds",
+		Help:      "Latency of the lightning node client methods.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Since returns the seconds elapsed since the time specified, to be used with histograms.
+func Since(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
+
+// Handler returns the HTTP handler exposing the metrics.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Serve exposes the metrics on the /metrics path of the address until the context is canceled.
+func Serve(ctx context.Context, address string) error {
+	logger := logger.New("MET")
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return errors.Wrap(err, "listening on address")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", Handler())
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Errorf("Shutting down server: %v", err)
+		}
+	}()
+
+	logger.Infof("Serving metrics on %s", listener.Addr())
+
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return errors.Wrap(err, "serving requests")
+	}
+
+	return nil
+}
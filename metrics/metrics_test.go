@@ -0,0 +1,35 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aftermath2/hydrus/metrics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	metrics.ChannelsOpened.Add(2)
+	metrics.ChannelScore.WithLabelValues("txid:0", "public_key").Set(0.5)
+	metrics.TaskLastSuccess.WithLabelValues("channels").Set(1700000000)
+
+	server := httptest.NewServer(metrics.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "hydrus_channels_opened_total 2")
+	assert.Contains(t, string(body), `hydrus_channel_score{channel_point="txid:0",remote_public_key="public_key"} 0.5`)
+	assert.Contains(t, string(body), `hydrus_task_last_success_timestamp_seconds{task="channels"} 1.7e+09`)
+	assert.Contains(t, string(body), "go_goroutines")
+}
@@ -0,0 +1,265 @@
+// Package notifier delivers the events emitted by the agent to external services.
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aftermath2/hydrus/config"
+	"github.com/aftermath2/hydrus/logger"
+
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+const notifyTimeout = 30 * time.Second
+
+// EventType is the kind of event emitted by the agent.
+type EventType string
+
+// Events emitted by the agent.
+const (
+	EventChannelsOpened EventType = config.EventChannelsOpened
+	EventChannelClosed  EventType = config.EventChannelClosed
+	EventRunSkipped     EventType = config.EventRunSkipped
+	EventTaskError      EventType = config.EventTaskError
+)
+
+// Event contains information about an action taken by the agent or a failure.
+type Event struct {
+	Time time.Time `json:"time"`
+	// map[public_key]funding_amount
+	Channels     map[string]uint64 `json:"channels,omitempty"`
+	Type         EventType         `json:"type"`
+	Task         string            `json:"task,omitempty"`
+	TxID         string            `json:"txid,omitempty"`
+	ChannelPoint string            `json:"channel_point,omitempty"`
+	Reason       string            `json:"reason,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	Force        bool              `json:"force,omitempty"`
+}
+
+// NewChannelsOpened returns the event of a batch of channels opened in the transaction.
+func NewChannelsOpened(txID string, channels map[string]uint64) Event {
+	return Event{
+		Time:     time.Now(),
+		Type:     EventChannelsOpened,
+		TxID:     txID,
+		Channels: channels,
+	}
+}
+
+// NewChannelClosed returns the event of a channel closed in the transaction.
+func NewChannelClosed(channelPoint, txID string, force bool) Event {
+	return Event{
+		Time:         time.Now(),
+		Type:         EventChannelClosed,
+		ChannelPoint: channelPoint,
+		TxID:         txID,
+		Force:        force,
+	}
+}
+
+// NewRunSkipped returns the event of a task execution that was skipped.
+func NewRunSkipped(task, reason string) Event {
+	return Event{
+		Time:   time.Now(),
+		Type:   EventRunSkipped,
+		Task:   task,
+		Reason: reason,
+	}
+}
+
+// NewTaskError returns the event of a task execution that failed.
+func NewTaskError(task string, err error) Event {
+	return Event{
+		Time:  time.Now(),
+		Type:  EventTaskError,
+		Task:  task,
+		Error: err.Error(),
+	}
+}
+
+// Title returns a short description of the event.
+func (e Event) Title() string {
+	switch e.Type {
+	case EventChannelsOpened:
+		return fmt.Sprintf("Hydrus opened %d channels", len(e.Channels))
+	case EventChannelClosed:
+		return "Hydrus closed a channel"
+	case EventRunSkipped:
+		return fmt.Sprintf("Hydrus skipped the %s task", e.Task)
+	case EventTaskError:
+		return fmt.Sprintf("Hydrus %s task failed", e.Task)
+	default:
+		return "Hydrus event"
+	}
+}
+
+// Message returns a human readable description of the event.
+func (e Event) Message() string {
+	switch e.Type {
+	case EventChannelsOpened:
+		publicKeys := make([]string, 0, len(e.Channels))
+		for publicKey := range e.Channels {
+			publicKeys = append(publicKeys, publicKey)
+		}
+		sort.Strings(publicKeys)
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Opening channels in transaction %s:", e.TxID)
+		for _, publicKey := range publicKeys {
+			fmt.Fprintf(&sb, "\n%s: %d sats", publicKey, e.Channels[publicKey])
+		}
+		return sb.String()
+	case EventChannelClosed:
+		closeType := "Cooperatively"
+		if e.Force {
+			closeType = "Force"
+		}
+		return fmt.Sprintf("%s closing channel %s in transaction %s", closeType, e.ChannelPoint, e.TxID)
+	case EventRunSkipped:
+		return fmt.Sprintf("Skipped the %s task: %s", e.Task, e.Reason)
+	case EventTaskError:
+		return fmt.Sprintf("Executing the %s task failed: %s", e.Task, e.Error)
+	default:
+		return string(e.Type)
+	}
+}
+
+// Notifier delivers events to an external service.
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// New returns the notifier implementation for the type configured.
+func New(notifier config.Notifier) Notifier {
+	client := &http.Client{Timeout: notifyTimeout}
+
+	switch notifier.Type {
+	case config.NotifierTypeSMTP:
+		return newSMTP(notifier.SMTP)
+	case config.NotifierTypeTelegram:
+		return newTelegram(notifier.Telegram, client)
+	case config.NotifierTypeNtfy:
+		return newNtfy(notifier.Ntfy, client)
+	default:
+		return newWebhook(notifier.Webhook, client)
+	}
+}
+
+// filter delivers only the events selected and within the rate limit.
+type filter struct {
+	notifier Notifier
+	limiter  *rate.Limiter
+	events   []EventType
+}
+
+func newFilter(config config.Notifier, notifier Notifier) *filter {
+	f := &filter{notifier: notifier}
+
+	for _, event := range config.Events {
+		f.events = append(f.events, EventType(event))
+	}
+
+	if config.RateLimit.MaxEvents > 0 {
+		interval := config.RateLimit.Period / time.Duration(config.RateLimit.MaxEvents)
+		f.limiter = rate.NewLimiter(rate.Every(interval), config.RateLimit.MaxEvents)
+	}
+
+	return f
+}
+
+// allow returns whether the event should be delivered.
+func (f *filter) allow(event Event) bool {
+	if len(f.events) > 0 && !slices.Contains(f.events, event.Type) {
+		return false
+	}
+
+	return f.limiter == nil || f.limiter.Allow()
+}
+
+// Dispatcher sends the events to all the notifiers configured.
+//
+// All methods are safe to call on a nil dispatcher, in which case they do nothing.
+type Dispatcher struct {
+	logger  logger.Logger
+	filters []*filter
+	wg      sync.WaitGroup
+}
+
+// NewDispatcher returns a dispatcher for the notifiers configured, or nil if there are none.
+func NewDispatcher(configs []config.Notifier) *Dispatcher {
+	if len(configs) == 0 {
+		return nil
+	}
+
+	filters := make([]*filter, 0, len(configs))
+	for _, config := range configs {
+		filters = append(filters, newFilter(config, New(config)))
+	}
+
+	return &Dispatcher{
+		logger:  logger.New("NTF"),
+		filters: filters,
+	}
+}
+
+// Notify sends the event in the background to the notifiers that accept it, so slow services don't delay
+// the agent. Failures are logged and do not interrupt the agent.
+func (d *Dispatcher) Notify(ctx context.Context, event Event) {
+	if d == nil {
+		return
+	}
+
+	// Deliver the event even if the task that emitted it was canceled
+	ctx = context.WithoutCancel(ctx)
+
+	for _, f := range d.filters {
+		if !f.allow(event) {
+			d.logger.Debugf("Dropping %s event", event.Type)
+			continue
+		}
+
+		d.wg.Go(func() {
+			ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+			defer cancel()
+
+			if err := f.notifier.Notify(ctx, event); err != nil {
+				d.logger.Errorf("Sending %s event: %v", event.Type, err)
+			}
+		})
+	}
+}
+
+// Wait blocks until the events being sent are delivered or fail.
+func (d *Dispatcher) Wait() {
+	if d == nil {
+		return
+	}
+
+	d.wg.Wait()
+}
+
+// send executes the request and returns an error if the response status is not successful.
+func send(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "sending request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package notifier
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventMessage(t *testing.T) {
+	tests := []struct {
+		desc            string
+		event           Event
+		expectedTitle   string
+		expectedMessage string
+	}{
+		{
+			desc: "Channels opened",
+			event: NewChannelsOpened("txid", map[string]uint64{
+				"b": 2_000_000,
+				"a": 1_000_000,
+			}),
+			expectedTitle:   "Hydrus opened 2 channels",
+			expectedMessage: "Opening channels in transaction txid:\na: 1000000 sats\nb: 2000000 sats",
+		},
+		{
+			desc:            "Channel force closed",
+			event:           NewChannelClosed("txid:0", "closing_txid", true),
+			expectedTitle:   "Hydrus closed a channel",
+			expectedMessage: "Force closing channel txid:0 in transaction closing_txid",
+		},
+		{
+			desc:            "Run skipped",
+			event:           NewRunSkipped("channels", "agent is paused"),
+			expectedTitle:   "Hydrus skipped the channels task",
+			expectedMessage: "Skipped the channels task: agent is paused",
+		},
+		{
+			desc:            "Task error",
+			event:           NewTaskError("routing_policies", errors.New("timeout")),
+			expectedTitle:   "Hydrus routing_policies task failed",
+			expectedMessage: "Executing the routing_policies task failed: timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.expectedTitle, tt.event.Title())
+			assert.Equal(t, tt.expectedMessage, tt.event.Message())
+		})
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	tests := []struct {
+		desc      string
+		notifier  config.Notifier
+		events    []Event
+		delivered int32
+	}{
+		{
+			desc:      "All events",
+			notifier:  config.Notifier{},
+			events:    []Event{NewRunSkipped("channels", "paused"), NewTaskError("channels", errors.New("err"))},
+			delivered: 2,
+		},
+		{
+			desc:      "Filtered events",
+			notifier:  config.Notifier{Events: []string{config.EventTaskError}},
+			events:    []Event{NewRunSkipped("channels", "paused"), NewTaskError("channels", errors.New("err"))},
+			delivered: 1,
+		},
+		{
+			desc: "Rate limited",
+			notifier: config.Notifier{
+				RateLimit: config.RateLimit{MaxEvents: 2, Period: time.Hour},
+			},
+			events: []Event{
+				NewRunSkipped("channels", "paused"),
+				NewRunSkipped("channels", "paused"),
+				NewRunSkipped("channels", "paused"),
+			},
+			delivered: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var delivered atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				delivered.Add(1)
+			}))
+			defer server.Close()
+
+			tt.notifier.Type = config.NotifierTypeWebhook
+			tt.notifier.Webhook.URL = server.URL
+			dispatcher := NewDispatcher([]config.Notifier{tt.notifier})
+
+			for _, event := range tt.events {
+				dispatcher.Notify(t.Context(), event)
+			}
+			dispatcher.Wait()
+
+			assert.Equal(t, tt.delivered, delivered.Load())
+		})
+	}
+}
+
+func TestDispatcherNil(t *testing.T) {
+	dispatcher := NewDispatcher(nil)
+	assert.Nil(t, dispatcher)
+
+	// Must not panic
+	dispatcher.Notify(context.Background(), NewRunSkipped("channels", "paused"))
+	dispatcher.Wait()
+}
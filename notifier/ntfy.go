@@ -0,0 +1,46 @@
+package notifier
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/pkg/errors"
+)
+
+const ntfyURL = "https://ntfy.sh"
+
+// ntfy publishes the events to a topic of a ntfy server.
+type ntfy struct {
+	client *http.Client
+	url    string
+	token  string
+}
+
+func newNtfy(config config.Ntfy, client *http.Client) *ntfy {
+	return &ntfy{
+		client: client,
+		url:    strings.TrimSuffix(cmp.Or(config.URL, ntfyURL), "/") + "/" + config.Topic,
+		token:  config.Token,
+	}
+}
+
+func (n *ntfy) Notify(ctx context.Context, event Event) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, strings.NewReader(event.Message()))
+	if err != nil {
+		return errors.Wrap(err, "creating request")
+	}
+	req.Header.Set("Title", event.Title())
+	req.Header.Set("Tags", string(event.Type))
+	if event.Type == EventTaskError {
+		req.Header.Set("Priority", "high")
+	}
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	return send(n.client, req)
+}
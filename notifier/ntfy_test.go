@@ -0,0 +1,40 @@
+package notifier
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNtfy(t *testing.T) {
+	event := NewTaskError("channels", errors.New("timeout"))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/hydrus", r.URL.Path)
+		assert.Equal(t, event.Title(), r.Header.Get("Title"))
+		assert.Equal(t, "task_error", r.Header.Get("Tags"))
+		assert.Equal(t, "high", r.Header.Get("Priority"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, event.Message(), string(body))
+	}))
+	defer server.Close()
+
+	ntfy := newNtfy(config.Ntfy{URL: server.URL + "/", Topic: "hydrus", Token: "token"}, server.Client())
+	err := ntfy.Notify(t.Context(), event)
+	require.NoError(t, err)
+}
+
+func TestNtfyDefaultURL(t *testing.T) {
+	ntfy := newNtfy(config.Ntfy{Topic: "hydrus"}, http.DefaultClient)
+	assert.Equal(t, "https://ntfy.sh/hydrus", ntfy.url)
+}
@@ -0,0 +1,132 @@
+package notifier
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/pkg/errors"
+)
+
+// smtpNotifier sends the events by email.
+type smtpNotifier struct {
+	auth smtp.Auth
+	// Configuration used to connect with implicit TLS, nil to upgrade the connection with STARTTLS if the
+	// server supports it
+	tlsConfig *tls.Config
+	address   string
+	host      string
+	from      string
+	to        []string
+}
+
+func newSMTP(config config.SMTP) *smtpNotifier {
+	// The address was validated when loading the configuration
+	host, _, _ := net.SplitHostPort(config.Address)
+
+	var auth smtp.Auth
+	if config.Username != "" {
+		auth = smtp.PlainAuth("", config.Username, config.Password, host)
+	}
+
+	var tlsConfig *tls.Config
+	if config.TLS {
+		tlsConfig = &tls.Config{ServerName: host}
+	}
+
+	return &smtpNotifier{
+		auth:      auth,
+		tlsConfig: tlsConfig,
+		address:   config.Address,
+		host:      host,
+		from:      config.From,
+		to:        config.To,
+	}
+}
+
+func (s *smtpNotifier) Notify(ctx context.Context, event Event) error {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return errors.Wrap(err, "connecting to mail server")
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return errors.Wrap(err, "setting connection deadline")
+		}
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		return errors.Wrap(err, "creating smtp client")
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && s.tlsConfig == nil {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return errors.Wrap(err, "starting tls")
+		}
+	}
+
+	if s.auth != nil {
+		if err := client.Auth(s.auth); err != nil {
+			return errors.Wrap(err, "authenticating")
+		}
+	}
+
+	if err := client.Mail(s.from); err != nil {
+		return errors.Wrap(err, "setting sender")
+	}
+
+	for _, to := range s.to {
+		if err := client.Rcpt(to); err != nil {
+			return errors.Wrapf(err, "adding recipient %q", to)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return errors.Wrap(err, "starting message")
+	}
+
+	if _, err := w.Write(s.message(event)); err != nil {
+		return errors.Wrap(err, "writing message")
+	}
+
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "sending message")
+	}
+
+	return client.Quit()
+}
+
+// dial connects to the mail server, using TLS if it's configured.
+func (s *smtpNotifier) dial(ctx context.Context) (net.Conn, error) {
+	if s.tlsConfig != nil {
+		dialer := &tls.Dialer{Config: s.tlsConfig}
+		return dialer.DialContext(ctx, "tcp", s.address)
+	}
+
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", s.address)
+}
+
+func (s *smtpNotifier) message(event Event) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", s.from)
+	fmt.Fprintf(&sb, "To: %s\r\n", strings.Join(s.to, ", "))
+	fmt.Fprintf(&sb, "Subject: %s\r\n", event.Title())
+	fmt.Fprintf(&sb, "Date: %s\r\n", event.Time.Format(time.RFC1123Z))
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	sb.WriteString(strings.ReplaceAll(event.Message(), "\n", "\r\n"))
+	sb.WriteString("\r\n")
+	return []byte(sb.String())
+}
@@ -0,0 +1,135 @@
+package notifier
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpServer is a minimal SMTP server that accepts a single message.
+type smtpServer struct {
+	listener   net.Listener
+	from       string
+	recipients []string
+	data       string
+	done       chan struct{}
+}
+
+func newSMTPServer(t *testing.T, tlsConfig *tls.Config) *smtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	s := &smtpServer{
+		listener: listener,
+		done:     make(chan struct{}),
+	}
+	go s.serve()
+
+	return s
+}
+
+func (s *smtpServer) serve() {
+	defer close(s.done)
+
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	text := textproto.NewConn(conn)
+	_ = text.PrintfLine("220 localhost ESMTP")
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		command, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(command) {
+		case "EHLO", "HELO":
+			_ = text.PrintfLine("250 localhost")
+		case "MAIL":
+			s.from = arg
+			_ = text.PrintfLine("250 OK")
+		case "RCPT":
+			s.recipients = append(s.recipients, arg)
+			_ = text.PrintfLine("250 OK")
+		case "DATA":
+			_ = text.PrintfLine("354 Start mail input")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.data = string(data)
+			_ = text.PrintfLine("250 OK")
+		case "QUIT":
+			_ = text.PrintfLine("221 Bye")
+			return
+		default:
+			_ = text.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTP(t *testing.T) {
+	server := newSMTPServer(t, nil)
+	defer server.listener.Close()
+
+	config := config.SMTP{
+		Address: server.listener.Addr().String(),
+		From:    "hydrus@example.com",
+		To:      []string{"alice@example.com", "bob@example.com"},
+	}
+	event := NewChannelClosed("txid:0", "closing_txid", false)
+
+	smtp := newSMTP(config)
+	err := smtp.Notify(t.Context(), event)
+	require.NoError(t, err)
+	<-server.done
+
+	assert.Equal(t, "FROM:<hydrus@example.com>", server.from)
+	assert.Equal(t, []string{"TO:<alice@example.com>", "TO:<bob@example.com>"}, server.recipients)
+	assert.Contains(t, server.data, "Subject: Hydrus closed a channel\n")
+	assert.Contains(t, server.data, "To: alice@example.com, bob@example.com\n")
+	assert.Contains(t, server.data, "\nCooperatively closing channel txid:0 in transaction closing_txid\n")
+}
+
+func TestSMTPImplicitTLS(t *testing.T) {
+	// Reuse the test certificate of the HTTP server, valid for 127.0.0.1
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+
+	server := newSMTPServer(t, &tls.Config{Certificates: tlsServer.TLS.Certificates})
+	defer server.listener.Close()
+
+	config := config.SMTP{
+		Address: server.listener.Addr().String(),
+		From:    "hydrus@example.com",
+		To:      []string{"alice@example.com"},
+		TLS:     true,
+	}
+	event := NewChannelClosed("txid:0", "closing_txid", true)
+
+	smtp := newSMTP(config)
+	smtp.tlsConfig.RootCAs = tlsServer.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	err := smtp.Notify(t.Context(), event)
+	require.NoError(t, err)
+	<-server.done
+
+	assert.Equal(t, []string{"TO:<alice@example.com>"}, server.recipients)
+	assert.Contains(t, server.data, "\nForce closing channel txid:0 in transaction closing_txid\n")
+}
@@ -0,0 +1,61 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/pkg/errors"
+)
+
+const telegramAPIURL = "https://api.telegram.org"
+
+// telegram sends the events as messages from a bot to a chat.
+type telegram struct {
+	client *http.Client
+	apiURL string
+	token  string
+	chatID string
+}
+
+type telegramMessage struct {
+	ChatID string `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+func newTelegram(config config.Telegram, client *http.Client) *telegram {
+	return &telegram{
+		client: client,
+		apiURL: telegramAPIURL,
+		token:  config.Token,
+		chatID: config.ChatID,
+	}
+}
+
+func (t *telegram) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(telegramMessage{
+		ChatID: t.chatID,
+		Text:   event.Title() + "\n\n" + event.Message(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "encoding message")
+	}
+
+	url := t.apiURL + "/bot" + t.token + "/sendMessage"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "creating request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Hide the token from the errors, the URL is included in them
+	if err := send(t.client, req); err != nil {
+		return errors.New(strings.ReplaceAll(err.Error(), t.token, "<token>"))
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package notifier
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelegram(t *testing.T) {
+	event := NewRunSkipped("channels", "agent is paused")
+
+	var received telegramMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bottoken/sendMessage", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	telegram := newTelegram(config.Telegram{Token: "token", ChatID: "1234"}, server.Client())
+	telegram.apiURL = server.URL
+
+	err := telegram.Notify(t.Context(), event)
+	require.NoError(t, err)
+
+	assert.Equal(t, "1234", received.ChatID)
+	assert.Equal(t, event.Title()+"\n\n"+event.Message(), received.Text)
+}
+
+func TestTelegramHidesToken(t *testing.T) {
+	telegram := newTelegram(config.Telegram{Token: "secret", ChatID: "1234"}, http.DefaultClient)
+	// Nothing listens on port 1
+	telegram.apiURL = "http://127.0.0.1:1"
+
+	err := telegram.Notify(t.Context(), NewRunSkipped("channels", "paused"))
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+}
@@ -0,0 +1,40 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/pkg/errors"
+)
+
+// webhook sends the events as JSON in the body of a POST request.
+type webhook struct {
+	client *http.Client
+	url    string
+}
+
+func newWebhook(config config.Webhook, client *http.Client) *webhook {
+	return &webhook{
+		client: client,
+		url:    config.URL,
+	}
+}
+
+func (w *webhook) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "encoding event")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "creating request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return send(w.client, req)
+}
@@ -0,0 +1,44 @@
+package notifier
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aftermath2/hydrus/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook(t *testing.T) {
+	event := NewChannelsOpened("txid", map[string]uint64{"public_key": 1_000_000})
+
+	var received Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	webhook := newWebhook(config.Webhook{URL: server.URL}, server.Client())
+	err := webhook.Notify(t.Context(), event)
+	require.NoError(t, err)
+
+	assert.Equal(t, EventChannelsOpened, received.Type)
+	assert.Equal(t, event.TxID, received.TxID)
+	assert.Equal(t, event.Channels, received.Channels)
+}
+
+func TestWebhookError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	webhook := newWebhook(config.Webhook{URL: server.URL}, server.Client())
+	err := webhook.Notify(t.Context(), NewRunSkipped("channels", "paused"))
+	assert.EqualError(t, err, "unexpected status code 503: unavailable")
+}
@@ -0,0 +1,315 @@
+// Package report builds the accounting of the node's channels over a period of time: the routing fees they
+// earned and the on-chain and rebalancing costs attributed to them.
+package report
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aftermath2/hydrus/channel"
+	"github.com/aftermath2/hydrus/graph"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+)
+
+const (
+	// Average time between blocks
+	blockInterval = 10 * time.Minute
+	daysPerYear   = 365
+	oneDay        = 24 * time.Hour
+)
+
+// Input contains the node information the report is built from.
+type Input struct {
+	Start time.Time
+	End   time.Time
+	// Used to estimate the time of the blocks channels were opened or closed at
+	Now                time.Time
+	CurrentBlockHeight uint32
+	PublicKey          string
+	Channels           []*lnrpc.Channel
+	ClosedChannels     []*lnrpc.ChannelCloseSummary
+	Transactions       []*lnrpc.Transaction
+	Forwards           []*lnrpc.ForwardingEvent
+	Payments           []*lnrpc.Payment
+}
+
+// Report contains the accounting of the channels that were open during the period.
+type Report struct {
+	Start    time.Time       `json:"start"`
+	End      time.Time       `json:"end"`
+	Channels []ChannelReport `json:"channels"`
+	Total    Summary         `json:"total"`
+}
+
+// ChannelReport contains the accounting of a channel.
+type ChannelReport struct {
+	Point           string `json:"point"`
+	RemotePublicKey string `json:"remote_public_key"`
+	ID              uint64 `json:"id"`
+	Closed          bool   `json:"closed"`
+	Summary
+}
+
+// Summary contains the accounting figures of one or more channels.
+type Summary struct {
+	// Capacity in satoshis
+	Capacity uint64 `json:"capacity"`
+	// Days the channels were open within the period
+	Days float64 `json:"days"`
+	// Fees earned by the forwards that arrived through the channels
+	FeesInMsat uint64 `json:"fees_in_msat"`
+	// Fees earned by the forwards that left through the channels
+	FeesOutMsat uint64 `json:"fees_out_msat"`
+	// Fees of the funding transactions created by Hydrus
+	OpenCostMsat uint64 `json:"open_cost_msat"`
+	// Fees of the closing transactions of the channels opened by Hydrus
+	CloseCostMsat uint64 `json:"close_cost_msat"`
+	// Fees of the circular payments that refilled the channels
+	RebalanceCostMsat uint64 `json:"rebalance_cost_msat"`
+	// Fees earned on the outgoing leg minus the costs
+	NetReturnMsat int64 `json:"net_return_msat"`
+	// Net return over the capacity, extrapolated to a year
+	AnnualizedYield float64 `json:"annualized_yield"`
+}
+
+// channelInfo contains the lifetime of a channel.
+type channelInfo struct {
+	report    ChannelReport
+	openTime  time.Time
+	closeTime time.Time
+	// Whether the funding transaction was created by Hydrus
+	hydrus bool
+}
+
+// Build returns the accounting of the node's channels within the period.
+//
+// Routing fees are attributed to both legs of the forwards but only the outgoing one counts towards the net
+// return, so that the node total is the sum of its channels.
+func Build(in Input) Report {
+	transactions := make(map[string]*lnrpc.Transaction, len(in.Transactions))
+	for _, tx := range in.Transactions {
+		transactions[tx.TxHash] = tx
+	}
+
+	channels := in.getChannels(transactions)
+	indices := make(map[uint64]int, len(channels))
+	for i, ch := range channels {
+		indices[ch.report.ID] = i
+	}
+
+	in.addOnChainCosts(channels, transactions)
+
+	for _, forward := range in.Forwards {
+		if !in.contains(time.Unix(0, int64(forward.TimestampNs))) {
+			continue
+		}
+		if i, ok := indices[forward.ChanIdIn]; ok {
+			channels[i].report.FeesInMsat += forward.FeeMsat
+		}
+		if i, ok := indices[forward.ChanIdOut]; ok {
+			channels[i].report.FeesOutMsat += forward.FeeMsat
+		}
+	}
+
+	for _, payment := range in.Payments {
+		if !in.contains(time.Unix(0, payment.CreationTimeNs)) {
+			continue
+		}
+		for _, htlc := range payment.Htlcs {
+			if htlc.Status != lnrpc.HTLCAttempt_SUCCEEDED || htlc.Route == nil || len(htlc.Route.Hops) == 0 {
+				continue
+			}
+
+			// Payments to ourselves are rebalances, the cost is attributed to the channel that received them
+			lastHop := htlc.Route.Hops[len(htlc.Route.Hops)-1]
+			if lastHop.PubKey != in.PublicKey {
+				continue
+			}
+			if i, ok := indices[lastHop.ChanId]; ok {
+				channels[i].report.RebalanceCostMsat += uint64(htlc.Route.TotalFeesMsat)
+			}
+		}
+	}
+
+	report := Report{
+		Start:    in.Start,
+		End:      in.End,
+		Channels: make([]ChannelReport, 0, len(channels)),
+	}
+	capacityDays := 0.0
+	for _, ch := range channels {
+		r := ch.report
+		r.NetReturnMsat = int64(r.FeesOutMsat) -
+			int64(r.OpenCostMsat) - int64(r.CloseCostMsat) - int64(r.RebalanceCostMsat)
+		r.AnnualizedYield = annualizedYield(r.NetReturnMsat, float64(r.Capacity)*r.Days)
+		report.Channels = append(report.Channels, r)
+
+		total := &report.Total
+		total.Capacity += r.Capacity
+		total.FeesInMsat += r.FeesInMsat
+		total.FeesOutMsat += r.FeesOutMsat
+		total.OpenCostMsat += r.OpenCostMsat
+		total.CloseCostMsat += r.CloseCostMsat
+		total.RebalanceCostMsat += r.RebalanceCostMsat
+		total.NetReturnMsat += r.NetReturnMsat
+		capacityDays += float64(r.Capacity) * r.Days
+	}
+	report.Total.Days = float64(in.End.Sub(in.Start)) / float64(oneDay)
+	report.Total.AnnualizedYield = annualizedYield(report.Total.NetReturnMsat, capacityDays)
+
+	slices.SortStableFunc(report.Channels, func(a, b ChannelReport) int {
+		if c := cmp.Compare(b.NetReturnMsat, a.NetReturnMsat); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return report
+}
+
+// getChannels returns the open and closed channels whose lifetime overlaps with the period.
+func (in Input) getChannels(transactions map[string]*lnrpc.Transaction) []channelInfo {
+	channels := make([]channelInfo, 0, len(in.Channels)+len(in.ClosedChannels))
+	add := func(ch channelInfo) {
+		ch.openTime = in.txTime(transactions, fundingTxID(ch.report.Point),
+			graph.GetChannelBlockHeight(ch.report.ID))
+		tx, ok := transactions[fundingTxID(ch.report.Point)]
+		ch.hydrus = ok && tx.Label == channel.TransactionLabel
+
+		start := later(in.Start, ch.openTime)
+		end := in.End
+		if ch.report.Closed && ch.closeTime.Before(end) {
+			end = ch.closeTime
+		}
+		if !start.Before(end) {
+			return
+		}
+
+		ch.report.Days = float64(end.Sub(start)) / float64(oneDay)
+		channels = append(channels, ch)
+	}
+
+	for _, c := range in.Channels {
+		add(channelInfo{
+			report: ChannelReport{
+				Point:           c.ChannelPoint,
+				RemotePublicKey: c.RemotePubkey,
+				ID:              c.ChanId,
+				Summary:         Summary{Capacity: uint64(c.Capacity)},
+			},
+		})
+	}
+
+	for _, c := range in.ClosedChannels {
+		// The channels that were never confirmed have no activity nor closing transaction
+		if c.CloseType == lnrpc.ChannelCloseSummary_FUNDING_CANCELED ||
+			c.CloseType == lnrpc.ChannelCloseSummary_ABANDONED {
+			continue
+		}
+
+		add(channelInfo{
+			report: ChannelReport{
+				Point:           c.ChannelPoint,
+				RemotePublicKey: c.RemotePubkey,
+				ID:              c.ChanId,
+				Closed:          true,
+				Summary:         Summary{Capacity: uint64(c.Capacity)},
+			},
+			closeTime: in.txTime(transactions, c.ClosingTxHash, c.CloseHeight),
+		})
+	}
+
+	return channels
+}
+
+// addOnChainCosts attributes the fees of the funding transactions created by Hydrus to the channels they
+// opened, in proportion to their capacity, and the fees of their closing transactions.
+func (in Input) addOnChainCosts(channels []channelInfo, transactions map[string]*lnrpc.Transaction) {
+	// Capacity opened by each funding transaction
+	batchCapacity := make(map[string]uint64)
+	for _, ch := range channels {
+		if ch.hydrus {
+			batchCapacity[fundingTxID(ch.report.Point)] += ch.report.Capacity
+		}
+	}
+
+	for i, ch := range channels {
+		if !ch.hydrus {
+			continue
+		}
+
+		fundingTx := transactions[fundingTxID(ch.report.Point)]
+		if in.contains(time.Unix(fundingTx.TimeStamp, 0)) && batchCapacity[fundingTxID(ch.report.Point)] > 0 {
+			share := float64(ch.report.Capacity) / float64(batchCapacity[fundingTxID(ch.report.Point)])
+			channels[i].report.OpenCostMsat = uint64(float64(fundingTx.TotalFees)*share) * 1000
+		}
+
+		if !ch.report.Closed || !in.contains(ch.closeTime) {
+			continue
+		}
+
+		// The closing transaction only spends the funding output, its fee is what the outputs don't take and
+		// it's paid by the node that opened the channel
+		if closeTx := in.closingTx(transactions, ch.report.Point); closeTx != nil {
+			outputs := uint64(0)
+			for _, output := range closeTx.OutputDetails {
+				outputs += uint64(output.Amount)
+			}
+			if outputs < ch.report.Capacity {
+				channels[i].report.CloseCostMsat = (ch.report.Capacity - outputs) * 1000
+			}
+		}
+	}
+}
+
+func (in Input) closingTx(transactions map[string]*lnrpc.Transaction, channelPoint string) *lnrpc.Transaction {
+	i := slices.IndexFunc(in.ClosedChannels, func(c *lnrpc.ChannelCloseSummary) bool {
+		return c.ChannelPoint == channelPoint
+	})
+	if i == -1 {
+		return nil
+	}
+
+	return transactions[in.ClosedChannels[i].ClosingTxHash]
+}
+
+// txTime returns the time of the transaction if it's known or estimates it from the block height.
+func (in Input) txTime(transactions map[string]*lnrpc.Transaction, txID string, blockHeight uint32) time.Time {
+	if tx, ok := transactions[txID]; ok && tx.TimeStamp > 0 {
+		return time.Unix(tx.TimeStamp, 0)
+	}
+
+	if blockHeight == 0 || blockHeight > in.CurrentBlockHeight {
+		return in.Now
+	}
+
+	return in.Now.Add(-time.Duration(in.CurrentBlockHeight-blockHeight) * blockInterval)
+}
+
+// contains returns whether the time is within the period.
+func (in Input) contains(t time.Time) bool {
+	return !t.Before(in.Start) && t.Before(in.End)
+}
+
+func annualizedYield(netReturnMsat int64, capacityDays float64) float64 {
+	if capacityDays == 0 {
+		return 0
+	}
+
+	return float64(netReturnMsat) / 1000 / capacityDays * daysPerYear
+}
+
+func fundingTxID(channelPoint string) string {
+	txID, _, _ := strings.Cut(channelPoint, ":")
+	return txID
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,164 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aftermath2/hydrus/channel"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuild(t *testing.T) {
+	end := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	start := end.Add(-10 * oneDay)
+	in := Input{
+		Start:              start,
+		End:                end,
+		Now:                end,
+		CurrentBlockHeight: 900_000,
+		PublicKey:          "local",
+		Channels: []*lnrpc.Channel{
+			// Opened by Hydrus within the period, along with the closed one
+			{ChannelPoint: "batch:0", ChanId: 1, RemotePubkey: "alice", Capacity: 3_000_000},
+			// Opened by hand before the period
+			{ChannelPoint: "manual:0", ChanId: 2, RemotePubkey: "bob", Capacity: 1_000_000},
+		},
+		ClosedChannels: []*lnrpc.ChannelCloseSummary{
+			{
+				ChannelPoint:  "batch:1",
+				ChanId:        3,
+				RemotePubkey:  "carol",
+				Capacity:      1_000_000,
+				ClosingTxHash: "close",
+			},
+			{
+				ChannelPoint: "canceled:0",
+				ChanId:       4,
+				Capacity:     1_000_000,
+				CloseType:    lnrpc.ChannelCloseSummary_FUNDING_CANCELED,
+			},
+		},
+		Transactions: []*lnrpc.Transaction{
+			{TxHash: "batch", TimeStamp: start.Add(oneDay).Unix(), TotalFees: 800, Label: channel.TransactionLabel},
+			{TxHash: "manual", TimeStamp: start.Add(-oneDay).Unix(), TotalFees: 500},
+			{
+				TxHash:    "close",
+				TimeStamp: start.Add(6 * oneDay).Unix(),
+				OutputDetails: []*lnrpc.OutputDetail{
+					{Amount: 600_000, IsOurAddress: true},
+					{Amount: 399_700},
+				},
+			},
+		},
+		Forwards: []*lnrpc.ForwardingEvent{
+			{ChanIdIn: 2, ChanIdOut: 1, FeeMsat: 10_000, TimestampNs: uint64(start.Add(2 * oneDay).UnixNano())},
+			{ChanIdIn: 1, ChanIdOut: 2, FeeMsat: 4_000, TimestampNs: uint64(start.Add(3 * oneDay).UnixNano())},
+			{ChanIdIn: 3, ChanIdOut: 1, FeeMsat: 1_000, TimestampNs: uint64(start.Add(4 * oneDay).UnixNano())},
+			// Outside of the period
+			{ChanIdIn: 2, ChanIdOut: 1, FeeMsat: 50_000, TimestampNs: uint64(start.Add(-oneDay).UnixNano())},
+		},
+		Payments: []*lnrpc.Payment{
+			{
+				CreationTimeNs: start.Add(5 * oneDay).UnixNano(),
+				Htlcs: []*lnrpc.HTLCAttempt{
+					{
+						Status: lnrpc.HTLCAttempt_SUCCEEDED,
+						Route: &lnrpc.Route{
+							TotalFeesMsat: 2_500,
+							Hops:          []*lnrpc.Hop{{ChanId: 1, PubKey: "alice"}, {ChanId: 2, PubKey: "local"}},
+						},
+					},
+				},
+			},
+			{
+				// Regular payment
+				CreationTimeNs: start.Add(5 * oneDay).UnixNano(),
+				Htlcs: []*lnrpc.HTLCAttempt{
+					{
+						Status: lnrpc.HTLCAttempt_SUCCEEDED,
+						Route:  &lnrpc.Route{TotalFeesMsat: 1_000, Hops: []*lnrpc.Hop{{ChanId: 1, PubKey: "alice"}}},
+					},
+				},
+			},
+		},
+	}
+
+	report := Build(in)
+
+	expected := []ChannelReport{
+		{
+			Point:           "manual:0",
+			RemotePublicKey: "bob",
+			ID:              2,
+			Summary: Summary{
+				Capacity:          1_000_000,
+				Days:              10,
+				FeesInMsat:        10_000,
+				FeesOutMsat:       4_000,
+				RebalanceCostMsat: 2_500,
+				NetReturnMsat:     1_500,
+				AnnualizedYield:   1.5 / 10_000_000 * daysPerYear,
+			},
+		},
+		{
+			Point:           "batch:1",
+			RemotePublicKey: "carol",
+			ID:              3,
+			Closed:          true,
+			Summary: Summary{
+				Capacity:        1_000_000,
+				Days:            5,
+				FeesInMsat:      1_000,
+				OpenCostMsat:    200_000,
+				CloseCostMsat:   300_000,
+				NetReturnMsat:   -500_000,
+				AnnualizedYield: -500.0 / 5_000_000 * daysPerYear,
+			},
+		},
+		{
+			Point:           "batch:0",
+			RemotePublicKey: "alice",
+			ID:              1,
+			Summary: Summary{
+				Capacity:      3_000_000,
+				Days:          9,
+				FeesInMsat:    4_000,
+				FeesOutMsat:   11_000,
+				OpenCostMsat:  600_000,
+				NetReturnMsat: -589_000,
+				// The open cost is spread over the days open within the period
+				AnnualizedYield: -589.0 / 27_000_000 * daysPerYear,
+			},
+		},
+	}
+
+	assert.Len(t, report.Channels, len(expected))
+	for i, channel := range report.Channels {
+		assert.InDelta(t, expected[i].AnnualizedYield, channel.AnnualizedYield, 1e-9)
+		assert.InDelta(t, expected[i].Days, channel.Days, 1e-9)
+		channel.AnnualizedYield = expected[i].AnnualizedYield
+		channel.Days = expected[i].Days
+		assert.Equal(t, expected[i], channel)
+	}
+
+	assert.Equal(t, uint64(5_000_000), report.Total.Capacity)
+	assert.Equal(t, float64(10), report.Total.Days)
+	assert.Equal(t, uint64(15_000), report.Total.FeesInMsat)
+	assert.Equal(t, uint64(15_000), report.Total.FeesOutMsat)
+	assert.Equal(t, uint64(800_000), report.Total.OpenCostMsat)
+	assert.Equal(t, uint64(300_000), report.Total.CloseCostMsat)
+	assert.Equal(t, uint64(2_500), report.Total.RebalanceCostMsat)
+	assert.Equal(t, int64(-1_087_500), report.Total.NetReturnMsat)
+	assert.InDelta(t, -1087.5/42_000_000*daysPerYear, report.Total.AnnualizedYield, 1e-9)
+}
+
+func TestBuildEmpty(t *testing.T) {
+	end := time.Now()
+	report := Build(Input{Start: end.Add(-oneDay), End: end, Now: end})
+
+	assert.Empty(t, report.Channels)
+	assert.Equal(t, float64(1), report.Total.Days)
+	assert.Zero(t, report.Total.AnnualizedYield)
+}